@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"backend_go/internal/config"
+	"backend_go/internal/service"
+)
+
+// usage prints the commands and how to get help on their flags.
+func usage() {
+	fmt.Fprint(os.Stderr, `usage: server [command] [flags]
+
+commands:
+  serve     serve the API (the default)
+  migrate   apply pending database migrations and exit
+  seed      load a seed dataset (-dataset, n5 by default) and exit
+  export    write all words and their groups as a seed dataset to -out and exit
+
+Run "server <command> -h" for the flags of a command.
+
+The database is the file given by -db, DB_PATH or words.db, in that order. DB_DSN, a go-sqlite3
+connection string such as "file:words.db?_busy_timeout=5000&cache=shared", takes precedence over
+all of them and is passed to the driver as is.
+
+APP_ENV is development (the default), production or test. It selects gin's mode and the log level,
+and in development serve fills an empty database with the demo words and groups.
+
+Every setting is read from the environment once, before the command runs, and documented on
+config.Config in internal/config. Invalid values are all reported and stop the command.
+`)
+}
+
+// newFlagSet returns the flag set of the named command. Invalid flags exit with status 2.
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ExitOnError)
+}
+
+// dbFlag defines the -db flag of a command, which overrides cfg.DBPath.
+func dbFlag(fs *flag.FlagSet, cfg *config.Config) {
+	fs.StringVar(&cfg.DBPath, "db", cfg.DBPath, "path of the SQLite database (DB_DSN takes precedence)")
+}
+
+// openMigrated opens the database of cfg and applies pending migrations, failing if they fail.
+func openMigrated(cfg *config.Config) (*service.Service, error) {
+	svc, err := service.Open(cfg.DataSource())
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	if err := service.Migrate(svc.DB); err != nil {
+		svc.Close()
+		return nil, fmt.Errorf("migrating database: %w", err)
+	}
+	svc.SetSeedSources(cfg.SeedFile, cfg.SeedDir)
+	return svc, nil
+}
+
+// runMigrate applies the pending migrations.
+func runMigrate(cfg *config.Config, args []string) error {
+	fs := newFlagSet("migrate")
+	dbFlag(fs, cfg)
+	fs.Parse(args)
+
+	svc, err := openMigrated(cfg)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+	fmt.Println("Migrations applied")
+	return nil
+}
+
+// runSeed loads a seed dataset and prints what it added.
+func runSeed(cfg *config.Config, args []string) error {
+	fs := newFlagSet("seed")
+	dbFlag(fs, cfg)
+	dataset := fs.String("dataset", service.DefaultSeedDataset, "name of the seed dataset to load")
+	fs.Parse(args)
+
+	svc, err := openMigrated(cfg)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	result, err := svc.LoadSeedDataset(*dataset)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownDataset) {
+			names, _ := svc.SeedDatasetNames()
+			return fmt.Errorf("unknown seed dataset %q, available: %v", *dataset, names)
+		}
+		return fmt.Errorf("loading seed dataset: %w", err)
+	}
+	return json.NewEncoder(os.Stdout).Encode(result)
+}
+
+// runExport writes every word and its groups to a JSON file in the seed dataset format, so that
+// it can be loaded again with seed (from SEED_DIR) or SEED_FILE.
+func runExport(cfg *config.Config, args []string) error {
+	fs := newFlagSet("export")
+	dbFlag(fs, cfg)
+	out := fs.String("out", "", `file to write, "-" for standard output`)
+	fs.Parse(args)
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "export requires -out")
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	svc, err := openMigrated(cfg)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	entries, err := svc.ExportSeedEntries()
+	if err != nil {
+		return fmt.Errorf("exporting words: %w", err)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d entries to %s\n", len(entries), *out)
+	return nil
+}
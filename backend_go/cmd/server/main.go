@@ -1,24 +1,83 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata"
 
+	"backend_go/internal/config"
 	"backend_go/internal/handlers"
+	"backend_go/internal/middleware"
 	"backend_go/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/acme/autocert"
 )
 
+// main runs the subcommand named by the first argument (see usage), serve when there is none, with
+// the configuration read from the environment. It exits with status 1 when the configuration is
+// invalid or the command fails and 2 when it is used incorrectly.
 func main() {
-	// Initialize the service with the SQLite database
-	svc, err := service.NewService("words.db")
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	commands := map[string]func(*config.Config, []string) error{
+		"serve":   runServe,
+		"migrate": runMigrate,
+		"seed":    runSeed,
+		"export":  runExport,
+	}
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Error initializing service: ", err)
+		log.Fatal(err)
+	}
+	if err := run(cfg, args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runServe initializes the service and serves the API until the process is interrupted.
+func runServe(cfg *config.Config, args []string) error {
+	fs := newFlagSet("serve")
+	dbFlag(fs, cfg)
+	fs.Parse(args)
+
+	// Initialize the service with the SQLite database, media storage, audio generation and the
+	// stats timezone of the configuration
+	svc, err := service.NewService(cfg)
+	if err != nil {
+		return fmt.Errorf("initializing service: %w", err)
 	}
 	defer svc.Close()
 
-	router := gin.Default()
+	// An empty database gets the demo words and groups in development, never in production
+	if cfg.SeedDemoData {
+		if err := svc.SeedData(); err != nil {
+			return fmt.Errorf("seeding database: %w", err)
+		}
+	}
+
+	if err := configureAuth(svc, cfg); err != nil {
+		return fmt.Errorf("configuring authentication: %w", err)
+	}
+
+	router := newRouter(cfg)
 
 	// Health check endpoint
 	router.GET("/ping", func(c *gin.Context) {
@@ -26,10 +85,127 @@ func main() {
 	})
 
 	// Register API routes and pass the service instance
-	handlers.RegisterRoutes(router, svc)
+	handlers.RegisterRoutes(router, svc, cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	servers := startServers(router, cfg)
+
+	// Study reminders are checked every minute and posted to the webhook of the reminder settings
+	remindersDone := make(chan struct{})
+	go func() {
+		defer close(remindersDone)
+		svc.RunReminders(ctx, &http.Client{Timeout: 10 * time.Second}, service.ReminderCheckInterval)
+	}()
+
+	// Study sessions started and left without reviews are cleaned up alongside the reminders
+	cleanupDone := make(chan struct{})
+	go func() {
+		defer close(cleanupDone)
+		if cfg.SessionCleanupInterval > 0 {
+			svc.RunSessionCleanup(ctx, cfg.SessionCleanupInterval, cfg.SessionAbandonAfter, cfg.SessionCleanupDelete)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down server...")
+	<-remindersDone
+	<-cleanupDone
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Println("Error shutting down server: ", err)
+		}
+	}
+	return nil
+}
+
+// configureAuth enables JWT login when cfg has a JWT secret, with its access and refresh token
+// lifetimes. When cfg has an admin username and password, an admin account is created on first start.
+func configureAuth(svc *service.Service, cfg *config.Config) error {
+	if cfg.JWTSecret == "" {
+		return nil
+	}
+	svc.EnableAuth(service.AuthConfig{Secret: []byte(cfg.JWTSecret), AccessTTL: cfg.JWTAccessTTL, RefreshTTL: cfg.JWTRefreshTTL})
+
+	if cfg.AdminUsername != "" && cfg.AdminPassword != "" {
+		return svc.EnsureAdminUser(cfg.AdminUsername, cfg.AdminPassword)
+	}
+	return nil
+}
+
+// newRouter builds the gin engine for the mode of cfg.
+//
+// In release mode gin's debug output is silenced and requests are logged as JSON lines through
+// slog. Otherwise requests go to gin's colorized console logger. In both modes panics are answered
+// with a JSON 500 and slog drops records below cfg.LogLevel. The engine is built with gin.New so
+// every middleware is listed here.
+func newRouter(cfg *config.Config) *gin.Engine {
+	gin.SetMode(cfg.GinMode)
+
+	opts := &slog.HandlerOptions{Level: cfg.LogLevel}
+	router := gin.New()
+	if cfg.GinMode != gin.ReleaseMode {
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, opts)))
+		router.Use(gin.Logger(), middleware.RequestID(), middleware.Recovery())
+		return router
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, opts))
+	slog.SetDefault(logger)
+	router.Use(middleware.RequestID(), middleware.JSONLogger(logger), middleware.Recovery())
+	return router
+}
+
+// startServers starts the listeners selected by cfg and returns them so they can be shut down.
+//
+// By default the API is served over plain HTTP on cfg.Port. A TLS certificate pair serves it over
+// HTTPS on that port instead. An autocert host obtains certificates from Let's Encrypt for that
+// hostname (cached in cfg.AutocertCacheDir), serving HTTPS on :443 and answering HTTP-01
+// challenges on :80, which redirects everything else to HTTPS.
+func startServers(handler http.Handler, cfg *config.Config) []*http.Server {
+	addr := fmt.Sprintf(":%d", cfg.Port)
+
+	switch {
+	case cfg.AutocertHost != "":
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertHost),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+		}
+
+		// A nil fallback handler makes the challenge listener redirect all other requests to HTTPS
+		challengeSrv := &http.Server{Addr: ":80", Handler: manager.HTTPHandler(nil)}
+		tlsSrv := &http.Server{Addr: ":443", Handler: handler, TLSConfig: manager.TLSConfig()}
+
+		log.Printf("Server is running on port 443 with Let's Encrypt certificates for %s", cfg.AutocertHost)
+		go serve(challengeSrv.ListenAndServe)
+		go serve(func() error { return tlsSrv.ListenAndServeTLS("", "") })
+		return []*http.Server{tlsSrv, challengeSrv}
+
+	case cfg.TLSCertFile != "":
+		srv := &http.Server{Addr: addr, Handler: handler}
+
+		log.Printf("Server is running on port %d with TLS", cfg.Port)
+		go serve(func() error { return srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile) })
+		return []*http.Server{srv}
+
+	default:
+		srv := &http.Server{Addr: addr, Handler: handler}
+
+		log.Printf("Server is running on port %d", cfg.Port)
+		go serve(srv.ListenAndServe)
+		return []*http.Server{srv}
+	}
+}
 
-	log.Println("Server is running on port 8080")
-	if err := router.Run(":8080"); err != nil {
+// serve runs a blocking listen function and exits the process if the listener fails for any reason
+// other than a graceful shutdown.
+func serve(listen func() error) {
+	if err := listen(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal("Error starting server: ", err)
 	}
 }
@@ -1,8 +1,6 @@
 -- 0001_init.sql
 -- Migration to initialize the database schema
 
-BEGIN TRANSACTION;
-
 -- Create words table
 CREATE TABLE IF NOT EXISTS words (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -56,5 +54,3 @@ CREATE TABLE IF NOT EXISTS word_review_items (
     FOREIGN KEY (word_id) REFERENCES words(id),
     FOREIGN KEY (study_session_id) REFERENCES study_sessions(id)
 );
-
-COMMIT; 
\ No newline at end of file
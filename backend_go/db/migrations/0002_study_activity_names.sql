@@ -0,0 +1,5 @@
+-- 0002_study_activity_names.sql
+-- Give study activities a name so that a single activity (e.g. "Vocabulary Quiz")
+-- can be shared by every study session that uses it
+
+ALTER TABLE study_activities ADD COLUMN name TEXT NOT NULL DEFAULT '';
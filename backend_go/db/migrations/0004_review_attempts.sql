@@ -0,0 +1,30 @@
+-- 0004_review_attempts.sql
+-- Allow a word to be reviewed again in the same session only as an explicit new attempt.
+-- word_review_items is rebuilt with an attempt number, keeping the latest review of each
+-- word per session, and (study_session_id, word_id, attempt) is made unique.
+
+CREATE TABLE word_review_items_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_id INTEGER NOT NULL,
+    study_session_id INTEGER NOT NULL,
+    correct BOOLEAN NOT NULL,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (word_id) REFERENCES words(id),
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id)
+);
+
+INSERT INTO word_review_items_new (word_id, study_session_id, correct, attempt, created_at)
+SELECT word_id, study_session_id, correct, 1, created_at
+FROM word_review_items
+WHERE rowid IN (
+    SELECT MAX(rowid) FROM word_review_items GROUP BY study_session_id, word_id
+)
+ORDER BY created_at, rowid;
+
+DROP TABLE word_review_items;
+
+ALTER TABLE word_review_items_new RENAME TO word_review_items;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_word_review_items_session_word_attempt
+    ON word_review_items (study_session_id, word_id, attempt);
@@ -0,0 +1,18 @@
+-- 0005_tags.sql
+-- Freeform labels such as "needs-practice" or "lesson-3" that can be attached to words.
+-- Unlike groups, tags carry no study sessions.
+
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS word_tags (
+    word_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (word_id, tag_id),
+    FOREIGN KEY (word_id) REFERENCES words(id),
+    FOREIGN KEY (tag_id) REFERENCES tags(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_tags_tag_id ON word_tags (tag_id);
@@ -0,0 +1,5 @@
+-- 0006_review_created_at_index.sql
+-- Covering index for scanning reviews by date range, newest first
+
+CREATE INDEX IF NOT EXISTS idx_word_review_items_created_at
+    ON word_review_items (created_at, word_id, study_session_id, correct);
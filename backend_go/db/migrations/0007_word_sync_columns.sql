@@ -0,0 +1,11 @@
+-- 0007_word_sync_columns.sql
+-- Track when each word last changed and soft-delete words instead of removing them,
+-- so offline clients can pull deltas that include deletions
+
+ALTER TABLE words ADD COLUMN updated_at DATETIME;
+
+ALTER TABLE words ADD COLUMN deleted_at DATETIME;
+
+UPDATE words SET updated_at = CURRENT_TIMESTAMP WHERE updated_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_words_updated_at ON words (updated_at);
@@ -0,0 +1,15 @@
+-- 0009_word_srs.sql
+-- Spaced repetition schedule of each reviewed word. Rows are recomputed from the word's
+-- review history whenever it changes, so words without a row have never been reviewed.
+
+CREATE TABLE IF NOT EXISTS word_srs (
+    word_id INTEGER PRIMARY KEY,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    ease_factor REAL NOT NULL DEFAULT 2.5,
+    last_reviewed_at DATETIME NOT NULL,
+    next_review_at DATETIME NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_srs_next_review_at ON word_srs (next_review_at);
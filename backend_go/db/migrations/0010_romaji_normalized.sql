@@ -0,0 +1,8 @@
+-- 0010_romaji_normalized.sql
+-- Shadow column holding the normalized romaji of each word (see service.NormalizeRomaji), so romaji
+-- search and answer checking can match "kyō", "kyou" and "KYOU" through an index.
+-- Existing rows are backfilled by the application after migrations run.
+
+ALTER TABLE words ADD COLUMN romaji_normalized TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_words_romaji_normalized ON words (romaji_normalized);
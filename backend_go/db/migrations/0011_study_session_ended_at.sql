@@ -0,0 +1,6 @@
+-- 0011_study_session_ended_at.sql
+-- Sessions are open until ended_at is set, either explicitly or when they go stale.
+
+ALTER TABLE study_sessions ADD COLUMN ended_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_study_sessions_open ON study_sessions (ended_at, created_at);
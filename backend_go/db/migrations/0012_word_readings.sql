@@ -0,0 +1,4 @@
+-- 0012_word_readings.sql
+-- Kana reading (furigana) of words whose japanese text contains kanji. Empty until filled in.
+
+ALTER TABLE words ADD COLUMN reading TEXT;
@@ -0,0 +1,17 @@
+-- 0013_word_meanings.sql
+-- English meanings of each word in display order. words.english keeps the primary meaning
+-- (position 0) for clients that only read a single string.
+
+CREATE TABLE IF NOT EXISTS word_meanings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_id INTEGER NOT NULL,
+    meaning TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id),
+    UNIQUE (word_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_meanings_meaning ON word_meanings (meaning COLLATE NOCASE);
+
+INSERT INTO word_meanings (word_id, meaning, position)
+SELECT id, english, 0 FROM words WHERE TRIM(english) <> '';
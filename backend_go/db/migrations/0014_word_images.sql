@@ -0,0 +1,6 @@
+-- 0014_word_images.sql
+-- File name of a word's picture in the media directory, NULL when the word has none.
+
+ALTER TABLE words ADD COLUMN image_path TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_words_image_path ON words (image_path);
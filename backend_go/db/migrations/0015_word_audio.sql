@@ -0,0 +1,6 @@
+-- 0015_word_audio.sql
+-- File name of a word's pronunciation recording in the media directory, NULL when it has none.
+
+ALTER TABLE words ADD COLUMN audio_path TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_words_audio_path ON words (audio_path);
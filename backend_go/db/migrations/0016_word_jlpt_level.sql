@@ -0,0 +1,5 @@
+-- JLPT level of a word, 1 (N1) to 5 (N5), NULL when it is not known.
+
+ALTER TABLE words ADD COLUMN jlpt_level INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_words_jlpt_level ON words (jlpt_level);
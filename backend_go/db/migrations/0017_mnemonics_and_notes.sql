@@ -0,0 +1,5 @@
+-- Learner-written memory aid of a word and free-text notes on a study session, NULL when empty.
+
+ALTER TABLE words ADD COLUMN mnemonic TEXT;
+
+ALTER TABLE study_sessions ADD COLUMN notes TEXT;
@@ -0,0 +1,36 @@
+-- Split english values holding several meanings separated by semicolons or slashes into one
+-- word_meanings row each. Existing extra meanings are kept after the split ones, repeated meanings
+-- are dropped, and words.english is set to the new primary meaning. char(59) is the semicolon,
+-- written that way because migration files are split into statements on it.
+
+CREATE TABLE word_meanings_split AS
+WITH RECURSIVE split(word_id, rest, meaning, n) AS (
+    SELECT id, REPLACE(english, '/', char(59)) || char(59), '', -1
+    FROM words
+    WHERE instr(english, char(59)) > 0 OR instr(english, '/') > 0
+    UNION ALL
+    SELECT word_id, substr(rest, instr(rest, char(59)) + 1), TRIM(substr(rest, 1, instr(rest, char(59)) - 1)), n + 1
+    FROM split
+    WHERE rest <> ''
+)
+SELECT word_id, meaning, n FROM split WHERE n >= 0 AND meaning <> '';
+
+INSERT INTO word_meanings_split (word_id, meaning, n)
+SELECT wm.word_id, wm.meaning, 1000000 + wm.position
+FROM word_meanings wm
+WHERE wm.position > 0 AND wm.word_id IN (SELECT word_id FROM word_meanings_split);
+
+DELETE FROM word_meanings_split
+WHERE rowid NOT IN (SELECT MIN(rowid) FROM word_meanings_split GROUP BY word_id, meaning);
+
+DELETE FROM word_meanings WHERE word_id IN (SELECT word_id FROM word_meanings_split);
+
+INSERT INTO word_meanings (word_id, meaning, position)
+SELECT word_id, meaning, ROW_NUMBER() OVER (PARTITION BY word_id ORDER BY n) - 1
+FROM word_meanings_split;
+
+UPDATE words
+SET english = (SELECT wm.meaning FROM word_meanings wm WHERE wm.word_id = words.id AND wm.position = 0)
+WHERE id IN (SELECT word_id FROM word_meanings_split);
+
+DROP TABLE word_meanings_split;
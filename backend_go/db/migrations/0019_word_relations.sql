@@ -0,0 +1,16 @@
+-- Synonym, antonym and see-also links between words. Relations are symmetric and stored once per
+-- pair, with word_id always the smaller ID.
+
+CREATE TABLE IF NOT EXISTS word_relations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_id INTEGER NOT NULL,
+    related_word_id INTEGER NOT NULL,
+    relation_type TEXT NOT NULL CHECK (relation_type IN ('synonym', 'antonym', 'see_also')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (word_id) REFERENCES words(id),
+    FOREIGN KEY (related_word_id) REFERENCES words(id),
+    CHECK (word_id < related_word_id),
+    UNIQUE (word_id, related_word_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_relations_related_word_id ON word_relations (related_word_id);
@@ -0,0 +1,12 @@
+-- Example sentences using a word, with their english translation.
+
+CREATE TABLE IF NOT EXISTS word_examples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_id INTEGER NOT NULL,
+    japanese TEXT NOT NULL,
+    english TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_examples_word_id ON word_examples (word_id);
@@ -0,0 +1,15 @@
+-- 0022_group_timestamps.sql
+-- Record when each group was created and last edited, and who created it.
+-- Existing groups are backfilled with their first study session, or the migration time
+-- when they were never studied.
+
+ALTER TABLE groups ADD COLUMN created_at DATETIME;
+
+ALTER TABLE groups ADD COLUMN updated_at DATETIME;
+
+ALTER TABLE groups ADD COLUMN created_by TEXT;
+
+UPDATE groups SET created_at = COALESCE((SELECT MIN(ss.created_at) FROM study_sessions ss WHERE ss.group_id = groups.id), CURRENT_TIMESTAMP)
+WHERE created_at IS NULL;
+
+UPDATE groups SET updated_at = created_at WHERE updated_at IS NULL;
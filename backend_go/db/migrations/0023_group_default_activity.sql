@@ -0,0 +1,4 @@
+-- 0023_group_default_activity.sql
+-- A group may name the study activity that sessions started for it use by default.
+
+ALTER TABLE groups ADD COLUMN default_study_activity_id INTEGER REFERENCES study_activities(id);
@@ -0,0 +1,10 @@
+-- 0024_study_session_queue.sql
+-- A study session may start with a queue of words to review, in order, such as the words of the
+-- session it was duplicated from.
+
+CREATE TABLE IF NOT EXISTS study_session_queue (
+    study_session_id INTEGER NOT NULL REFERENCES study_sessions(id),
+    word_id INTEGER NOT NULL REFERENCES words(id),
+    position INTEGER NOT NULL,
+    PRIMARY KEY (study_session_id, word_id)
+);
@@ -0,0 +1,8 @@
+-- 0025_achievements.sql
+-- Achievements earned, one row per achievement key. Keys are defined in the service, so an
+-- achievement not earned yet has no row.
+
+CREATE TABLE IF NOT EXISTS achievements (
+    key TEXT PRIMARY KEY,
+    earned_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,9 @@
+-- 0026_user_scoping.sql
+-- Study sessions and reviews belong to the learner who made them. Rows made without a learner,
+-- including every row from before this migration, have no user_id.
+
+ALTER TABLE study_sessions ADD COLUMN user_id INTEGER REFERENCES users(id);
+ALTER TABLE word_review_items ADD COLUMN user_id INTEGER REFERENCES users(id);
+
+CREATE INDEX IF NOT EXISTS idx_study_sessions_user_id ON study_sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_word_review_items_user_id ON word_review_items (user_id);
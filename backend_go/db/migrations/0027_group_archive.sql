@@ -0,0 +1,5 @@
+-- 0027_group_archive.sql
+-- Archived groups are hidden from the group list, the recommendations and the stale groups by
+-- default. Their words and study history are kept.
+
+ALTER TABLE groups ADD COLUMN archived BOOLEAN NOT NULL DEFAULT 0;
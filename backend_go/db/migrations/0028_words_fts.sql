@@ -0,0 +1,55 @@
+-- 0028_words_fts.sql
+-- Full-text index of the words that are not deleted, one row per word with the word ID as docid.
+-- Meanings are indexed together, separated by " / ". The triggers keep the index in sync with
+-- words and word_meanings.
+
+CREATE VIRTUAL TABLE IF NOT EXISTS words_fts USING fts4(japanese, romaji, english, meanings, tokenize=unicode61);
+
+INSERT INTO words_fts (docid, japanese, romaji, english, meanings)
+SELECT w.id, w.japanese, w.romaji, w.english,
+       (SELECT group_concat(meaning, ' / ') FROM (SELECT meaning FROM word_meanings WHERE word_id = w.id ORDER BY position))
+FROM words w
+WHERE w.deleted_at IS NULL;
+
+CREATE TRIGGER IF NOT EXISTS words_fts_insert AFTER INSERT ON words
+WHEN NEW.deleted_at IS NULL
+BEGIN
+    INSERT INTO words_fts (docid, japanese, romaji, english, meanings)
+    VALUES (NEW.id, NEW.japanese, NEW.romaji, NEW.english,
+            (SELECT group_concat(meaning, ' / ') FROM (SELECT meaning FROM word_meanings WHERE word_id = NEW.id ORDER BY position)));
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_fts_update AFTER UPDATE OF japanese, romaji, english, deleted_at ON words
+BEGIN
+    DELETE FROM words_fts WHERE docid = OLD.id;
+    INSERT INTO words_fts (docid, japanese, romaji, english, meanings)
+    SELECT NEW.id, NEW.japanese, NEW.romaji, NEW.english,
+           (SELECT group_concat(meaning, ' / ') FROM (SELECT meaning FROM word_meanings WHERE word_id = NEW.id ORDER BY position))
+    WHERE NEW.deleted_at IS NULL;
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_fts_delete AFTER DELETE ON words
+BEGIN
+    DELETE FROM words_fts WHERE docid = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_fts_meaning_insert AFTER INSERT ON word_meanings
+BEGIN
+    UPDATE words_fts
+    SET meanings = (SELECT group_concat(meaning, ' / ') FROM (SELECT meaning FROM word_meanings WHERE word_id = NEW.word_id ORDER BY position))
+    WHERE docid = NEW.word_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_fts_meaning_update AFTER UPDATE OF meaning, position ON word_meanings
+BEGIN
+    UPDATE words_fts
+    SET meanings = (SELECT group_concat(meaning, ' / ') FROM (SELECT meaning FROM word_meanings WHERE word_id = NEW.word_id ORDER BY position))
+    WHERE docid = NEW.word_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_fts_meaning_delete AFTER DELETE ON word_meanings
+BEGIN
+    UPDATE words_fts
+    SET meanings = (SELECT group_concat(meaning, ' / ') FROM (SELECT meaning FROM word_meanings WHERE word_id = OLD.word_id ORDER BY position))
+    WHERE docid = OLD.word_id;
+END;
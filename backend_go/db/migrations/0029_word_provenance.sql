@@ -0,0 +1,8 @@
+-- 0029_word_provenance.sql
+-- Where each word comes from: source names the import, such as "csv:words.csv" or "manual", and
+-- source_id identifies the word within that source. Words from before this migration have neither.
+
+ALTER TABLE words ADD COLUMN source TEXT;
+ALTER TABLE words ADD COLUMN source_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_words_source ON words (source, source_id);
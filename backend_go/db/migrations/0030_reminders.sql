@@ -0,0 +1,18 @@
+-- 0030_reminders.sql
+-- The study reminder settings, a single row. On the days listed in days (comma separated, mon to
+-- sun), once the time of day has passed, the reminder is posted to webhook_url unless daily_goal
+-- reviews were already made that day. last_fired_date is the local date of the last reminder sent,
+-- so a restart does not send the same day's reminder twice.
+
+CREATE TABLE IF NOT EXISTS reminder_settings (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    enabled BOOLEAN NOT NULL DEFAULT 0,
+    time_of_day TEXT NOT NULL DEFAULT '20:00',
+    days TEXT NOT NULL DEFAULT 'mon,tue,wed,thu,fri,sat,sun',
+    webhook_url TEXT NOT NULL DEFAULT '',
+    daily_goal INTEGER NOT NULL DEFAULT 20,
+    last_fired_date TEXT,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO reminder_settings (id) VALUES (1);
@@ -0,0 +1,5 @@
+-- 0031_review_skipped.sql
+-- A skipped review records that the learner passed on a word without answering. Skipped reviews
+-- are stored with correct = 0 and left out of accuracy, wrong counts and scheduling.
+
+ALTER TABLE word_review_items ADD COLUMN skipped BOOLEAN NOT NULL DEFAULT 0;
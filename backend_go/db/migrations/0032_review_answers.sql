@@ -0,0 +1,5 @@
+-- 0032_review_answers.sql
+-- The answer given in a review, when the client sends it, so that wrong answers can be looked at
+-- again in the mistakes log. Reviews recorded without it have NULL.
+
+ALTER TABLE word_review_items ADD COLUMN answer TEXT;
@@ -0,0 +1,6 @@
+-- 0033_parts_schema.sql
+-- Word parts follow a schema from now on (see ParseParts). Empty parts are cleared here, and the
+-- freeform values that can be read are rewritten in Go once this migration has run (see
+-- postMigrations).
+
+UPDATE words SET parts = NULL WHERE TRIM(parts) IN ('', 'null', '{}', '[]', '""');
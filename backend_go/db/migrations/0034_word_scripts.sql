@@ -0,0 +1,7 @@
+-- 0034_word_scripts.sql
+-- The script of a word's japanese text: kana, kanji or mixed (see WordScript), NULL for text with
+-- neither. It is filled in Go once this migration has run (see postMigrations) and on insert.
+
+ALTER TABLE words ADD COLUMN script TEXT;
+
+CREATE INDEX idx_words_script ON words(script);
@@ -0,0 +1,16 @@
+-- 0035_study_activity_types.sql
+-- The type of a study activity, one of StudyActivityTypes, so that sessions can be filtered by the
+-- kind of practice. Existing activities get the type their name suggests, other when it says none.
+
+ALTER TABLE study_activities ADD COLUMN type TEXT NOT NULL DEFAULT 'other';
+
+UPDATE study_activities SET type = CASE
+    WHEN LOWER(name) LIKE '%flash%card%' THEN 'flashcards'
+    WHEN LOWER(name) LIKE '%typing%' THEN 'typing'
+    WHEN LOWER(name) LIKE '%quiz%' THEN 'quiz'
+    WHEN LOWER(name) LIKE '%match%' THEN 'matching'
+    WHEN LOWER(name) LIKE '%listen%' THEN 'listening'
+    ELSE 'other'
+END;
+
+CREATE INDEX idx_study_activities_type ON study_activities(type);
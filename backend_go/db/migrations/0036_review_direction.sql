@@ -0,0 +1,5 @@
+-- 0036_review_direction.sql
+-- The direction a word was asked in, one of ReviewDirections. Reviews recorded before the column
+-- existed, or by clients that do not send one, keep NULL and count as unknown.
+
+ALTER TABLE word_review_items ADD COLUMN direction TEXT;
@@ -0,0 +1,5 @@
+-- 0037_abandoned_sessions.sql
+-- Study sessions started and left without a single review are ended by the stale session cleanup
+-- and flagged abandoned, which hides them from the history listing.
+
+ALTER TABLE study_sessions ADD COLUMN abandoned BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,35 @@
+-- 0038_unique_group_names.sql
+-- Group names are unique regardless of case, so "N5 Verbs" and "n5 verbs" cannot both exist.
+-- Existing names are trimmed first, as the API trims new ones. Groups then sharing the name of an
+-- older group get the first numbered suffix no group has taken yet, such as "Basic Greetings (2)",
+-- so the index can be created.
+
+UPDATE groups SET name = TRIM(name) WHERE name <> TRIM(name);
+
+-- The k-th duplicate of a name, in ID order, takes the k-th free suffix of that name. Suffixes
+-- run from 2 up to one more than the number of groups, enough for every duplicate.
+UPDATE groups SET name = renamed.name
+FROM (
+    WITH RECURSIVE
+        suffixes(n) AS (
+            SELECT 2
+            UNION ALL
+            SELECT n + 1 FROM suffixes WHERE n <= (SELECT COUNT(*) FROM groups)
+        ),
+        duplicates AS (
+            SELECT g.id, g.name, ROW_NUMBER() OVER (PARTITION BY LOWER(g.name) ORDER BY g.id) AS k
+            FROM groups g
+            WHERE EXISTS (SELECT 1 FROM groups older WHERE older.name = g.name COLLATE NOCASE AND older.id < g.id)
+        ),
+        candidates AS (
+            SELECT d.id, d.k, d.name || ' (' || suffixes.n || ')' AS name,
+                   ROW_NUMBER() OVER (PARTITION BY d.id ORDER BY suffixes.n) AS rank
+            FROM duplicates d, suffixes
+            WHERE NOT EXISTS (SELECT 1 FROM groups taken
+                              WHERE taken.name = d.name || ' (' || suffixes.n || ')' COLLATE NOCASE)
+        )
+    SELECT id, name FROM candidates WHERE rank = k
+) AS renamed
+WHERE groups.id = renamed.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groups_name ON groups (name COLLATE NOCASE);
@@ -0,0 +1,8 @@
+-- 0039_japanese_normalized.sql
+-- The japanese text of each word in the form used to detect duplicates (see NormalizeJapanese),
+-- so imports look up the words they may reuse through the index instead of reading every word. It
+-- is filled in Go once this migration has run (see postMigrations) and on insert.
+
+ALTER TABLE words ADD COLUMN japanese_normalized TEXT;
+
+CREATE INDEX idx_words_japanese_normalized ON words(japanese_normalized);
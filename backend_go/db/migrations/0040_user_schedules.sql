@@ -0,0 +1,34 @@
+-- 0040_user_schedules.sql
+-- Review schedules and achievements belong to a learner, like the reviews they derive from.
+-- user_id is 0 for those of reviews made without a learner. Existing achievements are kept for
+-- user 0, and the schedules are rebuilt per learner from the reviews after this file runs.
+
+CREATE TABLE word_srs_new (
+    user_id INTEGER NOT NULL DEFAULT 0,
+    word_id INTEGER NOT NULL,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    ease_factor REAL NOT NULL DEFAULT 2.5,
+    last_reviewed_at DATETIME NOT NULL,
+    next_review_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, word_id),
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+DROP TABLE word_srs;
+ALTER TABLE word_srs_new RENAME TO word_srs;
+
+CREATE INDEX IF NOT EXISTS idx_word_srs_next_review_at ON word_srs (user_id, next_review_at);
+CREATE INDEX IF NOT EXISTS idx_word_srs_word_id ON word_srs (word_id);
+
+CREATE TABLE achievements_new (
+    user_id INTEGER NOT NULL DEFAULT 0,
+    key TEXT NOT NULL,
+    earned_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, key)
+);
+
+INSERT INTO achievements_new (user_id, key, earned_at) SELECT 0, key, earned_at FROM achievements;
+
+DROP TABLE achievements;
+ALTER TABLE achievements_new RENAME TO achievements;
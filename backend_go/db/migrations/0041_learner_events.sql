@@ -0,0 +1,8 @@
+-- 0041_learner_events.sql
+-- Events about a learner's study, such as completed sessions, milestones and achievements, belong
+-- to that learner. Events about the shared words and groups, and every event from before this
+-- migration, have no user_id.
+
+ALTER TABLE events ADD COLUMN user_id INTEGER REFERENCES users(id);
+
+CREATE INDEX IF NOT EXISTS idx_events_user_id ON events (user_id, id);
@@ -0,0 +1,17 @@
+-- 0042_mastery_milestones.sql
+-- The mastery milestones each learner reached, so that each is celebrated once. user_id is 0 for
+-- those reached without a learner, including the milestones recorded before this migration.
+-- Reviews are indexed by word for the mastery check made after each correct review.
+
+CREATE TABLE IF NOT EXISTS mastery_milestones (
+    user_id INTEGER NOT NULL DEFAULT 0,
+    mastered_words INTEGER NOT NULL,
+    reached_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, mastered_words)
+);
+
+INSERT OR IGNORE INTO mastery_milestones (user_id, mastered_words, reached_at)
+SELECT COALESCE(user_id, 0), json_extract(payload, '$.mastered_words'), COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM events WHERE type = 'milestone_reached';
+
+CREATE INDEX IF NOT EXISTS idx_word_review_items_word_id ON word_review_items (word_id, user_id);
@@ -0,0 +1,9 @@
+-- 0043_group_default_activity_type.sql
+-- A group names the type of study activity, one of StudyActivityTypes, that sessions started for it
+-- use by default rather than a study activity. Groups keep the type of their default activity.
+
+ALTER TABLE groups ADD COLUMN default_activity_type TEXT;
+
+UPDATE groups SET default_activity_type = (SELECT type FROM study_activities WHERE id = groups.default_study_activity_id);
+
+ALTER TABLE groups DROP COLUMN default_study_activity_id;
@@ -0,0 +1,11 @@
+// Package seeds embeds the built-in seed datasets so that the server binary can load them
+// without the source tree.
+package seeds
+
+import "embed"
+
+// Datasets holds one seed file per built-in dataset, datasets/<name>.json, in the format read by
+// the service's seed loader.
+//
+//go:embed datasets/*.json
+var Datasets embed.FS
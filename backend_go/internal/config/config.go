@@ -0,0 +1,286 @@
+// Package config reads the server's settings from the environment.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Env is the environment the server runs in, selected by APP_ENV.
+type Env string
+
+// Environments accepted in APP_ENV.
+const (
+	// Development seeds an empty database with demo data and logs at debug level.
+	Development Env = "development"
+	// Production never seeds, runs gin in release mode and logs at info level.
+	Production Env = "production"
+	// Test never seeds and runs gin in test mode.
+	Test Env = "test"
+)
+
+// Page size modes decide what happens to a request asking for more than MaxPageSize items:
+// PageSizeClamp serves MaxPageSize items instead, PageSizeReject answers 400 naming the maximum.
+const (
+	PageSizeClamp  = "clamp"
+	PageSizeReject = "reject"
+)
+
+// Config holds the server's settings, each read from the environment variable named in its
+// comment. Load fills in the defaults and checks the values.
+type Config struct {
+	// Env is APP_ENV, development by default. It sets the defaults of GinMode, LogLevel and
+	// SeedDemoData.
+	Env Env
+	// GinMode is GIN_MODE: debug, release or test. Production defaults to release, test to test
+	// and development to debug.
+	GinMode string
+	// LogLevel is LOG_LEVEL: debug, info, warn or error. Development defaults to debug, the other
+	// environments to info.
+	LogLevel slog.Level
+	// SeedDemoData is SEED_DEMO_DATA, whether serve fills an empty database with the demo words
+	// and groups. It defaults to true in development and cannot be set in production.
+	SeedDemoData bool
+	// SeedFile is SEED_FILE, a JSON or CSV file seeded instead of the built-in demo dataset.
+	SeedFile string
+	// SeedDir is SEED_DIR, a directory of seed datasets loaded by name next to the built-in ones.
+	SeedDir string
+
+	// DBPath is DB_PATH, the SQLite database file, words.db by default.
+	DBPath string
+	// DBDSN is DB_DSN, a go-sqlite3 connection string used instead of DBPath.
+	DBDSN string
+
+	// Port is PORT, the port the API is served on without AUTOCERT_HOST, 8080 by default.
+	Port int
+	// TLSCertFile and TLSKeyFile are TLS_CERT_FILE and TLS_KEY_FILE, a certificate pair to serve
+	// HTTPS with. They must be set together.
+	TLSCertFile string
+	TLSKeyFile  string
+	// AutocertHost is AUTOCERT_HOST, a hostname to get Let's Encrypt certificates for, and
+	// AutocertCacheDir is AUTOCERT_CACHE_DIR, where they are kept, autocert-cache by default.
+	AutocertHost     string
+	AutocertCacheDir string
+
+	// CORSAllowedOrigins is CORS_ALLOWED_ORIGINS, a comma-separated allow-list of origins.
+	CORSAllowedOrigins string
+	// APIKey is API_KEY, required from API clients when set.
+	APIKey string
+	// JWTSecret is JWT_SECRET, which enables logins when set. JWTAccessTTL and JWTRefreshTTL are
+	// JWT_ACCESS_TTL and JWT_REFRESH_TTL, Go durations, 15m and 720h by default.
+	JWTSecret     string
+	JWTAccessTTL  time.Duration
+	JWTRefreshTTL time.Duration
+	// AdminUsername and AdminPassword are ADMIN_USERNAME and ADMIN_PASSWORD, an admin account
+	// created on first start when both are set.
+	AdminUsername string
+	AdminPassword string
+
+	// MediaDir is MEDIA_DIR, where uploaded images and audio are stored, media by default.
+	MediaDir string
+	// TTSCommand is TTS_COMMAND, a command generating word audio, and TTSURL is TTS_URL, an HTTP
+	// endpoint doing so. The command wins when both are set.
+	TTSCommand string
+	TTSURL     string
+	// Location is TIMEZONE, an IANA name such as Asia/Tokyo whose calendar days the day-based
+	// stats follow, UTC by default.
+	Location *time.Location
+	// SlowQueryThreshold is SLOW_QUERY_THRESHOLD, a Go duration such as 200ms. Statements taking
+	// at least that long are logged, none when it is 0, the default.
+	SlowQueryThreshold time.Duration
+
+	// DefaultPageSize and MaxPageSize are DEFAULT_PAGE_SIZE and MAX_PAGE_SIZE, 100 and 500 by
+	// default, and PageSizeMode is PAGE_SIZE_MODE, PageSizeClamp or PageSizeReject.
+	DefaultPageSize int
+	MaxPageSize     int
+	PageSizeMode    string
+	// SharedRateLimit is SHARED_RATE_LIMIT, the requests per minute a client may make to share
+	// links, 30 by default.
+	SharedRateLimit int
+	// SessionResumeWindow is SESSION_RESUME_HOURS, how long after its start an open study session
+	// can be resumed, 12 hours by default.
+	SessionResumeWindow time.Duration
+	// DisableRemoteImport is DISABLE_REMOTE_IMPORT, which turns off importing word lists from URLs.
+	DisableRemoteImport bool
+	// SessionCleanupInterval is SESSION_CLEANUP_INTERVAL, a Go duration, how often study sessions
+	// left without reviews are cleaned up, 1h by default and never when 0. SessionAbandonAfter is
+	// SESSION_ABANDON_HOURS, the age at which they are, 24 hours by default. They are flagged
+	// abandoned, or deleted when SessionCleanupDelete, SESSION_CLEANUP_DELETE, is set.
+	SessionCleanupInterval time.Duration
+	SessionAbandonAfter    time.Duration
+	SessionCleanupDelete   bool
+}
+
+// Load reads the configuration from the environment. It reports every invalid value at once.
+func Load() (*Config, error) {
+	l := &loader{}
+	cfg := &Config{
+		Env:                 Env(l.str("APP_ENV", string(Development))),
+		SeedFile:            l.str("SEED_FILE", ""),
+		SeedDir:             l.str("SEED_DIR", ""),
+		DBPath:              l.str("DB_PATH", "words.db"),
+		DBDSN:               l.str("DB_DSN", ""),
+		Port:                l.int("PORT", 8080, 1, 65535),
+		TLSCertFile:         l.str("TLS_CERT_FILE", ""),
+		TLSKeyFile:          l.str("TLS_KEY_FILE", ""),
+		AutocertHost:        l.str("AUTOCERT_HOST", ""),
+		AutocertCacheDir:    l.str("AUTOCERT_CACHE_DIR", "autocert-cache"),
+		CORSAllowedOrigins:  l.str("CORS_ALLOWED_ORIGINS", ""),
+		APIKey:              l.str("API_KEY", ""),
+		JWTSecret:           l.str("JWT_SECRET", ""),
+		JWTAccessTTL:        l.duration("JWT_ACCESS_TTL", 15*time.Minute),
+		JWTRefreshTTL:       l.duration("JWT_REFRESH_TTL", 30*24*time.Hour),
+		AdminUsername:       l.str("ADMIN_USERNAME", ""),
+		AdminPassword:       l.str("ADMIN_PASSWORD", ""),
+		MediaDir:            l.str("MEDIA_DIR", "media"),
+		TTSCommand:          l.str("TTS_COMMAND", ""),
+		TTSURL:              l.str("TTS_URL", ""),
+		Location:            time.UTC,
+		SlowQueryThreshold:  l.duration("SLOW_QUERY_THRESHOLD", 0),
+		MaxPageSize:         l.int("MAX_PAGE_SIZE", 500, 1, 0),
+		PageSizeMode:        l.str("PAGE_SIZE_MODE", PageSizeClamp),
+		SharedRateLimit:     l.int("SHARED_RATE_LIMIT", 30, 1, 0),
+		SessionResumeWindow: time.Duration(l.int("SESSION_RESUME_HOURS", 12, 1, 0)) * time.Hour,
+		DisableRemoteImport: l.bool("DISABLE_REMOTE_IMPORT", false),
+	}
+	cfg.SessionCleanupInterval = l.duration("SESSION_CLEANUP_INTERVAL", time.Hour)
+	cfg.SessionAbandonAfter = time.Duration(l.int("SESSION_ABANDON_HOURS", 24, 1, 0)) * time.Hour
+	cfg.SessionCleanupDelete = l.bool("SESSION_CLEANUP_DELETE", false)
+
+	switch cfg.Env {
+	case Development, Production, Test:
+	default:
+		l.fail("APP_ENV must be %s, %s or %s, got %q", Development, Production, Test, cfg.Env)
+	}
+	cfg.GinMode = l.str("GIN_MODE", map[Env]string{Production: gin.ReleaseMode, Test: gin.TestMode}[cfg.Env])
+	if cfg.GinMode == "" {
+		cfg.GinMode = gin.DebugMode
+	}
+	if cfg.GinMode != gin.DebugMode && cfg.GinMode != gin.ReleaseMode && cfg.GinMode != gin.TestMode {
+		l.fail("GIN_MODE must be %s, %s or %s, got %q", gin.DebugMode, gin.ReleaseMode, gin.TestMode, cfg.GinMode)
+	}
+	cfg.LogLevel = slog.LevelInfo
+	if cfg.Env == Development {
+		cfg.LogLevel = slog.LevelDebug
+	}
+	if v := l.str("LOG_LEVEL", ""); v != "" {
+		if err := cfg.LogLevel.UnmarshalText([]byte(v)); err != nil {
+			l.fail("LOG_LEVEL must be debug, info, warn or error, got %q", v)
+		}
+	}
+	cfg.SeedDemoData = l.bool("SEED_DEMO_DATA", cfg.Env == Development)
+	if cfg.SeedDemoData && cfg.Env == Production {
+		l.fail("SEED_DEMO_DATA cannot be set in production")
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		l.fail("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if tz := l.str("TIMEZONE", ""); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			l.fail("TIMEZONE must be an IANA timezone name, got %q", tz)
+		} else {
+			cfg.Location = loc
+		}
+	}
+
+	// Without DEFAULT_PAGE_SIZE the default follows a lower MAX_PAGE_SIZE
+	cfg.DefaultPageSize = l.int("DEFAULT_PAGE_SIZE", 100, 1, 0)
+	if cfg.DefaultPageSize > cfg.MaxPageSize {
+		if os.Getenv("DEFAULT_PAGE_SIZE") != "" {
+			l.fail("DEFAULT_PAGE_SIZE %d exceeds MAX_PAGE_SIZE %d", cfg.DefaultPageSize, cfg.MaxPageSize)
+		}
+		cfg.DefaultPageSize = cfg.MaxPageSize
+	}
+	if cfg.PageSizeMode != PageSizeClamp && cfg.PageSizeMode != PageSizeReject {
+		l.fail("PAGE_SIZE_MODE must be %s or %s, got %q", PageSizeClamp, PageSizeReject, cfg.PageSizeMode)
+	}
+
+	if err := errors.Join(l.errs...); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// DataSource returns the connection string of the database, DBDSN when it is set and DBPath
+// otherwise.
+func (c *Config) DataSource() string {
+	if c.DBDSN != "" {
+		return c.DBDSN
+	}
+	return c.DBPath
+}
+
+// loader reads environment variables, collecting the errors of invalid values.
+type loader struct {
+	errs []error
+}
+
+// fail records an error.
+func (l *loader) fail(format string, args ...interface{}) {
+	l.errs = append(l.errs, fmt.Errorf(format, args...))
+}
+
+// str returns the trimmed value of the variable name, def when it is unset or empty.
+func (l *loader) str(name, def string) string {
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		return v
+	}
+	return def
+}
+
+// int returns the integer value of the variable name, def when it is unset. The value must be at
+// least lo and, unless hi is 0, at most hi.
+func (l *loader) int(name string, def, lo, hi int) int {
+	v := l.str(name, "")
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < lo || (hi != 0 && n > hi) {
+		if hi != 0 {
+			l.fail("%s must be an integer between %d and %d, got %q", name, lo, hi, v)
+		} else {
+			l.fail("%s must be an integer of at least %d, got %q", name, lo, v)
+		}
+		return def
+	}
+	return n
+}
+
+// bool returns the boolean value of the variable name, def when it is unset.
+func (l *loader) bool(name string, def bool) bool {
+	v := l.str(name, "")
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.fail("%s must be true or false, got %q", name, v)
+		return def
+	}
+	return b
+}
+
+// duration returns the value of the variable name as a Go duration, def when it is unset. The
+// value must not be negative.
+func (l *loader) duration(name string, def time.Duration) time.Duration {
+	v := l.str(name, "")
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		l.fail("%s must be a Go duration such as 15m, got %q", name, v)
+		return def
+	}
+	return d
+}
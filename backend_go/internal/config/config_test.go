@@ -0,0 +1,41 @@
+package config
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoadModes(t *testing.T) {
+	tests := []struct {
+		env, ginMode string
+		wantMode     string
+		wantLevel    slog.Level
+	}{
+		{"", "", gin.DebugMode, slog.LevelDebug},
+		{"development", "", gin.DebugMode, slog.LevelDebug},
+		{"production", "", gin.ReleaseMode, slog.LevelInfo},
+		{"test", "", gin.TestMode, slog.LevelInfo},
+		{"production", "debug", gin.DebugMode, slog.LevelInfo},
+		{"development", "release", gin.ReleaseMode, slog.LevelDebug},
+	}
+	for _, tt := range tests {
+		t.Setenv("APP_ENV", tt.env)
+		t.Setenv("GIN_MODE", tt.ginMode)
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("APP_ENV=%q GIN_MODE=%q: %v", tt.env, tt.ginMode, err)
+		}
+		if cfg.GinMode != tt.wantMode || cfg.LogLevel != tt.wantLevel {
+			t.Errorf("APP_ENV=%q GIN_MODE=%q: mode %s, level %v, want %s, %v",
+				tt.env, tt.ginMode, cfg.GinMode, cfg.LogLevel, tt.wantMode, tt.wantLevel)
+		}
+	}
+
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("GIN_MODE", "")
+	if _, err := Load(); err == nil {
+		t.Error("APP_ENV=staging loaded, want an error")
+	}
+}
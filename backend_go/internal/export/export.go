@@ -0,0 +1,139 @@
+// Package export writes word lists in the file formats of other study tools.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"backend_go/internal/models"
+)
+
+// Exporter writes a word list in one file format.
+type Exporter interface {
+	// ContentType is the MIME type of the output.
+	ContentType() string
+	// Extension is the file name extension of the output, without the dot.
+	Extension() string
+	// Write writes words to w.
+	Write(w io.Writer, words []models.Word) error
+}
+
+// Options control how the flashcard formats (anki and quizlet) lay out each card.
+type Options struct {
+	// TermEnglish puts the english meaning on the front of the card instead of the japanese text.
+	TermEnglish bool
+	// WithRomaji appends the romaji in parentheses after the japanese text.
+	WithRomaji bool
+}
+
+// Formats lists the supported format names.
+var Formats = []string{"csv", "json", "anki", "quizlet"}
+
+// New returns the exporter of the named format.
+func New(format string, opts Options) (Exporter, error) {
+	switch format {
+	case "csv":
+		return csvExporter{}, nil
+	case "json":
+		return jsonExporter{}, nil
+	case "anki":
+		return cardExporter{opts: opts, header: "#separator:tab\n#html:false\n"}, nil
+	case "quizlet":
+		return cardExporter{opts: opts}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// optional returns the value of an optional text field, or "" when it is unset.
+func optional(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// csvExporter writes the CSV layout read by the CSV importers, with a header row.
+type csvExporter struct{}
+
+func (csvExporter) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (csvExporter) Extension() string { return "csv" }
+
+func (csvExporter) Write(w io.Writer, words []models.Word) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"japanese", "reading", "romaji", "english", "meanings", "parts", "mnemonic", "source", "source_id"}); err != nil {
+		return err
+	}
+	for _, word := range words {
+		if err := cw.Write([]string{word.Japanese, optional(word.Reading), word.Romaji, word.English, strings.Join(word.Meanings, "; "), string(word.Parts),
+			optional(word.Mnemonic), optional(word.Source), optional(word.SourceID)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// jsonExporter writes the words as a JSON array, as returned by the word endpoints.
+type jsonExporter struct{}
+
+func (jsonExporter) ContentType() string { return "application/json; charset=utf-8" }
+
+func (jsonExporter) Extension() string { return "json" }
+
+func (jsonExporter) Write(w io.Writer, words []models.Word) error {
+	if words == nil {
+		words = []models.Word{}
+	}
+	return json.NewEncoder(w).Encode(words)
+}
+
+// cardExporter writes one "term<TAB>definition" line per word, the plain text layout imported by
+// Quizlet and, after its header, by Anki.
+type cardExporter struct {
+	opts   Options
+	header string
+}
+
+func (cardExporter) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (cardExporter) Extension() string { return "txt" }
+
+func (e cardExporter) Write(w io.Writer, words []models.Word) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(e.header)
+	for _, word := range words {
+		japanese := cardField(word.Japanese)
+		if e.opts.WithRomaji {
+			japanese += " (" + cardField(word.Romaji) + ")"
+		}
+		term, definition := japanese, cardField(definitionOf(word))
+		if e.opts.TermEnglish {
+			term, definition = definition, term
+		}
+		bw.WriteString(term)
+		bw.WriteByte('\t')
+		bw.WriteString(definition)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// definitionOf is the english side of a card: every meaning of the word, primary first.
+func definitionOf(word models.Word) string {
+	if len(word.Meanings) == 0 {
+		return word.English
+	}
+	return strings.Join(word.Meanings, "; ")
+}
+
+// cardField replaces the tabs and line breaks that would split a card with spaces.
+func cardField(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return r == '\t' || r == '\n' || r == '\r'
+	}), " ")
+}
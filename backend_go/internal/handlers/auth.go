@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+)
+
+// userKey is the gin context key under which the authenticated user is stored.
+const userKey = "user"
+
+// apiKeyHeader carries the static API key used by scripts.
+const apiKeyHeader = "X-API-Key"
+
+// userIDHeader names the learner a request acts for when it is not made with a user's access token.
+const userIDHeader = "X-User-ID"
+
+// roleRank orders roles so that a higher rank includes every permission of a lower one.
+var roleRank = map[string]int{
+	models.RoleViewer: 1,
+	models.RoleEditor: 2,
+	models.RoleAdmin:  3,
+}
+
+// adminRoutes lists the routes that only admins may call.
+var adminRoutes = map[string]bool{
+	"/api/reset_history": true,
+	"/api/full_reset":    true,
+}
+
+// queryTokenRoutes lists the routes that also accept the access token as the access_token query
+// parameter, since browsers cannot set the Authorization header of a WebSocket handshake.
+var queryTokenRoutes = map[string]bool{
+	"/api/study_sessions/:id/ws": true,
+}
+
+// accessToken returns the bearer token of the Authorization header, or for queryTokenRoutes the
+// access_token query parameter, and whether there is one.
+func accessToken(c *gin.Context) (string, bool) {
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return token, true
+	}
+	if token := c.Query("access_token"); token != "" && queryTokenRoutes[c.FullPath()] {
+		return token, true
+	}
+	return "", false
+}
+
+// CurrentUser returns the user authenticated for the request, or nil when authentication is disabled.
+func CurrentUser(c *gin.Context) *models.User {
+	if v, ok := c.Get(userKey); ok {
+		return v.(*models.User)
+	}
+	return nil
+}
+
+// currentUsername returns the username of the authenticated user, or "" when authentication is disabled.
+func currentUsername(c *gin.Context) string {
+	if user := CurrentUser(c); user != nil {
+		return user.Username
+	}
+	return ""
+}
+
+// currentUserID returns the ID of the learner the request acts for: the authenticated user, or for
+// requests made with the API key or without authentication, the user ID in the X-User-ID header.
+// It returns 0 when there is none, in which case study data is not scoped to a learner.
+func currentUserID(c *gin.Context) int {
+	if user := CurrentUser(c); user != nil && user.ID != 0 {
+		return user.ID
+	}
+	id, err := strconv.Atoi(c.GetHeader(userIDHeader))
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// userService returns the service scoped to the learner the request acts for (see currentUserID).
+func userService(c *gin.Context) *service.Service {
+	return svc.ForUser(currentUserID(c))
+}
+
+// authenticate guards the API when JWT login or an API key is configured. Requests must carry
+// either a valid "Authorization: Bearer <access token>" header or the API key in X-API-Key,
+// which acts as an admin. The authenticated user is stored in the context and its role is then
+// checked against the route: viewers may only read, editors may also modify data, and admin
+// routes such as resets are reserved for admins. The /api/auth endpoints and the public
+// /api/shared share links are always open.
+func authenticate(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !svc.AuthEnabled() && apiKey == "" {
+			return
+		}
+		if strings.HasPrefix(c.FullPath(), "/api/auth/") || strings.HasPrefix(c.FullPath(), "/api/shared/") {
+			return
+		}
+
+		var user *models.User
+		if key := c.GetHeader(apiKeyHeader); key != "" && apiKey != "" {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				return
+			}
+			user = &models.User{Username: "api-key", Role: models.RoleAdmin}
+		} else if token, ok := accessToken(c); ok {
+			u, err := svc.ParseAccessToken(token)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired access token"})
+				return
+			}
+			user = u
+		} else {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+
+		if roleRank[user.Role] < roleRank[requiredRole(c)] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+		c.Set(userKey, user)
+	}
+}
+
+// requiredRole returns the least privileged role allowed to call the matched route.
+func requiredRole(c *gin.Context) string {
+	path := c.FullPath()
+	if adminRoutes[path] || strings.HasPrefix(path, "/api/admin/") {
+		return models.RoleAdmin
+	}
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return models.RoleViewer
+	default:
+		return models.RoleEditor
+	}
+}
+
+// Login handles POST /api/auth/login
+func Login(c *gin.Context) {
+	if !svc.AuthEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Login is not enabled"})
+		return
+	}
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	tokens, user, err := svc.Login(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          user,
+	})
+}
+
+// RefreshToken handles POST /api/auth/refresh
+func RefreshToken(c *gin.Context) {
+	if !svc.AuthEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Login is not enabled"})
+		return
+	}
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	tokens, err := svc.RefreshTokens(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout handles POST /api/auth/logout
+func Logout(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := svc.Logout(req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUser handles POST /api/admin/users
+func CreateUser(c *gin.Context) {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	errs := fieldErrors{}
+	errs.require("username", req.Username)
+	if len(req.Password) < 8 {
+		errs.add("password", "must be at least 8 characters")
+	}
+	if roleRank[req.Role] == 0 {
+		errs.add("role", "must be one of viewer, editor or admin")
+	}
+	if errs.respond(c) {
+		return
+	}
+	id, err := svc.CreateUser(req.Username, req.Password, req.Role)
+	if err != nil {
+		if errors.Is(err, service.ErrDuplicateUsername) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "username": req.Username, "role": req.Role})
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+)
+
+// authEnv enables JWT login and the API key.
+var authEnv = map[string]string{
+	"JWT_SECRET": "0123456789abcdef0123456789abcdef",
+	"API_KEY":    "script-key",
+}
+
+// login logs username in with password and returns the response and its token pair.
+func login(t *testing.T, router *gin.Engine, username, password string) (int, service.TokenPair) {
+	t.Helper()
+	w := request(router, http.MethodPost, "/api/auth/login", gin.H{"username": username, "password": password})
+	var tokens service.TokenPair
+	if w.Code == http.StatusOK {
+		decode(t, w, &tokens)
+	}
+	return w.Code, tokens
+}
+
+// createUsers creates a user of each role, named after the role, with the password "password123".
+func createUsers(t *testing.T, s *service.Service) {
+	t.Helper()
+	for _, role := range []string{models.RoleViewer, models.RoleEditor, models.RoleAdmin} {
+		if _, err := s.CreateUser(role, "password123", role); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	router, s := newTestServer(t, authEnv)
+	createUsers(t, s)
+
+	if code, _ := login(t, router, "viewer", "wrong password"); code != http.StatusUnauthorized {
+		t.Errorf("wrong password: status %d, want 401", code)
+	}
+	if code, _ := login(t, router, "nobody", "password123"); code != http.StatusUnauthorized {
+		t.Errorf("unknown user: status %d, want 401", code)
+	}
+	if w := request(router, http.MethodPost, "/api/auth/login", "not json"); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid payload: status %d, want 400", w.Code)
+	}
+
+	code, tokens := login(t, router, "viewer", "password123")
+	if code != http.StatusOK || tokens.AccessToken == "" || tokens.RefreshToken == "" || tokens.TokenType != "Bearer" {
+		t.Fatalf("login: status %d, tokens %+v", code, tokens)
+	}
+	if w := request(router, http.MethodGet, "/api/groups", nil, bearer(tokens.AccessToken)...); w.Code != http.StatusOK {
+		t.Errorf("with access token: status %d, want 200", w.Code)
+	}
+	if w := request(router, http.MethodGet, "/api/groups", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("without credentials: status %d, want 401", w.Code)
+	}
+	if w := request(router, http.MethodGet, "/api/groups", nil, bearer(tokens.AccessToken+"x")...); w.Code != http.StatusUnauthorized {
+		t.Errorf("with a tampered token: status %d, want 401", w.Code)
+	}
+	if w := request(router, http.MethodGet, "/api/groups", nil, "X-API-Key", "script-key"); w.Code != http.StatusOK {
+		t.Errorf("with the API key: status %d, want 200", w.Code)
+	}
+	if w := request(router, http.MethodGet, "/api/groups", nil, "X-API-Key", "wrong-key"); w.Code != http.StatusUnauthorized {
+		t.Errorf("with a wrong API key: status %d, want 401", w.Code)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	router, s := newTestServer(t, authEnv)
+	createUsers(t, s)
+	tokens := make(map[string]string)
+	for _, role := range []string{models.RoleViewer, models.RoleEditor, models.RoleAdmin} {
+		_, pair := login(t, router, role, "password123")
+		tokens[role] = pair.AccessToken
+	}
+
+	tests := []struct {
+		role, method, target string
+		body                 interface{}
+		forbidden            bool
+	}{
+		{models.RoleViewer, http.MethodGet, "/api/groups", nil, false},
+		{models.RoleViewer, http.MethodPost, "/api/groups", gin.H{"name": "Viewer group"}, true},
+		{models.RoleViewer, http.MethodPost, "/api/reset_history", nil, true},
+		{models.RoleViewer, http.MethodPost, "/api/admin/users", gin.H{}, true},
+		{models.RoleEditor, http.MethodPost, "/api/groups", gin.H{"name": "Editor group"}, false},
+		{models.RoleEditor, http.MethodPost, "/api/reset_history", nil, true},
+		{models.RoleEditor, http.MethodPost, "/api/full_reset", nil, true},
+		{models.RoleEditor, http.MethodPost, "/api/admin/users", gin.H{}, true},
+		{models.RoleAdmin, http.MethodPost, "/api/reset_history", nil, false},
+		{models.RoleAdmin, http.MethodPost, "/api/admin/users", gin.H{"username": "new", "password": "password123"}, false},
+	}
+	for _, tt := range tests {
+		w := request(router, tt.method, tt.target, tt.body, bearer(tokens[tt.role])...)
+		if forbidden := w.Code == http.StatusForbidden; forbidden != tt.forbidden {
+			t.Errorf("%s %s %s: status %d, forbidden %v", tt.role, tt.method, tt.target, w.Code, tt.forbidden)
+		}
+	}
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	router, s := newTestServer(t, authEnv)
+	createUsers(t, s)
+	_, first := login(t, router, "editor", "password123")
+
+	w := request(router, http.MethodPost, "/api/auth/refresh", gin.H{"refresh_token": first.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: status %d, want 200", w.Code)
+	}
+	var second service.TokenPair
+	decode(t, w, &second)
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == "" {
+		t.Fatalf("refresh returned %+v, want a new token pair", second)
+	}
+	if w := request(router, http.MethodGet, "/api/groups", nil, bearer(second.AccessToken)...); w.Code != http.StatusOK {
+		t.Errorf("refreshed access token: status %d, want 200", w.Code)
+	}
+
+	// Refresh tokens are single use
+	if w := request(router, http.MethodPost, "/api/auth/refresh", gin.H{"refresh_token": first.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("reused refresh token: status %d, want 401", w.Code)
+	}
+	if w := request(router, http.MethodPost, "/api/auth/refresh", gin.H{"refresh_token": "unknown"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown refresh token: status %d, want 401", w.Code)
+	}
+
+	if w := request(router, http.MethodPost, "/api/auth/logout", gin.H{"refresh_token": second.RefreshToken}); w.Code != http.StatusNoContent {
+		t.Fatalf("logout: status %d, want 204", w.Code)
+	}
+	if w := request(router, http.MethodPost, "/api/auth/refresh", gin.H{"refresh_token": second.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after logout: status %d, want 401", w.Code)
+	}
+	if w := request(router, http.MethodPost, "/api/auth/logout", gin.H{"refresh_token": second.RefreshToken}); w.Code != http.StatusNoContent {
+		t.Errorf("second logout: status %d, want 204", w.Code)
+	}
+}
+
+func TestExpiredTokens(t *testing.T) {
+	router, s := newTestServer(t, authEnv)
+	createUsers(t, s)
+	s.EnableAuth(service.AuthConfig{Secret: []byte(authEnv["JWT_SECRET"]), AccessTTL: -time.Minute, RefreshTTL: -time.Minute})
+	code, tokens := login(t, router, "viewer", "password123")
+	if code != http.StatusOK {
+		t.Fatalf("login: status %d, want 200", code)
+	}
+	if w := request(router, http.MethodGet, "/api/groups", nil, bearer(tokens.AccessToken)...); w.Code != http.StatusUnauthorized {
+		t.Errorf("expired access token: status %d, want 401", w.Code)
+	}
+	if w := request(router, http.MethodPost, "/api/auth/refresh", gin.H{"refresh_token": tokens.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("expired refresh token: status %d, want 401", w.Code)
+	}
+
+	// Tokens signed with another secret are rejected too
+	s.EnableAuth(service.AuthConfig{Secret: []byte("another secret of thirty-two bytes"), AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	_, other := login(t, router, "viewer", "password123")
+	s.EnableAuth(service.AuthConfig{Secret: []byte(authEnv["JWT_SECRET"]), AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	if w := request(router, http.MethodGet, "/api/groups", nil, bearer(other.AccessToken)...); w.Code != http.StatusUnauthorized {
+		t.Errorf("token of another secret: status %d, want 401", w.Code)
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	router, _ := newTestServer(t, authEnv)
+	admin := []string{"X-API-Key", "script-key"}
+
+	w := request(router, http.MethodPost, "/api/admin/users", gin.H{"username": "kana", "password": "password123"}, admin...)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, want 201: %s", w.Code, w.Body)
+	}
+	var created struct {
+		Role string `json:"role"`
+	}
+	decode(t, w, &created)
+	if created.Role != models.RoleViewer {
+		t.Errorf("role %q, want viewer by default", created.Role)
+	}
+	if w := request(router, http.MethodPost, "/api/admin/users", gin.H{"username": "kana", "password": "password456"}, admin...); w.Code != http.StatusConflict {
+		t.Errorf("duplicate username: status %d, want 409", w.Code)
+	}
+	if w := request(router, http.MethodPost, "/api/admin/users", gin.H{"username": "short", "password": "1234567"}, admin...); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("short password: status %d, want 422", w.Code)
+	}
+	if w := request(router, http.MethodPost, "/api/admin/users", gin.H{"username": "x", "password": "password123", "role": "owner"}, admin...); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unknown role: status %d, want 422", w.Code)
+	}
+	if code, _ := login(t, router, "kana", "password123"); code != http.StatusOK {
+		t.Errorf("login as the new user: status %d, want 200", code)
+	}
+}
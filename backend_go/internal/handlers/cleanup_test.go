@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"backend_go/internal/models"
+	"backend_go/internal/testutil"
+)
+
+func TestCleanupSessions(t *testing.T) {
+	router, s := newTestServer(t, map[string]string{"SESSION_ABANDON_HOURS": "1"})
+	f := testutil.NewFixture(t, s.DB).Group("N5").SessionAt(time.Now().Add(-2 * time.Hour))
+	stale := f.SessionID()
+	fresh := f.SessionAt(time.Now().Add(-30 * time.Minute)).SessionID()
+
+	listed := func(target string) []int {
+		t.Helper()
+		var sessions []models.StudySessionListItem
+		decode(t, request(router, http.MethodGet, target, nil), &sessions)
+		ids := make([]int, 0, len(sessions))
+		for _, session := range sessions {
+			ids = append(ids, session.ID)
+		}
+		return ids
+	}
+
+	w := request(router, http.MethodPost, "/api/admin/cleanup-sessions?dry_run=true", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("dry run: status %d, want 200: %s", w.Code, w.Body)
+	}
+	var result models.SessionCleanupResult
+	decode(t, w, &result)
+	if !result.DryRun || !reflect.DeepEqual(result.SessionIDs, []int{stale}) {
+		t.Errorf("dry run = %+v, want session %d listed", result, stale)
+	}
+	if ids := listed("/api/study_sessions"); len(ids) != 2 {
+		t.Errorf("after the dry run sessions %v are listed, want both", ids)
+	}
+
+	w = request(router, http.MethodPost, "/api/admin/cleanup-sessions", nil)
+	decode(t, w, &result)
+	if w.Code != http.StatusOK || result.DryRun || result.Action != "abandoned" || !reflect.DeepEqual(result.SessionIDs, []int{stale}) {
+		t.Errorf("cleanup: status %d, %+v, want session %d abandoned", w.Code, result, stale)
+	}
+	if ids := listed("/api/study_sessions"); !reflect.DeepEqual(ids, []int{fresh}) {
+		t.Errorf("sessions %v are listed, want %d", ids, fresh)
+	}
+	if ids := listed("/api/study_sessions?include_abandoned=true"); len(ids) != 2 {
+		t.Errorf("with include_abandoned sessions %v are listed, want both", ids)
+	}
+
+	fields := validationFields(t, request(router, http.MethodPost, "/api/admin/cleanup-sessions?dry_run=maybe", nil))
+	if _, ok := fields["dry_run"]; !ok {
+		t.Errorf("errors %v, want dry_run", fields)
+	}
+	fields = validationFields(t, request(router, http.MethodGet, "/api/study_sessions?include_abandoned=maybe", nil))
+	if _, ok := fields["include_abandoned"]; !ok {
+		t.Errorf("errors %v, want include_abandoned", fields)
+	}
+}
+
+func TestCleanupSessionsAuth(t *testing.T) {
+	router, s := newTestServer(t, authEnv)
+	createUsers(t, s)
+
+	for _, tt := range []struct {
+		name    string
+		headers []string
+		status  int
+	}{
+		{"viewer", nil, http.StatusForbidden},
+		{"editor", nil, http.StatusForbidden},
+		{"admin", nil, http.StatusOK},
+		{"API key", []string{"X-API-Key", "script-key"}, http.StatusOK},
+	} {
+		headers := tt.headers
+		if headers == nil {
+			_, tokens := login(t, router, tt.name, "password123")
+			headers = bearer(tokens.AccessToken)
+		}
+		if w := request(router, http.MethodPost, "/api/admin/cleanup-sessions?dry_run=true", nil, headers...); w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
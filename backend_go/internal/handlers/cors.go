@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAllowedOrigins is used when CORS_ALLOWED_ORIGINS is not set.
+const defaultAllowedOrigins = "http://localhost:5173" // Vite default port
+
+// originPolicy decides which browser origins may call the API.
+type originPolicy struct {
+	allowAll bool
+	exact    map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// parseOriginPolicy parses a comma-separated origin allow-list. Each entry is one of:
+//   - "*" to allow every origin (development only; credentials are then disabled)
+//   - an exact origin such as "https://vocab.example.com"
+//   - a wildcard origin such as "https://*.vercel.app", where * matches a single host label
+//   - a regular expression prefixed with "regex:", matched against the whole origin
+func parseOriginPolicy(spec string) (*originPolicy, error) {
+	policy := &originPolicy{exact: make(map[string]bool)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			policy.allowAll = true
+		case strings.HasPrefix(entry, "regex:"):
+			re, err := regexp.Compile("^(?:" + strings.TrimPrefix(entry, "regex:") + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid origin pattern %q: %w", entry, err)
+			}
+			policy.patterns = append(policy.patterns, re)
+		case strings.Contains(entry, "*"):
+			quoted := strings.ReplaceAll(regexp.QuoteMeta(entry), `\*`, `[A-Za-z0-9-]+`)
+			policy.patterns = append(policy.patterns, regexp.MustCompile("^"+quoted+"$"))
+		default:
+			policy.exact[strings.TrimSuffix(entry, "/")] = true
+		}
+	}
+	if !policy.allowAll && len(policy.exact) == 0 && len(policy.patterns) == 0 {
+		return nil, fmt.Errorf("no allowed origins in %q", spec)
+	}
+	return policy, nil
+}
+
+// allowed reports whether origin may make cross-origin requests.
+func (p *originPolicy) allowed(origin string) bool {
+	if p.allowAll || p.exact[origin] {
+		return true
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// newCORSMiddleware builds the CORS middleware from the CORS_ALLOWED_ORIGINS allow-list,
+// falling back to the Vite dev server origin when the list is unset or invalid.
+func newCORSMiddleware(spec string) []gin.HandlerFunc {
+	if spec == "" {
+		spec = defaultAllowedOrigins
+	}
+	policy, err := parseOriginPolicy(spec)
+	if err != nil {
+		log.Printf("Warning: %v, falling back to %s", err, defaultAllowedOrigins)
+		policy, _ = parseOriginPolicy(defaultAllowedOrigins)
+	}
+
+	config := cors.Config{
+		AllowMethods:  []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", "Authorization", userIDHeader},
+		ExposeHeaders: []string{"Content-Length", totalCountHeader},
+		MaxAge:        12 * time.Hour,
+	}
+	if policy.allowAll {
+		// Browsers reject credentialed requests to a wildcard origin, so never combine the two
+		config.AllowAllOrigins = true
+	} else {
+		config.AllowOriginFunc = policy.allowed
+		config.AllowCredentials = true
+	}
+
+	return []gin.HandlerFunc{rejectDisallowedOrigins(policy), cors.New(config)}
+}
+
+// rejectDisallowedOrigins answers cross-origin requests from origins outside the allow-list
+// with a JSON 403 instead of letting them fail on a missing Access-Control-Allow-Origin header.
+func rejectDisallowedOrigins(policy *originPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || origin == "http://"+c.Request.Host || origin == "https://"+c.Request.Host {
+			return
+		}
+		if !policy.allowed(origin) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Origin not allowed"})
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsRouter returns a router serving GET /api/words behind the CORS middleware of spec.
+func corsRouter(spec string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(newCORSMiddleware(spec)...)
+	router.GET("/api/words", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	return router
+}
+
+// preflight sends the CORS preflight of a GET /api/words from origin.
+func preflight(router *gin.Engine, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "/api/words", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	req.Header.Set("Access-Control-Request-Headers", "Authorization")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := corsRouter("https://vocab.example.com, https://*.vercel.app, regex:http://localhost:51[0-9]{2}")
+	tests := []struct {
+		origin string
+		allow  bool
+	}{
+		{"https://vocab.example.com", true},
+		{"https://preview-42.vercel.app", true},
+		{"http://localhost:5173", true},
+		{"https://evil.example.com", false},
+		{"http://vocab.example.com", false},
+		{"https://a.b.vercel.app", false},
+		{"https://vercel.app", false},
+		{"http://localhost:8080", false},
+		{"https://vocab.example.com.evil.io", false},
+	}
+	for _, tt := range tests {
+		w := preflight(router, tt.origin)
+		if !tt.allow {
+			if w.Code != http.StatusForbidden {
+				t.Errorf("%s: status %d, want 403", tt.origin, w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+				t.Errorf("%s: Access-Control-Allow-Origin %q, want none", tt.origin, got)
+			}
+			continue
+		}
+		if w.Code != http.StatusNoContent {
+			t.Errorf("%s: status %d, want 204", tt.origin, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.origin {
+			t.Errorf("%s: Access-Control-Allow-Origin %q, want the origin", tt.origin, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("%s: Access-Control-Allow-Credentials %q, want true", tt.origin, got)
+		}
+	}
+}
+
+func TestCORSWildcard(t *testing.T) {
+	router := corsRouter("*")
+	for _, origin := range []string{"http://localhost:3000", "https://anything.example"} {
+		w := preflight(router, origin)
+		if w.Code != http.StatusNoContent {
+			t.Errorf("%s: status %d, want 204", origin, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("%s: Access-Control-Allow-Origin %q, want *", origin, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("%s: Access-Control-Allow-Credentials %q, want none with a wildcard", origin, got)
+		}
+	}
+}
+
+func TestCORSSameOriginAndDefault(t *testing.T) {
+	router := corsRouter("")
+
+	// Requests from the API's own origin and without an Origin are not cross-origin
+	req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/words", nil)
+	req.Header.Set("Origin", "http://api.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("same-origin request: status %d, want 200", w.Code)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/words", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("request without Origin: status %d, want 200", w.Code)
+	}
+
+	// The Vite dev server is allowed by default
+	if w := preflight(router, "http://localhost:5173"); w.Code != http.StatusNoContent {
+		t.Errorf("default origin: status %d, want 204", w.Code)
+	}
+	if w := preflight(router, "http://localhost:3000"); w.Code != http.StatusForbidden {
+		t.Errorf("other origin: status %d, want 403", w.Code)
+	}
+}
+
+func TestParseOriginPolicy(t *testing.T) {
+	for _, spec := range []string{"", " , ", "regex:("} {
+		if _, err := parseOriginPolicy(spec); err == nil {
+			t.Errorf("parseOriginPolicy(%q) succeeded, want an error", spec)
+		}
+	}
+	policy, err := parseOriginPolicy("https://vocab.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !policy.allowed("https://vocab.example.com") {
+		t.Error("origin with a trailing slash in the allow-list is not allowed")
+	}
+}
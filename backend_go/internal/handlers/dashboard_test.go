@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"backend_go/internal/models"
+	"backend_go/internal/testutil"
+)
+
+func TestGetRecommendationLimit(t *testing.T) {
+	router, s := newTestServer(t, nil)
+	f := testutil.NewFixture(t, s.DB)
+	for i := 1; i <= 5; i++ {
+		name := fmt.Sprintf("Group %d", i)
+		f.Group(name).Word(fmt.Sprintf("語%d", i), "go", "word").InGroup(name)
+	}
+
+	for target, want := range map[string]int{
+		"/api/dashboard/recommendation":          defaultRecommendationLimit,
+		"/api/dashboard/recommendation?limit=1":  1,
+		"/api/dashboard/recommendation?limit=50": 5,
+	} {
+		w := request(router, http.MethodGet, target, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s: status %d, want 200", target, w.Code)
+			continue
+		}
+		var recommendations []models.GroupRecommendation
+		decode(t, w, &recommendations)
+		if len(recommendations) != want {
+			t.Errorf("GET %s returned %d groups, want %d", target, len(recommendations), want)
+		}
+	}
+	for _, limit := range []string{"0", "-1", "three"} {
+		if w := request(router, http.MethodGet, "/api/dashboard/recommendation?limit="+limit, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("limit %s: status %d, want 400", limit, w.Code)
+		}
+	}
+}
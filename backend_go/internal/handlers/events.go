@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+)
+
+// ListEvents handles GET /api/events. The optional type parameter is a comma-separated list of
+// event types to include. Learners see only their own study events.
+func ListEvents(c *gin.Context) {
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var types []string
+	errs := fieldErrors{}
+	if v := c.Query("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !isEventType(t) {
+				errs.add("type", "must be one of "+strings.Join(service.EventTypes, ", "))
+				break
+			}
+			types = append(types, t)
+		}
+	}
+	if errs.respond(c) {
+		return
+	}
+
+	events, total, err := userService(c).ListEvents(types, page, perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list events"})
+		return
+	}
+	c.Header(totalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, gin.H{
+		"items":      events,
+		"pagination": models.NewPagination(page, perPage, total),
+	})
+}
+
+// isEventType reports whether t is one of service.EventTypes.
+func isEventType(t string) bool {
+	for _, known := range service.EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ListAchievements handles GET /api/achievements
+func ListAchievements(c *gin.Context) {
+	achievements, err := userService(c).ListAchievements()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list achievements"})
+		return
+	}
+	c.JSON(http.StatusOK, achievements)
+}
@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/export"
+	"backend_go/internal/service"
+)
+
+// maxCompareGroups caps how many groups can be compared at once.
+const maxCompareGroups = 5
+
+// maxGroupCSVBytes caps the size of a CSV word list uploaded to POST /api/groups/import.
+const maxGroupCSVBytes = 5 << 20
+
+// GetGroupStats handles GET /api/groups/:id/stats
+func GetGroupStats(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	stats, err := userService(c).GetGroupStats(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group stats"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// parseIncludeArchived reads the include_archived parameter of the group listings, answering 400 if
+// it is invalid. Archived groups are left out by default.
+func parseIncludeArchived(c *gin.Context) (bool, bool) {
+	v := c.Query("include_archived")
+	if v == "" {
+		return false, true
+	}
+	include, err := strconv.ParseBool(v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "include_archived must be true or false"})
+		return false, false
+	}
+	return include, true
+}
+
+// ArchiveGroup handles POST /api/groups/:id/archive
+func ArchiveGroup(c *gin.Context) {
+	setGroupArchived(c, true)
+}
+
+// UnarchiveGroup handles POST /api/groups/:id/unarchive
+func UnarchiveGroup(c *gin.Context) {
+	setGroupArchived(c, false)
+}
+
+// setGroupArchived archives or unarchives the group of the request and responds with the group and
+// the number of its open study sessions, with a note when an archived group still has some.
+func setGroupArchived(c *gin.Context, archived bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	open, err := userService(c).SetGroupArchived(id, archived)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update group"})
+		}
+		return
+	}
+	group, err := svc.GetGroupByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group after update"})
+		return
+	}
+	response := gin.H{"group": group, "open_study_sessions": open}
+	if archived && open > 0 {
+		response["note"] = fmt.Sprintf("The group has %d study session(s) in progress, which stay open", open)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// defaultStaleDays is how many days without study make a group stale without a days parameter.
+const defaultStaleDays = 14
+
+// GetStaleGroups handles GET /api/groups/stale?days=14
+func GetStaleGroups(c *gin.Context) {
+	days := defaultStaleDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a non-negative integer"})
+			return
+		}
+		days = n
+	}
+	includeArchived, ok := parseIncludeArchived(c)
+	if !ok {
+		return
+	}
+	groups, err := userService(c).GetStaleGroups(time.Now(), days, includeArchived)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stale groups"})
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// CompareGroups handles GET /api/groups/compare?ids=3,7
+func CompareGroups(c *gin.Context) {
+	var ids []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(c.Query("ids"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ids must be a comma-separated list of group IDs"})
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < 2 || len(ids) > maxCompareGroups {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Between 2 and %d group ids are required", maxCompareGroups)})
+		return
+	}
+
+	stats, err := userService(c).ListGroupStats(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group stats"})
+		return
+	}
+	found := make(map[int]bool)
+	foundIDs := make([]int, 0, len(stats))
+	for _, st := range stats {
+		found[st.GroupID] = true
+		foundIDs = append(foundIDs, st.GroupID)
+	}
+	missing := make([]int, 0)
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	overlaps, err := svc.GetGroupOverlaps(foundIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group overlaps"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"groups":   stats,
+		"overlaps": overlaps,
+		"missing":  missing,
+	})
+}
+
+// CreateShareLink handles POST /api/groups/:id/share
+func CreateShareLink(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	var req struct {
+		// ExpiresInHours makes the link expire after that many hours; zero means it never expires
+		ExpiresInHours int `json:"expires_in_hours"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	if req.ExpiresInHours < 0 {
+		errs := fieldErrors{"expires_in_hours": "must not be negative"}
+		errs.respond(c)
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+	}
+	link, err := svc.CreateShareLink(id, expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, link)
+}
+
+// RevokeShareLink handles DELETE /api/groups/:id/share/:token
+func RevokeShareLink(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	if err := svc.RevokeShareLink(id, c.Param("token")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share link"})
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetSharedGroup handles GET /api/shared/:token
+func GetSharedGroup(c *gin.Context) {
+	shared, err := svc.GetSharedGroup(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shared group"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, shared)
+}
+
+// exportMediaTypes maps the media types an Accept header may ask an export for to their format.
+var exportMediaTypes = map[string]string{
+	"text/csv":         "csv",
+	"application/json": "json",
+}
+
+// ExportGroup handles GET /api/groups/:id/export. The format parameter selects the format; without
+// it the Accept header picks CSV or JSON, defaulting to CSV, and other media types get a 406.
+func ExportGroup(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	format := c.Query("format")
+	if format == "" {
+		mediaType := c.NegotiateFormat("text/csv", "application/json")
+		if mediaType == "" {
+			c.JSON(http.StatusNotAcceptable, gin.H{"error": "Exports are available as text/csv or application/json"})
+			return
+		}
+		format = exportMediaTypes[mediaType]
+	}
+	opts := export.Options{WithRomaji: c.Query("romaji") == "true"}
+	switch term := c.DefaultQuery("term", "japanese"); term {
+	case "japanese":
+	case "english":
+		opts.TermEnglish = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "term must be japanese or english"})
+		return
+	}
+	exporter, err := export.New(format, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := svc.GetGroupByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group"})
+		return
+	}
+	words, err := svc.GetGroupWords(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group words"})
+		return
+	}
+
+	filename := fmt.Sprintf("group-%d-%s.%s", group.ID, format, exporter.Extension())
+	c.Header("Content-Type", exporter.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+	if err := exporter.Write(c.Writer, words); err != nil {
+		// The status line is already sent, so the client only sees a truncated body
+		log.Printf("Exporting group %d as %s: %v", id, format, err)
+	}
+}
+
+// MoveGroupWords handles POST /api/groups/:id/words/move, which moves word_ids from the group
+// from_group_id into the group :id. It answers with the IDs moved, skipped and failed rather than
+// failing as a whole on one bad ID.
+func MoveGroupWords(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	var req struct {
+		WordIDs     []int `json:"word_ids"`
+		FromGroupID int   `json:"from_group_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	errs := fieldErrors{}
+	switch {
+	case len(req.WordIDs) == 0:
+		errs.add("word_ids", "is required")
+	case len(req.WordIDs) > maxBatchIDs:
+		errs.add("word_ids", fmt.Sprintf("must contain at most %d ids", maxBatchIDs))
+	}
+	errs.requirePositive("from_group_id", req.FromGroupID)
+	if req.FromGroupID == id {
+		errs.add("from_group_id", "must be another group than the destination")
+	}
+	if errs.respond(c) {
+		return
+	}
+
+	result, err := svc.MoveGroupWords(id, req.FromGroupID, req.WordIDs)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move words"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"moved":     len(result.Succeeded),
+		"succeeded": result.Succeeded,
+		"skipped":   result.Skipped,
+		"failed":    result.Failed,
+	})
+}
+
+// BulkAddWordsToGroup handles POST /api/groups/:id/words/bulk, which adds word_ids to the group in
+// one transaction. Words already in the group are skipped, and IDs of unknown words fail the whole
+// batch with 400.
+func BulkAddWordsToGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	var req struct {
+		WordIDs []int `json:"word_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	switch {
+	case len(req.WordIDs) == 0:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "word_ids is required"})
+		return
+	case len(req.WordIDs) > maxBatchIDs:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("word_ids must contain at most %d ids", maxBatchIDs)})
+		return
+	}
+
+	added, skipped, err := svc.BulkAddWordsToGroup(id, req.WordIDs)
+	var unknown *service.UnknownWordsError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+	case errors.As(err, &unknown):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ids", "word_ids": unknown.IDs})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add words to group"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"added": added, "skipped": skipped})
+	}
+}
+
+// importGroupCSV answers a multipart POST /api/groups/import: the CSV word list of the file field
+// (see service.ParseWordsCSV) is added to the group group_id or to the group named name, which is
+// created when there is none. Words that already exist are linked to the group instead of created.
+// It answers 200 rather than 201 when nothing was created, as when the same file is imported again.
+func importGroupCSV(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+	data, ok := readUpload(c, "file", maxGroupCSVBytes)
+	if !ok {
+		return
+	}
+	errs := fieldErrors{}
+	name := c.PostForm("name")
+	errs.text("name", &name, maxNameLength)
+	groupID := 0
+	if v := c.PostForm("group_id"); v != "" {
+		var err error
+		if groupID, err = strconv.Atoi(v); err != nil || groupID <= 0 {
+			errs.add("group_id", "must be a positive integer")
+		} else if name != "" {
+			errs.add("name", "cannot be set with group_id")
+		}
+	} else {
+		errs.require("name", name)
+	}
+	words, err := service.ParseWordsCSV(bytes.NewReader(data))
+	if err != nil {
+		errs.add("file", "must be a CSV file with a header row: "+err.Error())
+	} else if len(words) == 0 {
+		errs.add("file", "contains no words")
+	}
+	for i := range words {
+		w := &words[i]
+		prefix := fmt.Sprintf("rows[%d].", i)
+		cleanWord(errs, prefix, w)
+		errs.require(prefix+"japanese", w.Japanese)
+		errs.require(prefix+"romaji", w.Romaji)
+		errs.require(prefix+"english", w.English)
+		validateJLPTLevel(errs, prefix+"jlpt_level", w.JLPTLevel)
+		errs.maxLength(prefix+"mnemonic", w.Mnemonic, maxMnemonicLength)
+	}
+	if errs.respond(c) {
+		return
+	}
+
+	header, _ := c.FormFile("file")
+	result, err := svc.ImportWordsIntoGroup(groupID, name, currentUsername(c), words, "csv:"+header.Filename, dryRun)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import group"})
+		}
+		return
+	}
+	// Importing a file already imported creates nothing
+	status := importStatus(dryRun)
+	if !result.GroupCreated && result.WordsCreated == 0 && result.WordsLinked == 0 {
+		status = http.StatusOK
+	}
+	c.JSON(status, result)
+}
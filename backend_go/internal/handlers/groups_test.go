@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/models"
+	"backend_go/internal/testutil"
+)
+
+func TestGroupDefaultActivityType(t *testing.T) {
+	router, s := newTestServer(t, nil)
+	f := testutil.NewFixture(t, s.DB).Group("N5").
+		Activity("Kana quiz", "quiz").Activity("Typing", "typing").Activity("Kanji quiz", "quiz")
+	target := "/api/groups/" + strconv.Itoa(f.GroupID("N5"))
+	startSession := func() *models.StudySession {
+		t.Helper()
+		w := request(router, http.MethodPost, "/api/study_sessions", gin.H{"group_id": f.GroupID("N5")})
+		if w.Code != http.StatusCreated {
+			return nil
+		}
+		var session models.StudySession
+		decode(t, w, &session)
+		return &session
+	}
+
+	if session := startSession(); session != nil {
+		t.Errorf("without a default activity type started %+v", session)
+	}
+
+	// Setting only the default activity type keeps the name
+	w := request(router, http.MethodPut, target, gin.H{"default_activity_type": "quiz"})
+	var group models.Group
+	decode(t, w, &group)
+	if w.Code != http.StatusOK || group.Name != "N5" || group.DefaultActivityType == nil || *group.DefaultActivityType != "quiz" {
+		t.Fatalf("update: status %d, %+v, want N5 with the quiz default", w.Code, group)
+	}
+	// The first activity of the type is used
+	var kanaQuiz int
+	if err := s.DB.QueryRow("SELECT id FROM study_activities WHERE name = 'Kana quiz'").Scan(&kanaQuiz); err != nil {
+		t.Fatal(err)
+	}
+	if session := startSession(); session == nil || session.StudyActivityID != kanaQuiz {
+		t.Errorf("with the quiz default started %+v, want the Kana quiz activity %d", session, kanaQuiz)
+	}
+
+	// No activity of the type leaves nothing to fall back to
+	request(router, http.MethodPut, target, gin.H{"default_activity_type": "listening"})
+	if fields := validationFields(t, request(router, http.MethodPost, "/api/study_sessions", gin.H{"group_id": f.GroupID("N5")})); fields["study_activity_id"] == "" {
+		t.Errorf("with no listening activity: errors %v, want study_activity_id", fields)
+	}
+
+	if fields := validationFields(t, request(router, http.MethodPut, target, gin.H{"default_activity_type": "singing"})); fields["default_activity_type"] == "" {
+		t.Errorf("unknown type: errors %v, want default_activity_type", fields)
+	}
+	decode(t, request(router, http.MethodPut, target, gin.H{"default_activity_type": ""}), &group)
+	if group.DefaultActivityType != nil {
+		t.Errorf("after removing the default: %+v", group)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/config"
+	"backend_go/internal/service"
+	"backend_go/internal/testutil"
+)
+
+// newTestServer registers the routes on a new router over a migrated test database, with the
+// configuration loaded from the environment after setting env. JWT login is enabled when env sets
+// JWT_SECRET, as the server does. The handlers use package state, so tests using it must not run
+// in parallel.
+func newTestServer(t *testing.T, env map[string]string) (*gin.Engine, *service.Service) {
+	t.Helper()
+	t.Setenv("APP_ENV", "test")
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	settings, err := config.Load()
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	settings.MediaDir = t.TempDir()
+
+	s := testutil.NewService(t)
+	s.SetLocation(settings.Location)
+	if settings.JWTSecret != "" {
+		s.EnableAuth(service.AuthConfig{Secret: []byte(settings.JWTSecret), AccessTTL: settings.JWTAccessTTL, RefreshTTL: settings.JWTRefreshTTL})
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, s, settings)
+	return router, s
+}
+
+// request sends a request to router with body encoded as JSON, unless it is nil or already an
+// io.Reader, and headers given as name and value pairs.
+func request(router http.Handler, method, target string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			panic(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decode decodes the JSON body of w into v, failing the test if it is not valid.
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding response %q: %v", w.Body.String(), err)
+	}
+}
+
+// bearer returns the Authorization header pair of an access token.
+func bearer(token string) []string {
+	return []string{"Authorization", "Bearer " + token}
+}
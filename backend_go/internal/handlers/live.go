@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// StudySessionSocket handles GET /api/study_sessions/:id/ws. It upgrades to a WebSocket that
+// receives every review recorded in the session as a JSON message until the client disconnects.
+// Messages sent by the client are ignored. With JWT login enabled, browsers pass the access token
+// as the access_token query parameter, see queryTokenRoutes.
+func StudySessionSocket(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
+		return
+	}
+	reviews, unsubscribe, err := userService(c).SubscribeStudySession(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to subscribe to study session"})
+		return
+	}
+	defer unsubscribe()
+
+	// websocket.Server rather than websocket.Handler: the Origin check is left to the CORS middleware
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		// Reading fails once the client goes away, which ends the subscription and the loop below
+		go func() {
+			io.Copy(io.Discard, ws)
+			unsubscribe()
+		}()
+		for review := range reviews {
+			if err := websocket.JSON.Send(ws, review); err != nil {
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"backend_go/internal/models"
+	"backend_go/internal/testutil"
+)
+
+// dialSession opens the WebSocket of a study session on server, with query appended to its URL.
+func dialSession(server *httptest.Server, sessionID int, query string) (*websocket.Conn, error) {
+	url := fmt.Sprintf("ws%s/api/study_sessions/%d/ws%s", strings.TrimPrefix(server.URL, "http"), sessionID, query)
+	ws, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if resp != nil && resp.Body != nil {
+		resp.Body.Close()
+	}
+	return ws, err
+}
+
+// receive reads the next review from ws, failing the test if none arrives within a second.
+func receive(t *testing.T, ws *websocket.Conn) models.LiveReview {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	var review models.LiveReview
+	if err := ws.ReadJSON(&review); err != nil {
+		t.Fatalf("receiving a review: %v", err)
+	}
+	return review
+}
+
+func TestStudySessionSocket(t *testing.T) {
+	router, s := newTestServer(t, nil)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	f := testutil.NewFixture(t, s.DB).Group("N5").
+		Word("水", "mizu", "water").Session().
+		Word("火", "hi", "fire").Session()
+	other, watched := f.SessionIDs()[0], f.SessionIDs()[1]
+
+	first, err := dialSession(server, watched, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := dialSession(server, watched, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	// A review of another session is not sent, the review of the watched one reaches every client
+	review := func(sessionID int, japanese string, body gin.H) {
+		target := fmt.Sprintf("/api/study_sessions/%d/words/%d/review", sessionID, f.WordID(japanese))
+		if w := request(router, http.MethodPost, target, body); w.Code != http.StatusOK && w.Code != http.StatusCreated {
+			t.Fatalf("POST %s: status %d: %s", target, w.Code, w.Body)
+		}
+	}
+	review(other, "水", gin.H{"correct": true})
+	review(watched, "火", gin.H{"correct": false, "attempt": 2})
+	for _, ws := range []*websocket.Conn{first, second} {
+		got := receive(t, ws)
+		if got.StudySessionID != watched || got.WordID != f.WordID("火") || got.Japanese != "火" || got.English != "fire" || got.Correct || got.Attempt != 2 {
+			t.Errorf("received %+v, want the wrong second attempt at 火 in session %d", got, watched)
+		}
+	}
+
+	// A client that left does not keep the others from receiving
+	first.Close()
+	review(watched, "火", gin.H{"skipped": true, "attempt": 3})
+	if got := receive(t, second); !got.Skipped || got.Attempt != 3 {
+		t.Errorf("received %+v, want the skipped third attempt", got)
+	}
+
+	if _, err := dialSession(server, watched+100, ""); err == nil {
+		t.Error("dialing a session that does not exist succeeded")
+	}
+	for target, want := range map[string]int{
+		fmt.Sprintf("/api/study_sessions/%d/ws", watched+100): http.StatusNotFound,
+		"/api/study_sessions/abc/ws":                          http.StatusBadRequest,
+	} {
+		if w := request(router, http.MethodGet, target, nil); w.Code != want {
+			t.Errorf("GET %s: status %d, want %d", target, w.Code, want)
+		}
+	}
+}
+
+func TestStudySessionSocketAuth(t *testing.T) {
+	router, s := newTestServer(t, authEnv)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	createUsers(t, s)
+	editorID, err := s.CreateUser("learner", "password123", models.RoleEditor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := testutil.NewFixture(t, s.DB).User(editorID).Group("N5").Word("水", "mizu", "water").Session()
+	_, tokens := login(t, router, "learner", "password123")
+	_, viewer := login(t, router, "viewer", "password123")
+
+	if _, err := dialSession(server, f.SessionID(), ""); err == nil {
+		t.Error("dialing without a token succeeded")
+	}
+	if _, err := dialSession(server, f.SessionID(), "?access_token="+tokens.AccessToken+"x"); err == nil {
+		t.Error("dialing with a tampered token succeeded")
+	}
+	// Other learners cannot watch the session
+	if w := request(router, http.MethodGet, fmt.Sprintf("/api/study_sessions/%d/ws?access_token=%s", f.SessionID(), viewer.AccessToken), nil); w.Code != http.StatusNotFound {
+		t.Errorf("another learner's token: status %d, want 404", w.Code)
+	}
+	// The query parameter is only accepted for the WebSocket
+	if w := request(router, http.MethodGet, "/api/groups?access_token="+tokens.AccessToken, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("access_token on another route: status %d, want 401", w.Code)
+	}
+
+	ws, err := dialSession(server, f.SessionID(), "?access_token="+tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	target := fmt.Sprintf("/api/study_sessions/%d/words/%d/review", f.SessionID(), f.WordID("水"))
+	if w := request(router, http.MethodPost, target, gin.H{"correct": true}, bearer(tokens.AccessToken)...); w.Code >= 300 {
+		t.Fatalf("POST %s: status %d: %s", target, w.Code, w.Body)
+	}
+	if got := receive(t, ws); got.WordID != f.WordID("水") || !got.Correct {
+		t.Errorf("received %+v, want the correct review of 水", got)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+)
+
+// defaultMistakesSessionWords and maxMistakesSessionWords bound the limit of a mistakes study session.
+const (
+	defaultMistakesSessionWords = 20
+	maxMistakesSessionWords     = 100
+)
+
+// ListMistakes handles GET /api/mistakes. sort_by is recency (default), listing every wrong answer,
+// or frequency, listing each word once with its number of misses.
+func ListMistakes(c *gin.Context) {
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	errs := fieldErrors{}
+	filter := parseMistakeFilter(c, errs)
+	sortBy := c.DefaultQuery("sort_by", service.MistakeSortRecency)
+	if sortBy != service.MistakeSortRecency && sortBy != service.MistakeSortFrequency {
+		errs.add("sort_by", fmt.Sprintf("must be %s or %s", service.MistakeSortRecency, service.MistakeSortFrequency))
+	}
+	if errs.respond(c) {
+		return
+	}
+
+	mistakes, total, err := userService(c).ListMistakes(filter, sortBy, page, perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list mistakes"})
+		return
+	}
+	c.Header(totalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, gin.H{
+		"items":      mistakes,
+		"pagination": models.NewPagination(page, perPage, total),
+	})
+}
+
+// parseMistakeFilter reads the from, to and group_id query parameters of the mistakes log,
+// recording the invalid ones in errs.
+func parseMistakeFilter(c *gin.Context, errs fieldErrors) models.MistakeFilter {
+	var filter models.MistakeFilter
+	var err error
+	if v := c.Query("from"); v != "" {
+		if filter.From, err = parseDateParam(v, false); err != nil {
+			errs.add("from", "must be an RFC3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if filter.To, err = parseDateParam(v, true); err != nil {
+			errs.add("to", "must be an RFC3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	if v := c.Query("group_id"); v != "" {
+		if filter.GroupID, err = strconv.Atoi(v); err != nil || filter.GroupID <= 0 {
+			errs.add("group_id", "must be a positive integer")
+		}
+	}
+	return filter
+}
+
+// CreateMistakesSession handles POST /api/mistakes/create-session, which starts a study session
+// for group_id queueing the words most often answered wrong in that group's sessions. The from and
+// to query parameters narrow the mistakes considered, as in ListMistakes.
+func CreateMistakesSession(c *gin.Context) {
+	var req struct {
+		GroupID         int `json:"group_id"`
+		StudyActivityID int `json:"study_activity_id"`
+		Limit           int `json:"limit"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultMistakesSessionWords
+	}
+	errs := fieldErrors{}
+	filter := parseMistakeFilter(c, errs)
+	errs.requirePositive("group_id", req.GroupID)
+	if req.StudyActivityID != 0 {
+		errs.requirePositive("study_activity_id", req.StudyActivityID)
+	}
+	if req.Limit < 1 || req.Limit > maxMistakesSessionWords {
+		errs.add("limit", fmt.Sprintf("must be between 1 and %d", maxMistakesSessionWords))
+	}
+	if errs.respond(c) {
+		return
+	}
+	filter.GroupID = req.GroupID
+
+	id, queued, err := userService(c).CreateMistakesSession(filter, req.StudyActivityID, req.Limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		case errors.Is(err, service.ErrNoDefaultActivity):
+			errs.add("study_activity_id", "is required because the group has no default study activity")
+			errs.respond(c)
+		case errors.Is(err, service.ErrNoMistakes):
+			errs.add("group_id", "has no mistakes to study in the given range")
+			errs.respond(c)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create study session"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"id":           id,
+		"queued_words": queued,
+	})
+}
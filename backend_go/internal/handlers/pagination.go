@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/config"
+	"backend_go/internal/service"
+)
+
+// parsePagination reads the page and per_page query parameters, defaulting to the
+// first page of cfg.DefaultPageSize items. A per_page above cfg.MaxPageSize is capped or rejected
+// according to cfg.PageSizeMode.
+func parsePagination(c *gin.Context) (int, int, error) {
+	page := 1
+	if v := c.Query("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 1 {
+			return 0, 0, errors.New("page must be a positive integer")
+		}
+		page = p
+	}
+	perPage := cfg.DefaultPageSize
+	if v := c.Query("per_page"); v != "" {
+		pp, err := strconv.Atoi(v)
+		if err != nil || pp < 1 {
+			return 0, 0, errors.New("per_page must be a positive integer")
+		}
+		perPage = pp
+	}
+	perPage, err := capPageSize("per_page", perPage)
+	if err != nil {
+		return 0, 0, err
+	}
+	return page, perPage, nil
+}
+
+// capPageSize applies cfg.PageSizeMode to the page size n read from the named parameter. In clamp
+// mode it returns at most cfg.MaxPageSize, in reject mode an error when n exceeds it.
+func capPageSize(name string, n int) (int, error) {
+	if n <= cfg.MaxPageSize {
+		return n, nil
+	}
+	if cfg.PageSizeMode == config.PageSizeReject {
+		return 0, fmt.Errorf("%s must be at most %d", name, cfg.MaxPageSize)
+	}
+	return cfg.MaxPageSize, nil
+}
+
+// totalCountHeader carries the unpaginated number of items behind a list response.
+const totalCountHeader = "X-Total-Count"
+
+// setTotalCount sets the X-Total-Count header from count. If counting fails it responds with
+// a 500 and returns false, so the caller must stop handling the request.
+func setTotalCount(c *gin.Context, count func() (int, error)) bool {
+	total, err := count()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count items"})
+		return false
+	}
+	c.Header(totalCountHeader, strconv.Itoa(total))
+	return true
+}
+
+// parseCursor reads the cursor and limit query parameters of cursor pagination. cursor is the
+// next_cursor of the previous page, empty for the first page, and ok is false when the request
+// does not use cursor pagination. limit defaults to cfg.DefaultPageSize and is capped like per_page.
+func parseCursor(c *gin.Context) (cursor string, limit int, ok bool, err error) {
+	if cursor, ok = c.GetQuery("cursor"); !ok {
+		return "", 0, false, nil
+	}
+	limit, err = parseLimit(c)
+	return cursor, limit, true, err
+}
+
+// parseLimit reads the limit query parameter of cursor pagination, cfg.DefaultPageSize by default.
+func parseLimit(c *gin.Context) (int, error) {
+	limit := cfg.DefaultPageSize
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, errors.New("limit must be a positive integer")
+		}
+		limit = n
+	}
+	return capPageSize("limit", limit)
+}
+
+// respondCursorPage writes a page of cursor pagination, the items followed by the cursor of the
+// next page, null on the last one. A service.ErrInvalidCursor answers 400 telling the client to
+// start over, and other errors answer 500 with the failure message.
+func respondCursorPage(c *gin.Context, items interface{}, next *string, err error, failure string) {
+	if errors.Is(err, service.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid or expired cursor",
+			"hint":  "Start again from the first page with an empty cursor",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items":       items,
+		"next_cursor": next,
+	})
+}
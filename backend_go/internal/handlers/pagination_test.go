@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/models"
+	"backend_go/internal/testutil"
+)
+
+// cursorPage is a page of cursor pagination.
+type cursorPage struct {
+	Items      []models.Word `json:"items"`
+	NextCursor *string       `json:"next_cursor"`
+}
+
+func TestWordsCursorWhileCreating(t *testing.T) {
+	router, s := newTestServer(t, nil)
+	f := testutil.NewFixture(t, s.DB).Group("N5")
+	for i := 0; i < 9; i++ {
+		f.Word(fmt.Sprintf("語%d", i), fmt.Sprintf("go%d", i), fmt.Sprintf("word %d", i))
+	}
+
+	// Page through the words newest first while words are added, as an import would
+	seen := make(map[int]bool)
+	cursor := ""
+	for page := 1; ; page++ {
+		if page > 10 {
+			t.Fatal("the listing did not end after 10 pages")
+		}
+		w := request(router, http.MethodGet, "/api/words?sort_by=id&order=desc&limit=2&cursor="+url.QueryEscape(cursor), nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("page %d: status %d: %s", page, w.Code, w.Body)
+		}
+		var p cursorPage
+		decode(t, w, &p)
+		for _, word := range p.Items {
+			if seen[word.ID] {
+				t.Errorf("page %d repeats word %d", page, word.ID)
+			}
+			seen[word.ID] = true
+		}
+		if p.NextCursor == nil {
+			break
+		}
+		cursor = *p.NextCursor
+		body := gin.H{"japanese": fmt.Sprintf("新%d", page), "romaji": "shin", "english": "new"}
+		if w := request(router, http.MethodPost, "/api/words", body); w.Code != http.StatusCreated {
+			t.Fatalf("creating a word: status %d: %s", w.Code, w.Body)
+		}
+	}
+	// Words added while paging are newer than the cursor, so only the nine words are listed
+	for i := 0; i < 9; i++ {
+		if id := f.WordID(fmt.Sprintf("語%d", i)); !seen[id] {
+			t.Errorf("word %d was never listed", id)
+		}
+	}
+	if len(seen) != 9 {
+		t.Errorf("listed %d words, want the 9 there were when paging started", len(seen))
+	}
+}
+
+func TestInvalidCursor(t *testing.T) {
+	router, s := newTestServer(t, nil)
+	f := testutil.NewFixture(t, s.DB).Group("N5")
+	for i := 0; i < 3; i++ {
+		f.Word(fmt.Sprintf("語%d", i), "go", "word").Session().Review(true)
+	}
+
+	var words cursorPage
+	decode(t, request(router, http.MethodGet, "/api/words?limit=1&cursor=", nil), &words)
+	if words.NextCursor == nil {
+		t.Fatal("no next cursor after the first of 3 words")
+	}
+	next := url.QueryEscape(*words.NextCursor)
+
+	for _, target := range []string{
+		"/api/words?cursor=garbage",
+		"/api/reviews?cursor=garbage",
+		"/api/study_sessions?cursor=garbage",
+		// A cursor is only valid for the listing and order it was issued for
+		"/api/reviews?cursor=" + next,
+		"/api/study_sessions?cursor=" + next,
+		"/api/words?sort_by=romaji&cursor=" + next,
+	} {
+		w := request(router, http.MethodGet, target, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status %d, want 400", target, w.Code)
+			continue
+		}
+		var body struct {
+			Error string `json:"error"`
+			Hint  string `json:"hint"`
+		}
+		decode(t, w, &body)
+		if body.Hint == "" {
+			t.Errorf("GET %s: no hint to start again in %s", target, w.Body)
+		}
+	}
+
+	if w := request(router, http.MethodGet, "/api/words?limit=0&cursor=", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("limit 0: status %d, want 400", w.Code)
+	}
+	if w := request(router, http.MethodGet, "/api/words?page=2&cursor="+next, nil); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("cursor with page: status %d, want 422", w.Code)
+	}
+	if w := request(router, http.MethodGet, "/api/words?limit=1&cursor="+next, nil); w.Code != http.StatusOK {
+		t.Errorf("the issued cursor: status %d, want 200", w.Code)
+	}
+}
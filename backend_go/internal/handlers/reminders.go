@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/service"
+)
+
+// maxDailyGoal caps the daily review goal of the study reminder.
+const maxDailyGoal = 1000
+
+// GetReminderSettings handles GET /api/settings/reminders
+func GetReminderSettings(c *gin.Context) {
+	settings, err := svc.GetReminderSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminder settings"})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateReminderSettings handles PUT /api/settings/reminders. Fields left out of the body keep
+// their current values.
+func UpdateReminderSettings(c *gin.Context) {
+	settings, err := svc.GetReminderSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminder settings"})
+		return
+	}
+	if err := c.ShouldBindJSON(settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	errs := fieldErrors{}
+	if t, err := time.Parse("15:04", strings.TrimSpace(settings.TimeOfDay)); err != nil {
+		errs.add("time_of_day", "must be an HH:MM time")
+	} else {
+		settings.TimeOfDay = t.Format("15:04")
+	}
+	days := make([]string, 0, len(settings.Days))
+	for _, day := range service.Weekdays {
+		for _, d := range settings.Days {
+			if strings.EqualFold(strings.TrimSpace(d), day) {
+				days = append(days, day)
+				break
+			}
+		}
+	}
+	for _, d := range settings.Days {
+		if !isWeekday(d) {
+			errs.add("days", "must list days among "+strings.Join(service.Weekdays, ", "))
+			break
+		}
+	}
+	settings.Days = days
+	settings.WebhookURL = strings.TrimSpace(settings.WebhookURL)
+	if settings.WebhookURL != "" {
+		if u, err := url.Parse(settings.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add("webhook_url", "must be an http or https URL")
+		}
+	} else if settings.Enabled {
+		errs.add("webhook_url", "is required to enable reminders")
+	}
+	if settings.DailyGoal < 1 || settings.DailyGoal > maxDailyGoal {
+		errs.add("daily_goal", fmt.Sprintf("must be between 1 and %d", maxDailyGoal))
+	}
+	if errs.respond(c) {
+		return
+	}
+
+	updated, err := svc.UpdateReminderSettings(*settings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reminder settings"})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// isWeekday reports whether d names one of service.Weekdays, ignoring case and spaces.
+func isWeekday(d string) bool {
+	for _, day := range service.Weekdays {
+		if strings.EqualFold(strings.TrimSpace(d), day) {
+			return true
+		}
+	}
+	return false
+}
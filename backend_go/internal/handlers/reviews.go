@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+)
+
+// parseDateParam parses a date query parameter given either as RFC3339 or as YYYY-MM-DD.
+// Date-only values are interpreted in the configured timezone; when endOfDay is set they cover
+// the whole day.
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, svc.Location())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return t, nil
+}
+
+// GetWordHistory handles GET /api/words/:id/history
+func GetWordHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	history, err := userService(c).GetWordHistory(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word history"})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// ListReviews handles GET /api/reviews. A cursor parameter selects cursor pagination with limit
+// instead of page and per_page, see respondCursorPage.
+func ListReviews(c *gin.Context) {
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var filter models.ReviewFilter
+	errs := fieldErrors{}
+	if v := c.Query("from"); v != "" {
+		if filter.From, err = parseDateParam(v, false); err != nil {
+			errs.add("from", "must be an RFC3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if filter.To, err = parseDateParam(v, true); err != nil {
+			errs.add("to", "must be an RFC3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	if v := c.Query("word_id"); v != "" {
+		if filter.WordID, err = strconv.Atoi(v); err != nil || filter.WordID <= 0 {
+			errs.add("word_id", "must be a positive integer")
+		}
+	}
+	if v := c.Query("group_id"); v != "" {
+		if filter.GroupID, err = strconv.Atoi(v); err != nil || filter.GroupID <= 0 {
+			errs.add("group_id", "must be a positive integer")
+		}
+	}
+	if v := c.Query("correct"); v != "" {
+		correct, err := strconv.ParseBool(v)
+		if err != nil {
+			errs.add("correct", "must be true or false")
+		}
+		filter.Correct = &correct
+	}
+	if v := c.Query("skipped"); v != "" {
+		skipped, err := strconv.ParseBool(v)
+		if err != nil {
+			errs.add("skipped", "must be true or false")
+		}
+		filter.Skipped = &skipped
+	}
+	if errs.respond(c) {
+		return
+	}
+
+	if cursor, limit, ok, err := parseCursor(c); ok {
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		reviews, next, err := userService(c).ListReviewsByCursor(filter, cursor, limit)
+		respondCursorPage(c, reviews, next, err, "Failed to list reviews")
+		return
+	}
+	reviews, total, err := userService(c).ListReviews(filter, page, perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list reviews"})
+		return
+	}
+	c.Header(totalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, gin.H{
+		"items":      reviews,
+		"pagination": models.NewPagination(page, perPage, total),
+	})
+}
+
+// UndoLastReview handles DELETE /api/study_sessions/:id/reviews/last, which removes the session's
+// most recent review, such as one given by pressing the wrong button, and returns it.
+func UndoLastReview(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
+		return
+	}
+	review, err := userService(c).UndoLastReview(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+		case errors.Is(err, service.ErrNoReviews):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study session has no reviews to undo"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to undo review"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Review undone",
+		"review":  review,
+	})
+}
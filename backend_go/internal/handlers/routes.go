@@ -4,69 +4,155 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gin-contrib/cors"
 
+	"backend_go/internal/config"
+	"backend_go/internal/media"
+	"backend_go/internal/middleware"
+	"backend_go/internal/models"
 	"backend_go/internal/service"
 )
 
 var svc *service.Service
 
-// RegisterRoutes registers API routes and their handlers, and accepts a service instance.
-func RegisterRoutes(router *gin.Engine, serviceInstance *service.Service) {
-	// Add recovery middleware to catch panics and prevent ECONNRESET errors
-	router.Use(gin.Recovery())
-	
-	// Configure CORS
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173"}, // Vite default port
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * 60 * 60, // 12 hours
-	}))
-	
+// cfg holds the settings of the handlers, such as page sizes and rate limits.
+var cfg *config.Config
+
+// RegisterRoutes registers API routes and their handlers, and accepts a service instance and the
+// server settings.
+func RegisterRoutes(router *gin.Engine, serviceInstance *service.Service, settings *config.Config) {
+	// Configure CORS from the CORS_ALLOWED_ORIGINS allow-list
+	router.Use(newCORSMiddleware(settings.CORSAllowedOrigins)...)
+
 	svc = serviceInstance
-	api := router.Group("/api")
+	cfg = settings
+
+	// Uploaded media is served as static files outside /api
+	if store := svc.MediaStore(); store != nil {
+		router.Static(media.URLPrefix, store.Dir)
+	}
+	api := router.Group("/api", authenticate(cfg.APIKey))
 	{
+		// Auth endpoints
+		api.POST("/auth/login", Login)
+		api.POST("/auth/refresh", RefreshToken)
+		api.POST("/auth/logout", Logout)
+		api.POST("/admin/users", CreateUser)
+		api.GET("/admin/words/missing_reading", ListWordsMissingReading)
+		api.GET("/admin/words/invalid_parts", ListWordsWithInvalidParts)
+		api.POST("/admin/optimize", OptimizeDB)
+		api.POST("/admin/cleanup-sessions", CleanupSessions)
+		api.POST("/admin/seed", LoadSeedDataset)
+		api.GET("/admin/query_timings", GetQueryTimings)
+		api.GET("/version", GetVersion)
+
 		// Dashboard endpoints registered directly on the API group
 		api.GET("/dashboard/last-study-session", GetLastStudySession)
 		api.GET("/dashboard/study-progress", GetStudyProgress)
 		api.GET("/dashboard/quick-stats", GetQuickStats)
+		api.GET("/dashboard/retention", GetRetention)
+		api.GET("/dashboard/recommendation", GetRecommendation)
+		api.GET("/dashboard/velocity", GetVelocity)
+		api.GET("/dashboard/activity-stats", GetActivityStats)
+		api.GET("/dashboard/upcoming", GetUpcomingReviews)
+		api.GET("/dashboard/jlpt_breakdown", GetJLPTBreakdown)
+		api.POST("/dashboard/groups_stats", GetGroupSetStats)
+		api.GET("/digest", GetDigest)
 
 		// Study Activities endpoints
 		api.GET("/study_activities/:id", GetStudyActivity)
 		api.GET("/study_activities/:id/study_sessions", GetStudyActivitySessions)
+		api.GET("/study_activities/:id/sessions", ListStudyActivitySessions)
 		api.POST("/study_activities", CreateStudyActivity)
 
 		// Words endpoints
 		api.GET("/words", ListWords)
+		api.HEAD("/words", HeadWords)
 		api.GET("/words/:id", GetWord)
 		api.POST("/words", CreateWord)
+		api.POST("/words/batch", GetWordsBatch)
+		api.POST("/words/bulk-delete", BulkDeleteWords)
+		api.POST("/words/bulk_delete", BulkDeleteWords)
+		api.DELETE("/words", DeleteWordsBySource)
+		api.POST("/words/import-url", ImportWordsFromURL)
 		api.PUT("/words/:id", UpdateWord)
 		api.DELETE("/words/:id", DeleteWord)
+		api.GET("/word_of_the_day", GetWordOfTheDay)
+		api.GET("/words/duplicates", ListDuplicateWords)
+		api.GET("/words/search", SearchWords)
+		api.GET("/words/facets", GetWordFacets)
+		api.POST("/words/:id/merge", MergeWords)
+		api.GET("/words/:id/schedule", GetWordSchedule)
+		api.POST("/words/:id/check", CheckWordAnswer)
+		api.GET("/words/:id/meanings", ListWordMeanings)
+		api.GET("/words/:id/related", GetRelatedWords)
+		api.GET("/words/:id/examples", GetWordExamples)
+		api.POST("/words/:id/examples", AddWordExample)
+		api.POST("/words/:id/related", CreateWordRelation)
+		api.DELETE("/words/:id/related/:related_id", DeleteWordRelation)
+		api.POST("/words/:id/meanings", AddWordMeaning)
+		api.PUT("/words/:id/meanings/:meaning_id", UpdateWordMeaning)
+		api.DELETE("/words/:id/meanings/:meaning_id", DeleteWordMeaning)
+		api.POST("/words/:id/image", UploadWordImage)
+		api.DELETE("/words/:id/image", DeleteWordImage)
+		api.POST("/words/:id/audio", UploadWordAudio)
+		api.DELETE("/words/:id/audio", DeleteWordAudio)
+		api.POST("/words/:id/audio/generate", GenerateWordAudio)
+		api.GET("/words/:id/tags", GetWordTags)
+		api.GET("/words/:id/groups", GetWordGroups)
+		api.GET("/words/:id/history", GetWordHistory)
+		api.POST("/words/:id/tags", AddWordTag)
+		api.DELETE("/words/:id/tags/:tag", RemoveWordTag)
 
 		// Groups endpoints
 		api.GET("/groups", ListGroups)
+		api.HEAD("/groups", HeadGroups)
 		api.GET("/groups/:id", GetGroup)
+		api.GET("/groups/:id/stats", GetGroupStats)
+		api.GET("/groups/compare", CompareGroups)
+		api.GET("/groups/stale", GetStaleGroups)
 		api.POST("/groups", CreateGroup)
+		api.POST("/groups/import", ImportGroup)
 		api.PUT("/groups/:id", UpdateGroup)
+		api.POST("/groups/:id/archive", ArchiveGroup)
+		api.POST("/groups/:id/unarchive", UnarchiveGroup)
 		api.DELETE("/groups/:id", DeleteGroup)
 		api.GET("/groups/:id/words", GetGroupWords)
+		api.POST("/groups/:id/words/bulk", BulkAddWordsToGroup)
+		api.POST("/groups/:id/words/move", MoveGroupWords)
+		api.GET("/groups/:id/export", ExportGroup)
 		api.GET("/groups/:id/study_sessions", GetGroupStudySessions)
+		api.POST("/groups/:id/share", CreateShareLink)
+		api.DELETE("/groups/:id/share/:token", RevokeShareLink)
+
+		// Public share link endpoint, rate limited on its own since it needs no authentication
+		api.GET("/shared/:token", middleware.RateLimit(cfg.SharedRateLimit, time.Minute), GetSharedGroup)
 
 		// Study Sessions endpoints
 		api.POST("/study_sessions", CreateStudySession)
 		api.GET("/study_sessions", ListStudySessions)
+		api.HEAD("/study_sessions", HeadStudySessions)
+		api.GET("/study_sessions/current", GetCurrentStudySession)
 		api.GET("/study_sessions/:id", GetStudySession)
 		api.GET("/study_sessions/:id/words", GetStudySessionWords)
 		api.PUT("/study_sessions/:id", UpdateStudySession)
 		api.DELETE("/study_sessions/:id", DeleteStudySession)
+		api.POST("/study_sessions/:id/end", EndStudySession)
+		api.GET("/study_sessions/:id/retry_queue", GetRetryQueue)
+		api.POST("/study_sessions/:id/duplicate", DuplicateStudySession)
+		api.GET("/study_sessions/:id/queue", GetStudySessionQueue)
+		api.GET("/study_sessions/:id/next", GetNextWord)
+		api.GET("/study_sessions/:id/quiz", GetQuiz)
+		api.GET("/study_sessions/:id/ws", StudySessionSocket)
+		api.GET("/study_sessions/:id/report", GetSessionReport)
 
 		// Reset endpoints
 		api.POST("/reset_history", ResetHistory)
@@ -74,13 +160,25 @@ func RegisterRoutes(router *gin.Engine, serviceInstance *service.Service) {
 
 		// Word review endpoint
 		api.POST("/study_sessions/:id/words/:word_id/review", ReviewWord)
+		api.DELETE("/study_sessions/:id/reviews/last", UndoLastReview)
+
+		// Reviews endpoints
+		api.GET("/reviews", ListReviews)
+
+		// Activity timeline endpoint
+		api.GET("/events", ListEvents)
+		api.GET("/mistakes", ListMistakes)
+		api.POST("/mistakes/create-session", CreateMistakesSession)
+		api.GET("/achievements", ListAchievements)
+		api.GET("/settings/reminders", GetReminderSettings)
+		api.PUT("/settings/reminders", UpdateReminderSettings)
 	}
 }
 
 // Dashboard Handlers
 func GetLastStudySession(c *gin.Context) {
 	log.Println("[DEBUG] Handling GET /api/dashboard/last-study-session")
-	data, err := svc.GetDashboardLastStudySession()
+	data, err := userService(c).GetDashboardLastStudySession()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch last study session"})
 		return
@@ -90,7 +188,11 @@ func GetLastStudySession(c *gin.Context) {
 
 func GetStudyProgress(c *gin.Context) {
 	log.Println("[DEBUG] Handling GET /api/dashboard/study-progress")
-	data, err := svc.GetDashboardStudyProgress()
+	weeks, ok := parseWeeks(c)
+	if !ok {
+		return
+	}
+	data, err := userService(c).GetDashboardStudyProgress(time.Now(), weeks)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch study progress"})
 		return
@@ -98,9 +200,25 @@ func GetStudyProgress(c *gin.Context) {
 	c.JSON(http.StatusOK, data)
 }
 
+// defaultRecentDays and maxRecentDays bound the recent_days parameter of the quick stats, the
+// window of their recent accuracy.
+const (
+	defaultRecentDays = 7
+	maxRecentDays     = 365
+)
+
 func GetQuickStats(c *gin.Context) {
 	log.Println("[DEBUG] Handling GET /api/dashboard/quick-stats")
-	data, err := svc.GetDashboardQuickStats()
+	recentDays := defaultRecentDays
+	if v := c.Query("recent_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRecentDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("recent_days must be an integer between 1 and %d", maxRecentDays)})
+			return
+		}
+		recentDays = n
+	}
+	data, err := userService(c).GetDashboardQuickStats(time.Now(), recentDays)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quick stats"})
 		return
@@ -108,6 +226,199 @@ func GetQuickStats(c *gin.Context) {
 	c.JSON(http.StatusOK, data)
 }
 
+// defaultRetentionMinSamples is the smallest bucket size the retention curve trusts by default.
+const defaultRetentionMinSamples = 20
+
+// GetRetention handles GET /api/dashboard/retention
+func GetRetention(c *gin.Context) {
+	minSamples := defaultRetentionMinSamples
+	if v := c.Query("min_samples"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_samples must be a non-negative integer"})
+			return
+		}
+		minSamples = n
+	}
+	buckets, err := userService(c).GetDashboardRetention(minSamples)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch retention"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"min_samples": minSamples, "buckets": buckets})
+}
+
+// defaultRecommendationLimit is how many groups the recommendation returns without a limit parameter.
+const defaultRecommendationLimit = 3
+
+// GetRecommendation handles GET /api/dashboard/recommendation
+func GetRecommendation(c *gin.Context) {
+	limit := defaultRecommendationLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+	includeArchived, ok := parseIncludeArchived(c)
+	if !ok {
+		return
+	}
+	recommendations, err := userService(c).GetRecommendations(time.Now(), limit, includeArchived)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute recommendation"})
+		return
+	}
+	c.JSON(http.StatusOK, recommendations)
+}
+
+// defaultWeeks and maxWeeks bound the weeks parameter of the weekly dashboard series.
+const (
+	defaultWeeks = 8
+	maxWeeks     = 52
+)
+
+// parseWeeks reads the weeks parameter of a weekly dashboard series, answering 400 if it is invalid.
+func parseWeeks(c *gin.Context) (int, bool) {
+	v := c.Query("weeks")
+	if v == "" {
+		return defaultWeeks, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxWeeks {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("weeks must be an integer between 1 and %d", maxWeeks)})
+		return 0, false
+	}
+	return n, true
+}
+
+// GetVelocity handles GET /api/dashboard/velocity
+func GetVelocity(c *gin.Context) {
+	weeks, ok := parseWeeks(c)
+	if !ok {
+		return
+	}
+	velocity, err := userService(c).GetDashboardVelocity(time.Now(), weeks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch velocity"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"mastery_correct_reviews": service.MasteryCorrectReviews,
+		"weeks":                   velocity,
+	})
+}
+
+// defaultUpcomingDays and maxUpcomingDays bound the days parameter of the upcoming reviews forecast.
+const (
+	defaultUpcomingDays = 7
+	maxUpcomingDays     = 90
+)
+
+// GetUpcomingReviews handles GET /api/dashboard/upcoming
+func GetUpcomingReviews(c *gin.Context) {
+	days := defaultUpcomingDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxUpcomingDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be an integer between 1 and %d", maxUpcomingDays)})
+			return
+		}
+		days = n
+	}
+	upcoming, err := userService(c).GetUpcomingReviews(time.Now(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch upcoming reviews"})
+		return
+	}
+	c.JSON(http.StatusOK, upcoming)
+}
+
+// GetJLPTBreakdown handles GET /api/dashboard/jlpt_breakdown
+func GetJLPTBreakdown(c *gin.Context) {
+	breakdown, err := userService(c).GetJLPTBreakdown()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch JLPT breakdown"})
+		return
+	}
+	c.JSON(http.StatusOK, breakdown)
+}
+
+// GetGroupSetStats handles POST /api/dashboard/groups_stats, which combines the stats of the groups
+// in {"group_ids": [...]}. Unknown IDs are reported as missing.
+func GetGroupSetStats(c *gin.Context) {
+	var req struct {
+		GroupIDs []int `json:"group_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if len(req.GroupIDs) == 0 || len(req.GroupIDs) > maxBatchIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Between 1 and %d group ids are required", maxBatchIDs)})
+		return
+	}
+	ids := make([]int, 0, len(req.GroupIDs))
+	seen := make(map[int]bool)
+	for _, id := range req.GroupIDs {
+		if id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "group_ids must be positive group IDs"})
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	stats, err := userService(c).GetGroupSetStats(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group stats"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetDigest handles GET /api/digest?date=YYYY-MM-DD. The date is a day of the configured timezone
+// and defaults to yesterday.
+func GetDigest(c *gin.Context) {
+	date := time.Now().In(svc.Location()).AddDate(0, 0, -1)
+	if v := c.Query("date"); v != "" {
+		d, err := time.ParseInLocation("2006-01-02", v, svc.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be a YYYY-MM-DD date"})
+			return
+		}
+		date = d
+	}
+	digest, err := userService(c).GetDigest(date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build digest"})
+		return
+	}
+	c.JSON(http.StatusOK, digest)
+}
+
+// GetActivityStats handles GET /api/dashboard/activity-stats
+func GetActivityStats(c *gin.Context) {
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	stats, total, err := userService(c).GetActivityStats(page, perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity stats"})
+		return
+	}
+	c.Header(totalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, gin.H{
+		"items":      stats,
+		"pagination": models.NewPagination(page, perPage, total),
+	})
+}
+
 // Study Activities Handlers
 func GetStudyActivity(c *gin.Context) {
 	idStr := c.Param("id")
@@ -131,7 +442,7 @@ func GetStudyActivitySessions(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study activity ID"})
 		return
 	}
-	session, err := svc.GetStudyActivitySessions(id)
+	session, err := userService(c).GetStudyActivitySessions(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch study activity sessions"})
 		return
@@ -139,16 +450,65 @@ func GetStudyActivitySessions(c *gin.Context) {
 	c.JSON(http.StatusOK, session)
 }
 
+// ListStudyActivitySessions handles GET /api/study_activities/:id/sessions
+func ListStudyActivitySessions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study activity ID"})
+		return
+	}
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := svc.GetStudyActivity(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study activity not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch study activity"})
+		}
+		return
+	}
+	sessions, total, err := userService(c).ListStudyActivitySessions(id, page, perPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch study activity sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items":      sessions,
+		"pagination": models.NewPagination(page, perPage, total),
+	})
+}
+
 func CreateStudyActivity(c *gin.Context) {
 	var req struct {
-		StudySessionID int `json:"study_session_id"`
-		GroupID        int `json:"group_id"`
+		Name           string `json:"name"`
+		Type           string `json:"type"`
+		StudySessionID int    `json:"study_session_id"`
+		GroupID        int    `json:"group_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
-	id, err := svc.CreateStudyActivity(req.StudySessionID, req.GroupID)
+	errs := fieldErrors{}
+	errs.text("name", &req.Name, maxNameLength)
+	errs.require("name", req.Name)
+	// Activities created without a type are of type other
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	if req.Type == "" {
+		req.Type = service.StudyActivityOther
+	} else if !isOneOf(req.Type, service.StudyActivityTypes) {
+		errs.add("type", "must be one of "+strings.Join(service.StudyActivityTypes, ", "))
+	}
+	errs.requirePositive("study_session_id", req.StudySessionID)
+	errs.requirePositive("group_id", req.GroupID)
+	if errs.respond(c) {
+		return
+	}
+	id, err := svc.CreateStudyActivity(req.Name, req.Type, req.StudySessionID, req.GroupID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create study activity"})
 		return
@@ -158,14 +518,305 @@ func CreateStudyActivity(c *gin.Context) {
 
 // Words Handlers
 func ListWords(c *gin.Context) {
-	words, err := svc.GetWords()
+	// updated_since returns a sync delta, including soft-deleted words
+	if v := c.Query("updated_since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "updated_since must be an RFC3339 timestamp"})
+			return
+		}
+		words, err := svc.GetWordsUpdatedSince(since)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch words"})
+			return
+		}
+		c.Header(totalCountHeader, strconv.Itoa(len(words)))
+		c.JSON(http.StatusOK, words)
+		return
+	}
+	if tag := c.Query("tag"); tag != "" {
+		words, err := svc.GetWordsByTag(tag)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch words"})
+			return
+		}
+		c.Header(totalCountHeader, strconv.Itoa(len(words)))
+		c.JSON(http.StatusOK, words)
+		return
+	}
+	sort, ok := parseWordSort(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseWordFilter(c)
+	if !ok {
+		return
+	}
+	// cursor selects cursor pagination in any order, the recommended way to scan large word lists
+	if cursor, limit, ok, err := parseCursor(c); ok {
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if c.Query("after_id") != "" || c.Query("page") != "" || c.Query("per_page") != "" {
+			errs := fieldErrors{"cursor": "cannot be combined with after_id, page or per_page"}
+			errs.respond(c)
+			return
+		}
+		words, next, err := userService(c).GetWordsByCursor(cursor, limit, sort, filter)
+		respondCursorPage(c, words, next, err, "Failed to fetch words")
+		return
+	}
+	// after_id/limit selects the older cursor pagination by ID
+	if c.Query("after_id") != "" || c.Query("limit") != "" {
+		if sort != nil {
+			errs := fieldErrors{"sort_by": "cannot be combined with after_id or limit"}
+			errs.respond(c)
+			return
+		}
+		listWordsAfter(c, filter)
+		return
+	}
+	if c.Query("page") != "" || c.Query("per_page") != "" {
+		listWordsPage(c, sort, filter)
+		return
+	}
+	words, err := userService(c).GetWords(sort, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch words"})
 		return
 	}
+	if !setTotalCount(c, func() (int, error) { return userService(c).CountWords(filter) }) {
+		return
+	}
 	c.JSON(http.StatusOK, words)
 }
 
+// parseWordSort reads the sort_by and order query parameters, responding with a validation error
+// and returning false when they are invalid.
+func parseWordSort(c *gin.Context) (service.WordSort, bool) {
+	sort, err := service.ParseWordSort(c.Query("sort_by"), c.Query("order"))
+	if err != nil {
+		var sortErr *service.SortError
+		if errors.As(err, &sortErr) {
+			errs := fieldErrors{sortErr.Param: sortErr.Message}
+			errs.respond(c)
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	return sort, true
+}
+
+// parseWordFilter reads the max_accuracy, min_reviews, source and script query parameters and the
+// parts filters (pos, verb_class, transitivity, adjective_type and usage), responding with a validation
+// error and returning false when they are invalid.
+func parseWordFilter(c *gin.Context) (service.WordFilter, bool) {
+	filter := service.WordFilter{
+		Source: strings.TrimSpace(c.Query("source")),
+		Usage:  strings.ToLower(strings.TrimSpace(c.Query("usage"))),
+	}
+	errs := fieldErrors{}
+	for _, p := range []struct {
+		param   string
+		allowed []string
+		value   *string
+	}{
+		{"pos", service.PartsOfSpeech, &filter.PartOfSpeech},
+		{"verb_class", service.VerbClasses, &filter.VerbClass},
+		{"transitivity", service.Transitivities, &filter.Transitivity},
+		{"adjective_type", service.AdjectiveTypes, &filter.AdjectiveType},
+		{"script", service.Scripts, &filter.Script},
+	} {
+		v := strings.ToLower(strings.TrimSpace(c.Query(p.param)))
+		if v == "" {
+			continue
+		}
+		if !isOneOf(v, p.allowed) {
+			errs.add(p.param, "must be one of "+strings.Join(p.allowed, ", "))
+		}
+		*p.value = v
+	}
+	if v := c.Query("max_accuracy"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err != nil || f < 0 || f > 1 {
+			errs.add("max_accuracy", "must be a number between 0 and 1")
+		} else {
+			filter.MaxAccuracy = &f
+		}
+	}
+	if v := c.Query("min_reviews"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n < 0 {
+			errs.add("min_reviews", "must be a non-negative integer")
+		} else {
+			filter.MinReviews = n
+		}
+	}
+	if errs.respond(c) {
+		return filter, false
+	}
+	return filter, true
+}
+
+// listWordsPage answers GET /api/words with offset pagination (page and per_page).
+func listWordsPage(c *gin.Context, sort service.WordSort, filter service.WordFilter) {
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	words, err := userService(c).GetWordsPage(page, perPage, sort, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch words"})
+		return
+	}
+	total, err := userService(c).CountWords(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count words"})
+		return
+	}
+	c.Header(totalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, gin.H{
+		"items":      words,
+		"pagination": models.NewPagination(page, perPage, total),
+	})
+}
+
+// listWordsAfter answers GET /api/words with cursor pagination (after_id and limit).
+// next_cursor is the after_id of the following page, or null on the last page.
+func listWordsAfter(c *gin.Context, filter service.WordFilter) {
+	afterID := 0
+	if v := c.Query("after_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "after_id must be a non-negative integer"})
+			return
+		}
+		afterID = id
+	}
+	limit, err := parseLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	words, next, err := userService(c).GetWordsAfter(afterID, limit, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch words"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items":       words,
+		"next_cursor": next,
+	})
+}
+
+// HeadWords handles HEAD /api/words
+func HeadWords(c *gin.Context) {
+	filter, ok := parseWordFilter(c)
+	if !ok {
+		return
+	}
+	count := func() (int, error) { return userService(c).CountWords(filter) }
+	if tag := c.Query("tag"); tag != "" {
+		count = func() (int, error) { return svc.CountWordsByTag(tag) }
+	}
+	if setTotalCount(c, count) {
+		c.Status(http.StatusOK)
+	}
+}
+
+// GetWordTags handles GET /api/words/:id/tags
+func GetWordTags(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	tags, err := svc.GetWordTags(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word tags"})
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// GetWordGroups handles GET /api/words/:id/groups
+func GetWordGroups(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	groups, err := svc.GetWordGroups(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word groups"})
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// AddWordTag handles POST /api/words/:id/tags
+func AddWordTag(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	var req struct {
+		Tag string `json:"tag"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	errs := fieldErrors{}
+	errs.text("tag", &req.Tag, maxNameLength)
+	errs.require("tag", req.Tag)
+	if errs.respond(c) {
+		return
+	}
+	if err := svc.AddWordTag(id, req.Tag); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add tag"})
+		}
+		return
+	}
+	tags, err := svc.GetWordTags(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word tags"})
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// RemoveWordTag handles DELETE /api/words/:id/tags/:tag
+func RemoveWordTag(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	if err := svc.RemoveWordTag(id, c.Param("tag")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found on word"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove tag"})
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 func GetWord(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -182,17 +833,152 @@ func GetWord(c *gin.Context) {
 		}
 		return
 	}
+	related, err := svc.GetRelatedWords(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch related words"})
+		return
+	}
+	detail := models.WordDetail{Word: *word, Related: *related}
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		if strings.TrimSpace(include) != "examples" {
+			continue
+		}
+		examples, err := svc.GetWordExamples(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word examples"})
+			return
+		}
+		detail.Examples = &examples
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// GetWordOfTheDay handles GET /api/word_of_the_day
+func GetWordOfTheDay(c *gin.Context) {
+	word, err := userService(c).GetWordOfTheDay(time.Now())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No words available for a word of the day"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word of the day"})
+		}
+		return
+	}
 	c.JSON(http.StatusOK, word)
 }
 
+// maxBatchIDs caps how many ids a single batch lookup may request.
+const maxBatchIDs = 500
+
+// GetWordsBatch handles POST /api/words/batch
+func GetWordsBatch(c *gin.Context) {
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if len(req.IDs) > maxBatchIDs {
+		errs := fieldErrors{"ids": fmt.Sprintf("must contain at most %d ids", maxBatchIDs)}
+		errs.respond(c)
+		return
+	}
+	words, err := svc.GetWordsByIDs(req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch words"})
+		return
+	}
+	c.JSON(http.StatusOK, words)
+}
+
+// BulkDeleteWords handles POST /api/words/bulk-delete (also /api/words/bulk_delete). It answers
+// with the IDs deleted, skipped and failed rather than failing as a whole on one bad ID.
+func BulkDeleteWords(c *gin.Context) {
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	errs := fieldErrors{}
+	switch {
+	case len(req.IDs) == 0:
+		errs.add("ids", "is required")
+	case len(req.IDs) > maxBatchIDs:
+		errs.add("ids", fmt.Sprintf("must contain at most %d ids", maxBatchIDs))
+	}
+	if errs.respond(c) {
+		return
+	}
+	result, err := svc.BulkDeleteWords(req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete words"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"deleted":   len(result.Succeeded),
+		"succeeded": result.Succeeded,
+		"skipped":   result.Skipped,
+		"failed":    result.Failed,
+	})
+}
+
+// DeleteWordsBySource handles DELETE /api/words?source=..., which rolls back an import by deleting
+// every word it created. The confirm query parameter must repeat the source, so that a stray
+// request cannot wipe a whole import.
+func DeleteWordsBySource(c *gin.Context) {
+	source := strings.TrimSpace(c.Query("source"))
+	errs := fieldErrors{}
+	errs.require("source", source)
+	if errs.respond(c) {
+		return
+	}
+	if c.Query("confirm") != source {
+		count, err := svc.CountWords(service.WordFilter{Source: source})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count words"})
+			return
+		}
+		errs.add("confirm", fmt.Sprintf("must equal the source to delete its %d words", count))
+		errs.respond(c)
+		return
+	}
+	deleted, err := svc.DeleteWordsBySource(source)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete words"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
+
 // Groups Handlers
 func ListGroups(c *gin.Context) {
-	groups, err := svc.ListGroups()
+	includeArchived, ok := parseIncludeArchived(c)
+	if !ok {
+		return
+	}
+	groups, err := svc.ListGroups(includeArchived)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list groups"})
 		return
 	}
-	c.JSON(http.StatusOK, groups)
+	if !setTotalCount(c, func() (int, error) { return svc.CountGroups(includeArchived) }) {
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// HeadGroups handles HEAD /api/groups
+func HeadGroups(c *gin.Context) {
+	includeArchived, ok := parseIncludeArchived(c)
+	if !ok {
+		return
+	}
+	if setTotalCount(c, func() (int, error) { return svc.CountGroups(includeArchived) }) {
+		c.Status(http.StatusOK)
+	}
 }
 
 func GetGroup(c *gin.Context) {
@@ -221,6 +1007,16 @@ func GetGroupWords(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
 		return
 	}
+	// Review stats are opt-in because aggregating them costs an extra scan of word_review_items
+	if c.Query("with_stats") == "true" {
+		words, err := userService(c).GetGroupWordsWithStats(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group words"})
+			return
+		}
+		c.JSON(http.StatusOK, words)
+		return
+	}
 	words, err := svc.GetGroupWords(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group words"})
@@ -236,7 +1032,7 @@ func GetGroupStudySessions(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
 		return
 	}
-	sessions, err := svc.GetGroupStudySessions(id)
+	sessions, err := userService(c).GetGroupStudySessions(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group study sessions"})
 		return
@@ -245,15 +1041,64 @@ func GetGroupStudySessions(c *gin.Context) {
 }
 
 // Study Sessions Handlers
+
+// ListStudySessions handles GET /api/study_sessions. activity_type keeps the sessions whose study
+// activity is of that type. Abandoned sessions are left out unless include_abandoned is true.
 func ListStudySessions(c *gin.Context) {
-	sessions, err := svc.ListStudySessions()
+	filter, ok := parseStudySessionFilter(c)
+	if !ok {
+		return
+	}
+	if cursor, limit, ok, err := parseCursor(c); ok {
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sessions, next, err := userService(c).ListStudySessionsByCursor(filter, cursor, limit)
+		respondCursorPage(c, sessions, next, err, "Failed to list study sessions")
+		return
+	}
+	sessions, err := userService(c).ListStudySessions(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list study sessions"})
 		return
 	}
+	if !setTotalCount(c, func() (int, error) { return userService(c).CountStudySessions(filter) }) {
+		return
+	}
 	c.JSON(http.StatusOK, sessions)
 }
 
+// HeadStudySessions handles HEAD /api/study_sessions, accepting the filters of ListStudySessions.
+func HeadStudySessions(c *gin.Context) {
+	filter, ok := parseStudySessionFilter(c)
+	if !ok {
+		return
+	}
+	if setTotalCount(c, func() (int, error) { return userService(c).CountStudySessions(filter) }) {
+		c.Status(http.StatusOK)
+	}
+}
+
+// parseStudySessionFilter reads the activity_type and include_abandoned query parameters of the
+// study sessions listing, responding with a validation error and returning false when one is
+// invalid. activity_type must be one of service.StudyActivityTypes.
+func parseStudySessionFilter(c *gin.Context) (models.StudySessionFilter, bool) {
+	var filter models.StudySessionFilter
+	errs := fieldErrors{}
+	filter.ActivityType = strings.ToLower(strings.TrimSpace(c.Query("activity_type")))
+	if filter.ActivityType != "" && !isOneOf(filter.ActivityType, service.StudyActivityTypes) {
+		errs.add("activity_type", "must be one of "+strings.Join(service.StudyActivityTypes, ", "))
+	}
+	if v := c.Query("include_abandoned"); v != "" {
+		var err error
+		if filter.IncludeAbandoned, err = strconv.ParseBool(v); err != nil {
+			errs.add("include_abandoned", "must be true or false")
+		}
+	}
+	return filter, !errs.respond(c)
+}
+
 func GetStudySession(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -261,7 +1106,7 @@ func GetStudySession(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
 		return
 	}
-	session, err := svc.GetStudySessionByID(id)
+	session, err := userService(c).GetStudySessionByID(id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
@@ -280,7 +1125,7 @@ func GetStudySessionWords(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
 		return
 	}
-	words, err := svc.GetStudySessionWords(id)
+	words, err := userService(c).GetStudySessionWords(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch study session words"})
 		return
@@ -290,7 +1135,7 @@ func GetStudySessionWords(c *gin.Context) {
 
 // Reset Handlers
 func ResetHistory(c *gin.Context) {
-	err := svc.ResetHistory()
+	err := userService(c).ResetHistory()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset history"})
 		return
@@ -307,6 +1152,58 @@ func FullReset(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Full reset performed successfully"})
 }
 
+// OptimizeDB handles POST /api/admin/optimize
+func OptimizeDB(c *gin.Context) {
+	result, err := svc.OptimizeDB()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to optimize database"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CleanupSessions handles POST /api/admin/cleanup-sessions, which runs the stale session cleanup
+// with the configured age and action at once. dry_run=true lists the sessions without changing them.
+func CleanupSessions(c *gin.Context) {
+	dryRun := false
+	if v := c.Query("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			errs := fieldErrors{"dry_run": "must be true or false"}
+			errs.respond(c)
+			return
+		}
+	}
+	result, err := svc.CleanupStaleSessions(time.Now().Add(-cfg.SessionAbandonAfter), cfg.SessionCleanupDelete, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up study sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetQueryTimings handles GET /api/admin/query_timings
+func GetQueryTimings(c *gin.Context) {
+	c.JSON(http.StatusOK, svc.GetQueryTimings())
+}
+
+// LoadSeedDataset handles POST /api/admin/seed. The dataset parameter names the dataset to add,
+// DefaultSeedDataset when omitted.
+func LoadSeedDataset(c *gin.Context) {
+	dataset := c.DefaultQuery("dataset", service.DefaultSeedDataset)
+	result, err := svc.LoadSeedDataset(dataset)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownDataset) {
+			names, _ := svc.SeedDatasetNames()
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown seed dataset", "datasets": names})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load seed dataset"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // Word Review Handler
 func ReviewWord(c *gin.Context) {
 	studySessionIDStr := c.Param("id")
@@ -323,17 +1220,65 @@ func ReviewWord(c *gin.Context) {
 	}
 	var req struct {
 		Correct bool `json:"correct"`
+		// Skipped records that the word was passed on, which does not count as wrong
+		Skipped bool `json:"skipped"`
+		// Answer is the answer given, kept for the mistakes log
+		Answer string `json:"answer"`
+		// Attempt defaults to 1; resubmitting an attempt updates it, a higher attempt records a re-ask
+		Attempt int `json:"attempt"`
+		// Direction is the direction the word was asked in, as tagged by the quiz; reviews
+		// without one count only toward the overall accuracy
+		Direction string `json:"direction"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
-	err = svc.ReviewWord(studySessionID, wordID, req.Correct)
+	if req.Attempt == 0 {
+		req.Attempt = 1
+	}
+	errs := fieldErrors{}
+	errs.requirePositive("attempt", req.Attempt)
+	errs.text("answer", &req.Answer, maxWordTextLength)
+	if req.Direction != "" && !isOneOf(req.Direction, service.ReviewDirections) {
+		errs.add("direction", "must be one of "+strings.Join(service.ReviewDirections, ", "))
+	}
+	if req.Correct && req.Skipped {
+		errs.add("skipped", "cannot be set with correct")
+	}
+	if errs.respond(c) {
+		return
+	}
+	outcome, err := userService(c).ReviewWord(studySessionID, wordID, req.Correct, req.Skipped, req.Attempt, req.Answer, req.Direction)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record review"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Review recorded successfully"})
+	result := "updated"
+	if outcome.Created {
+		result = "created"
+	}
+	response := gin.H{
+		"message":          "Review recorded successfully",
+		"result":           result,
+		"word_id":          wordID,
+		"study_session_id": studySessionID,
+		"correct":          req.Correct,
+		"skipped":          req.Skipped,
+		"attempt":          req.Attempt,
+		"interval_days":    nil,
+		"next_review_at":   nil,
+	}
+	// A word whose reviews were all skipped has no schedule
+	if outcome.Schedule != nil {
+		response["interval_days"] = outcome.Schedule.IntervalDays
+		response["next_review_at"] = outcome.Schedule.NextReviewAt
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateGroup handles POST /api/groups
@@ -345,12 +1290,75 @@ func CreateGroup(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
-	id, err := svc.CreateGroup(req.Name)
+	errs := fieldErrors{}
+	errs.text("name", &req.Name, maxNameLength)
+	errs.require("name", req.Name)
+	if errs.respond(c) {
+		return
+	}
+	id, err := svc.CreateGroup(req.Name, currentUsername(c))
+	if err != nil {
+		if errors.Is(err, service.ErrDuplicateGroupName) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		}
+		return
+	}
+	group, err := svc.GetGroupByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch created group"})
+		return
+	}
+	c.JSON(http.StatusCreated, group)
+}
+
+// ImportGroup handles POST /api/groups/import. A JSON body creates the group with its words, a
+// multipart upload adds a CSV word list to a new or existing group (see importGroupCSV). With
+// dry_run=true it reports what the import would do without saving anything.
+func ImportGroup(c *gin.Context) {
+	if c.ContentType() == "multipart/form-data" {
+		importGroupCSV(c)
+		return
+	}
+	var req struct {
+		Name  string              `json:"name"`
+		Words []models.ImportWord `json:"words"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+	errs := fieldErrors{}
+	errs.text("name", &req.Name, maxNameLength)
+	errs.require("name", req.Name)
+	for i := range req.Words {
+		w := &req.Words[i]
+		prefix := fmt.Sprintf("words[%d].", i)
+		cleanWord(errs, prefix, w)
+		errs.require(prefix+"japanese", w.Japanese)
+		errs.require(prefix+"romaji", w.Romaji)
+		errs.require(prefix+"english", w.English)
+		validateJLPTLevel(errs, prefix+"jlpt_level", w.JLPTLevel)
+		errs.maxLength(prefix+"mnemonic", w.Mnemonic, maxMnemonicLength)
+	}
+	if errs.respond(c) {
+		return
+	}
+	result, err := svc.ImportGroup(req.Name, currentUsername(c), req.Words, "json:"+req.Name, dryRun)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		if errors.Is(err, service.ErrDuplicateGroupName) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import group"})
+		}
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"id": id, "name": req.Name})
+	c.JSON(importStatus(dryRun), result)
 }
 
 // UpdateGroup handles PUT /api/groups/:id
@@ -362,15 +1370,39 @@ func UpdateGroup(c *gin.Context) {
 		return
 	}
 	var req struct {
-		Name string `json:"name"`
+		Name                *string `json:"name"`
+		DefaultActivityType *string `json:"default_activity_type"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
-	err = svc.UpdateGroup(id, req.Name)
+	// Sending only default_activity_type leaves the name unchanged, and "" removes the default
+	var name string
+	errs := fieldErrors{}
+	if req.Name != nil || req.DefaultActivityType == nil {
+		if req.Name != nil {
+			name = *req.Name
+		}
+		errs.text("name", &name, maxNameLength)
+		errs.require("name", name)
+	}
+	if t := req.DefaultActivityType; t != nil && *t != "" && !isOneOf(*t, service.StudyActivityTypes) {
+		errs.add("default_activity_type", "must be one of "+strings.Join(service.StudyActivityTypes, ", ")+", or empty to remove the default")
+	}
+	if errs.respond(c) {
+		return
+	}
+	err = svc.UpdateGroup(id, models.GroupUpdate{Name: name, DefaultActivityType: req.DefaultActivityType})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update group"})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		case errors.Is(err, service.ErrDuplicateGroupName):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update group"})
+		}
 		return
 	}
 	group, err := svc.GetGroupByID(id)
@@ -407,12 +1439,29 @@ func CreateStudySession(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
-	id, err := svc.CreateStudySession(req.GroupID, req.StudyActivityID)
+	// Omitting study_activity_id uses the group's default study activity
+	errs := fieldErrors{}
+	errs.requirePositive("group_id", req.GroupID)
+	if req.StudyActivityID != 0 {
+		errs.requirePositive("study_activity_id", req.StudyActivityID)
+	}
+	if errs.respond(c) {
+		return
+	}
+	id, err := userService(c).CreateStudySession(req.GroupID, req.StudyActivityID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create study session"})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		case errors.Is(err, service.ErrNoDefaultActivity):
+			errs.add("study_activity_id", "is required because the group has no default study activity")
+			errs.respond(c)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create study session"})
+		}
 		return
 	}
-	session, err := svc.GetStudySessionByID(int(id))
+	session, err := userService(c).GetStudySessionByID(int(id))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch study session"})
 		return
@@ -423,23 +1472,44 @@ func CreateStudySession(c *gin.Context) {
 // Update CreateWord handler
 func CreateWord(c *gin.Context) {
 	var req struct {
-		Japanese string      `json:"japanese"`
-		Romaji   string      `json:"romaji"`
-		English  string      `json:"english"`
-		Parts    interface{} `json:"parts"`
+		Japanese  string          `json:"japanese"`
+		Reading   string          `json:"reading"`
+		Romaji    string          `json:"romaji"`
+		English   string          `json:"english"`
+		Meanings  []string        `json:"meanings"`
+		Parts     json.RawMessage `json:"parts"`
+		JLPTLevel *int            `json:"jlpt_level"`
+		Mnemonic  string          `json:"mnemonic"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
-	partsStr := ""
-	if req.Parts != nil {
-		b, err := json.Marshal(req.Parts)
-		if err == nil {
-			partsStr = string(b)
-		}
+	errs := fieldErrors{}
+	parts, ok := parseParts(c, errs, req.Parts)
+	if !ok {
+		return
 	}
-	id, err := svc.CreateWord(req.Japanese, req.Romaji, req.English, partsStr)
+	input := models.ImportWord{
+		Japanese:  req.Japanese,
+		Reading:   req.Reading,
+		Romaji:    req.Romaji,
+		English:   req.English,
+		Meanings:  req.Meanings,
+		Parts:     parts,
+		JLPTLevel: req.JLPTLevel,
+		Mnemonic:  req.Mnemonic,
+	}
+	cleanWord(errs, "", &input)
+	errs.require("japanese", input.Japanese)
+	errs.require("romaji", input.Romaji)
+	requireMeaning(errs, input.English, input.Meanings)
+	validateJLPTLevel(errs, "jlpt_level", input.JLPTLevel)
+	errs.maxLength("mnemonic", input.Mnemonic, maxMnemonicLength)
+	if errs.respond(c) {
+		return
+	}
+	id, err := svc.CreateWord(input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create word"})
 		return
@@ -460,13 +1530,44 @@ func UpdateWord(c *gin.Context) {
 		return
 	}
 	var req struct {
-		English string `json:"english"`
+		English   string          `json:"english"`
+		Meanings  []string        `json:"meanings"`
+		JLPTLevel *int            `json:"jlpt_level"`
+		Mnemonic  *string         `json:"mnemonic"`
+		Reading   *string         `json:"reading"`
+		Parts     json.RawMessage `json:"parts"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
-	if err := svc.UpdateWord(id, req.English); err != nil {
+	errs := fieldErrors{}
+	// Leaving parts out keeps them, null clears them
+	var parts *string
+	if req.Parts != nil {
+		canonical, ok := parseParts(c, errs, req.Parts)
+		if !ok {
+			return
+		}
+		parts = &canonical
+	}
+	errs.text("english", &req.English, maxWordTextLength)
+	cleanMeanings(errs, "", req.Meanings)
+	requireMeaning(errs, req.English, req.Meanings)
+	validateJLPTLevel(errs, "jlpt_level", req.JLPTLevel)
+	if req.Mnemonic != nil {
+		errs.maxLength("mnemonic", *req.Mnemonic, maxMnemonicLength)
+	}
+	if req.Reading != nil {
+		errs.text("reading", req.Reading, maxWordTextLength)
+		checkReading(errs, "reading", *req.Reading)
+	}
+	if errs.respond(c) {
+		return
+	}
+	update := models.WordUpdate{English: req.English, Meanings: req.Meanings, JLPTLevel: req.JLPTLevel, Mnemonic: req.Mnemonic,
+		Reading: req.Reading, Parts: parts}
+	if err := svc.UpdateWord(id, update); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
 		} else {
@@ -509,13 +1610,25 @@ func UpdateStudySession(c *gin.Context) {
 		return
 	}
 	var req struct {
-		StudyActivityID int `json:"study_activity_id"`
+		StudyActivityID int     `json:"study_activity_id"`
+		Notes           *string `json:"notes"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
-	if err := svc.UpdateStudySession(id, req.StudyActivityID); err != nil {
+	// Sending only notes leaves the activity unchanged
+	errs := fieldErrors{}
+	if req.Notes == nil || req.StudyActivityID != 0 {
+		errs.requirePositive("study_activity_id", req.StudyActivityID)
+	}
+	if req.Notes != nil {
+		errs.maxLength("notes", *req.Notes, maxNotesLength)
+	}
+	if errs.respond(c) {
+		return
+	}
+	if err := userService(c).UpdateStudySession(id, models.StudySessionUpdate{StudyActivityID: req.StudyActivityID, Notes: req.Notes}); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
 		} else {
@@ -523,7 +1636,7 @@ func UpdateStudySession(c *gin.Context) {
 		}
 		return
 	}
-	session, err := svc.GetStudySessionByID(id)
+	session, err := userService(c).GetStudySessionByID(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated study session"})
 		return
@@ -538,7 +1651,7 @@ func DeleteStudySession(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
 		return
 	}
-	if err := svc.DeleteStudySession(id); err != nil {
+	if err := userService(c).DeleteStudySession(id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
 		} else {
@@ -548,3 +1661,255 @@ func DeleteStudySession(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// GetCurrentStudySession handles GET /api/study_sessions/current
+func GetCurrentStudySession(c *gin.Context) {
+	session, err := userService(c).GetCurrentStudySession(time.Now(), cfg.SessionResumeWindow)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch current study session"})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// EndStudySession handles POST /api/study_sessions/:id/end
+func EndStudySession(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
+		return
+	}
+	// The body is optional and may carry closing notes
+	var req struct {
+		Notes *string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if req.Notes != nil {
+		errs := fieldErrors{}
+		errs.maxLength("notes", *req.Notes, maxNotesLength)
+		if errs.respond(c) {
+			return
+		}
+	}
+	session, err := userService(c).EndStudySession(id, req.Notes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end study session"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// DuplicateStudySession handles POST /api/study_sessions/:id/duplicate
+func DuplicateStudySession(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
+		return
+	}
+	// The body is optional; queue_words queues the original session's words in the new one
+	var req struct {
+		QueueWords bool `json:"queue_words"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	newID, queued, err := userService(c).DuplicateStudySession(id, req.QueueWords)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to duplicate study session"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"id":              newID,
+		"duplicated_from": id,
+		"queued_words":    queued,
+	})
+}
+
+// GetStudySessionQueue handles GET /api/study_sessions/:id/queue
+func GetStudySessionQueue(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
+		return
+	}
+	words, err := userService(c).GetStudySessionQueue(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch study session queue"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, words)
+}
+
+// GetNextWord handles GET /api/study_sessions/:id/next, answering 204 when the session is complete.
+func GetNextWord(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
+		return
+	}
+	next, err := userService(c).GetNextWord(id, time.Now())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch next word"})
+		}
+		return
+	}
+	if next == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, next)
+}
+
+// GetSessionReport handles GET /api/study_sessions/:id/report
+func GetSessionReport(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
+		return
+	}
+	report, err := userService(c).GetSessionReport(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build study session report"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Defaults and bounds of the quiz parameters: count is the number of words, mix the share of new,
+// weak and strong words and cap the times a word may be asked in a session.
+const (
+	defaultQuizCount   = 10
+	maxQuizCount       = 100
+	defaultQuizWordCap = 2
+)
+
+var defaultQuizMix = service.QuizMix{2, 2, 1}
+
+// parseQuizMix parses a mix such as "2:2:1" of new, weak and strong words.
+func parseQuizMix(v string) (service.QuizMix, bool) {
+	var mix service.QuizMix
+	parts := strings.Split(v, ":")
+	if len(parts) != len(mix) {
+		return mix, false
+	}
+	total := 0
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 0 {
+			return mix, false
+		}
+		mix[i] = n
+		total += n
+	}
+	return mix, total > 0
+}
+
+// GetQuiz handles GET /api/study_sessions/:id/quiz?count=10&mix=2:2:1&cap=2. The optional seed
+// parameter makes the selection repeatable. direction is jp_to_en (default), en_to_jp or mixed, and
+// tags each word with the direction its review should be sent with.
+func GetQuiz(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
+		return
+	}
+	count, wordCap, mix, seed := defaultQuizCount, defaultQuizWordCap, defaultQuizMix, time.Now().UnixNano()
+	errs := fieldErrors{}
+	direction := c.DefaultQuery("direction", service.DirectionJapaneseToEnglish)
+	if !isOneOf(direction, service.QuizDirections) {
+		errs.add("direction", "must be one of "+strings.Join(service.QuizDirections, ", "))
+	}
+	if v := c.Query("count"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n < 1 || n > maxQuizCount {
+			errs.add("count", fmt.Sprintf("must be an integer between 1 and %d", maxQuizCount))
+		} else {
+			count = n
+		}
+	}
+	if v := c.Query("cap"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n < 1 {
+			errs.add("cap", "must be a positive integer")
+		} else {
+			wordCap = n
+		}
+	}
+	if v := c.Query("mix"); v != "" {
+		var ok bool
+		if mix, ok = parseQuizMix(v); !ok {
+			errs.add("mix", "must be three non-negative integers such as 2:2:1 for new:weak:strong, not all zero")
+		}
+	}
+	if v := c.Query("seed"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err != nil {
+			errs.add("seed", "must be an integer")
+		} else {
+			seed = n
+		}
+	}
+	if errs.respond(c) {
+		return
+	}
+	quiz, err := userService(c).GetQuiz(id, count, mix, wordCap, seed, direction)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to select quiz words"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, quiz)
+}
+
+// GetRetryQueue handles GET /api/study_sessions/:id/retry_queue
+func GetRetryQueue(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid study session ID"})
+		return
+	}
+	queue, err := userService(c).GetRetryQueue(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch retry queue"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, queue)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"backend_go/internal/models"
+	"backend_go/internal/testutil"
+)
+
+func TestGetCurrentStudySessionWindow(t *testing.T) {
+	router, s := newTestServer(t, map[string]string{"SESSION_RESUME_HOURS": "1"})
+	f := testutil.NewFixture(t, s.DB).Group("N5").Word("水", "mizu", "water").InGroup("N5")
+
+	// Two hours old is past the one hour window
+	f.SessionAt(time.Now().Add(-2 * time.Hour))
+	if w := request(router, http.MethodGet, "/api/study_sessions/current", nil); w.Code != http.StatusNoContent {
+		t.Errorf("with a stale session: status %d, want 204", w.Code)
+	}
+
+	f.SessionAt(time.Now().Add(-30 * time.Minute))
+	w := request(router, http.MethodGet, "/api/study_sessions/current", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("with a recent session: status %d, want 200", w.Code)
+	}
+	var current models.CurrentStudySession
+	decode(t, w, &current)
+	if current.ID != f.SessionID() || current.RemainingWords != 1 {
+		t.Errorf("current = %+v, want session %d with 1 word remaining", current, f.SessionID())
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+)
+
+// fieldErrors collects semantic validation failures keyed by request field name.
+//
+// Handlers answer 400 when a body cannot be parsed at all and use fieldErrors for bodies that
+// parse but carry unacceptable values, which are reported together as a 422.
+type fieldErrors map[string]string
+
+// add records msg for field, keeping the first message reported for a field.
+func (e fieldErrors) add(field, msg string) {
+	if _, exists := e[field]; !exists {
+		e[field] = msg
+	}
+}
+
+// require records an error for field when value is empty or only whitespace.
+func (e fieldErrors) require(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		e.add(field, "is required")
+	}
+}
+
+// requireMeaning records an error for english when a word write has neither an english
+// meaning nor a non-blank entry in meanings.
+func requireMeaning(e fieldErrors, english string, meanings []string) {
+	for _, m := range meanings {
+		if strings.TrimSpace(m) != "" {
+			return
+		}
+	}
+	e.require("english", english)
+}
+
+// requirePositive records an error for field when value is not a positive ID.
+func (e fieldErrors) requirePositive(field string, value int) {
+	if value <= 0 {
+		e.add(field, "must be a positive integer")
+	}
+}
+
+// Length limits of free-text fields, in characters.
+const (
+	maxMnemonicLength = 1000
+	maxNotesLength    = 4000
+)
+
+// Length limits of single-line text fields, in characters, and of a word's parts JSON, in bytes.
+const (
+	maxNameLength     = 100
+	maxWordTextLength = 500
+	maxPartsBytes     = 4 << 10
+)
+
+// cleanText trims s and replaces every run of control characters, newlines and tabs included,
+// with a single space, so that pasted values cannot differ from their visible text.
+func cleanText(s string) string {
+	var b strings.Builder
+	inControl := false
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			if !inControl {
+				b.WriteByte(' ')
+			}
+			inControl = true
+			continue
+		}
+		inControl = false
+		b.WriteRune(r)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// text cleans the single-line value of field in place with cleanText and records an error when
+// the result has more than max characters.
+func (e fieldErrors) text(field string, value *string, max int) {
+	*value = cleanText(*value)
+	e.maxLength(field, *value, max)
+}
+
+// cleanWord cleans the text fields of a word write in place and checks their lengths, reporting
+// errors under field names starting with prefix. A missing romaji is generated from the reading, or
+// from japanese when it is kana only.
+func cleanWord(e fieldErrors, prefix string, w *models.ImportWord) {
+	e.text(prefix+"japanese", &w.Japanese, maxWordTextLength)
+	e.text(prefix+"reading", &w.Reading, maxWordTextLength)
+	checkReading(e, prefix+"reading", w.Reading)
+	e.text(prefix+"romaji", &w.Romaji, maxWordTextLength)
+	if w.Romaji == "" {
+		w.Romaji = service.GenerateRomaji(w.Japanese, w.Reading)
+	}
+	checkRomaji(e, prefix+"romaji", w.Romaji, w.Japanese)
+	e.text(prefix+"english", &w.English, maxWordTextLength)
+	cleanMeanings(e, prefix, w.Meanings)
+	e.text(prefix+"source", &w.Source, maxNameLength)
+	e.text(prefix+"source_id", &w.SourceID, maxNameLength)
+	if len(w.Parts) > maxPartsBytes {
+		e.add(prefix+"parts", fmt.Sprintf("must be at most %d bytes", maxPartsBytes))
+	}
+}
+
+// checkRomaji records an error for field when romaji has letters outside the latin script, such as
+// kana or kanji, which is how a japanese column copied over the romaji one in an import shows up.
+// Long vowel marks are latin and allowed.
+func checkRomaji(e fieldErrors, field, romaji, japanese string) {
+	for _, r := range romaji {
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			if romaji == japanese {
+				e.add(field, "is the same as japanese, it must be the reading in latin letters")
+			} else {
+				e.add(field, fmt.Sprintf("must be written in latin letters, found %q", r))
+			}
+			return
+		}
+	}
+}
+
+// checkReading records an error for field when reading has letters that are not kana.
+func checkReading(e fieldErrors, field, reading string) {
+	if r, found := service.NonKana(reading); found {
+		e.add(field, fmt.Sprintf("must be written in kana, found %q", r))
+	}
+}
+
+// cleanMeanings cleans each meaning in place and checks its length.
+func cleanMeanings(e fieldErrors, prefix string, meanings []string) {
+	for i := range meanings {
+		e.text(fmt.Sprintf("%smeanings[%d]", prefix, i), &meanings[i], maxWordTextLength)
+	}
+}
+
+// maxLength records an error for field when value has more than max characters.
+func (e fieldErrors) maxLength(field, value string, max int) {
+	if utf8.RuneCountInString(strings.TrimSpace(value)) > max {
+		e.add(field, fmt.Sprintf("must be at most %d characters", max))
+	}
+}
+
+// validateJLPTLevel records an error for field when a JLPT level is given outside 1 (N1) to 5 (N5).
+func validateJLPTLevel(e fieldErrors, field string, level *int) {
+	if level != nil && (*level < 1 || *level > 5) {
+		e.add(field, "must be between 1 and 5")
+	}
+}
+
+// respond writes a 422 listing the collected errors and returns true if there were any.
+func (e fieldErrors) respond(c *gin.Context) bool {
+	if len(e) == 0 {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Validation failed", "fields": e})
+	return true
+}
+
+// parseParts checks the parts of a word write against the parts schema and returns them in
+// canonical form. Keys the schema does not define answer 400, unless the strict query parameter is
+// false, and problems with the values are recorded in e under parts.<key>. It returns false when
+// it has already answered.
+func parseParts(c *gin.Context, e fieldErrors, raw json.RawMessage) (string, bool) {
+	strict := true
+	if v := c.Query("strict"); v != "" {
+		var err error
+		if strict, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "strict must be true or false"})
+			return "", false
+		}
+	}
+	parts, err := service.ParseParts(string(raw), !strict)
+	var perr *service.PartsError
+	if !errors.As(err, &perr) {
+		return parts, err == nil
+	}
+	if len(perr.Unknown) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parts has unknown keys: " + strings.Join(perr.Unknown, ", ")})
+		return "", false
+	}
+	for key, msg := range perr.Invalid {
+		if key == "" {
+			e.add("parts", msg)
+		} else {
+			e.add("parts."+key, msg)
+		}
+	}
+	return "", true
+}
+
+// isOneOf reports whether v is one of allowed.
+func isOneOf(v string, allowed []string) bool {
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validationFields returns the field errors of a 422 response, failing the test for another status.
+func validationFields(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d, want 422: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Fields map[string]string `json:"fields"`
+	}
+	decode(t, w, &body)
+	return body.Fields
+}
+
+func TestCleanText(t *testing.T) {
+	tests := map[string]string{
+		"  mizu  ":            "mizu",
+		"two\nlines":          "two lines",
+		"tab\t\r\n\tted":      "tab ted",
+		"\x00\x1fcontrol\x7f": "control",
+		"全角　スペース":             "全角　スペース",
+		" \t\n ":              "",
+	}
+	for in, want := range tests {
+		if got := cleanText(in); got != want {
+			t.Errorf("cleanText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGroupNameLength(t *testing.T) {
+	router, _ := newTestServer(t, nil)
+	tests := []struct {
+		name    string
+		invalid string
+	}{
+		// Lengths count characters, not bytes
+		{strings.Repeat("語", maxNameLength), ""},
+		{strings.Repeat("語", maxNameLength+1), "must be at most 100 characters"},
+		// Surrounding whitespace does not count
+		{"  " + strings.Repeat("a", maxNameLength-1) + "b\n", ""},
+		{" \t\n ", "is required"},
+		{"", "is required"},
+	}
+	for _, tt := range tests {
+		w := request(router, http.MethodPost, "/api/groups", gin.H{"name": tt.name})
+		if tt.invalid == "" {
+			if w.Code != http.StatusCreated {
+				t.Errorf("name of %d characters: status %d, want 201: %s", len([]rune(tt.name)), w.Code, w.Body)
+			}
+			continue
+		}
+		if got := validationFields(t, w)["name"]; got != tt.invalid {
+			t.Errorf("name %q: error %q, want %q", tt.name, got, tt.invalid)
+		}
+	}
+
+	w := request(router, http.MethodPost, "/api/groups", gin.H{"name": " Verbs\nand\tadjectives "})
+	var group struct {
+		Name string `json:"name"`
+	}
+	decode(t, w, &group)
+	if group.Name != "Verbs and adjectives" {
+		t.Errorf("created group named %q, want the cleaned name", group.Name)
+	}
+}
+
+func TestWordFieldLengths(t *testing.T) {
+	router, _ := newTestServer(t, nil)
+	word := func(japanese, romaji, english string) gin.H {
+		return gin.H{"japanese": japanese, "romaji": romaji, "english": english}
+	}
+	long := strings.Repeat("a", maxWordTextLength)
+	tests := []struct {
+		body  gin.H
+		field string
+	}{
+		{word(strings.Repeat("水", maxWordTextLength), "mizu", "water"), ""},
+		{word(strings.Repeat("水", maxWordTextLength+1), "mizu", "water"), "japanese"},
+		{word("水", long, "water"), ""},
+		{word("水", long+"a", "water"), "romaji"},
+		{word("水", "mizu", long), ""},
+		{word("水", "mizu", long+"a"), "english"},
+		{gin.H{"japanese": "水", "romaji": "mizu", "meanings": []string{"water", long + "a"}}, "meanings[1]"},
+		{word(" \t ", "mizu", "water"), "japanese"},
+		{word("水", "\n", "water"), "romaji"},
+		{word("水", "mizu", "  "), "english"},
+	}
+	for _, tt := range tests {
+		w := request(router, http.MethodPost, "/api/words", tt.body)
+		if tt.field == "" {
+			if w.Code != http.StatusCreated {
+				t.Errorf("%v: status %d, want 201: %s", tt.body, w.Code, w.Body)
+			}
+			continue
+		}
+		if _, ok := validationFields(t, w)[tt.field]; !ok {
+			t.Errorf("%v: no error for %s in %s", tt.body, tt.field, w.Body)
+		}
+	}
+
+	// Parts are limited in bytes once in canonical form
+	for _, tt := range []struct {
+		note    string
+		invalid bool
+	}{
+		{strings.Repeat("n", maxPartsBytes-64), false},
+		{strings.Repeat("n", maxPartsBytes), true},
+	} {
+		body := word("水", "mizu", "water")
+		body["parts"] = gin.H{"part_of_speech": "noun", "note": tt.note}
+		w := request(router, http.MethodPost, "/api/words?strict=false", body)
+		if !tt.invalid {
+			if w.Code != http.StatusCreated {
+				t.Errorf("parts of %d bytes: status %d, want 201: %s", len(tt.note), w.Code, w.Body)
+			}
+			continue
+		}
+		if got := validationFields(t, w)["parts"]; got != fmt.Sprintf("must be at most %d bytes", maxPartsBytes) {
+			t.Errorf("parts of %d bytes: error %q", len(tt.note), got)
+		}
+	}
+}
+
+func TestImportValidation(t *testing.T) {
+	router, _ := newTestServer(t, nil)
+	long := strings.Repeat("a", maxWordTextLength+1)
+
+	// JSON group import
+	w := request(router, http.MethodPost, "/api/groups/import", gin.H{
+		"name": "  ",
+		"words": []gin.H{
+			{"japanese": "水", "romaji": "mizu", "english": "water"},
+			{"japanese": "\t", "romaji": "hi", "english": long},
+		},
+	})
+	fields := validationFields(t, w)
+	for _, field := range []string{"name", "words[1].japanese", "words[1].english"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("JSON import: no error for %s in %v", field, fields)
+		}
+	}
+	if _, ok := fields["words[0].japanese"]; ok {
+		t.Errorf("JSON import: error for the valid first word in %v", fields)
+	}
+
+	// CSV group import
+	csv := "japanese,romaji,english\n水,mizu,water\n火, ,fire\n山,yama," + long + "\n"
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("name", "CSV words")
+	file, _ := form.CreateFormFile("file", "words.csv")
+	file.Write([]byte(csv))
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/groups/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	fields = validationFields(t, w)
+	if len(fields) != 2 || fields["rows[1].romaji"] == "" || fields["rows[2].english"] == "" {
+		t.Errorf("CSV import: errors %v, want rows[1].romaji and rows[2].english", fields)
+	}
+
+	// URL import from a local server, refused on its loopback address unless fetched with its own client
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		fmt.Fprint(w, csv)
+	}))
+	defer remote.Close()
+	w = request(router, http.MethodPost, "/api/words/import-url", gin.H{"url": remote.URL})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("URL import from a loopback address: status %d, want 400", w.Code)
+	}
+	defer func(client *http.Client) { remoteImportClient = client }(remoteImportClient)
+	remoteImportClient = remote.Client()
+	w = request(router, http.MethodPost, "/api/words/import-url", gin.H{"url": remote.URL})
+	fields = validationFields(t, w)
+	if len(fields) != 2 || fields["rows[1].romaji"] == "" || fields["rows[2].english"] == "" {
+		t.Errorf("URL import: errors %v, want rows[1].romaji and rows[2].english", fields)
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/models"
+	"backend_go/internal/version"
+)
+
+// GetVersion handles GET /api/version, which identifies the build and the database schema the
+// server runs with. The database path and size are only shown to admins, or to everyone when
+// authentication is disabled.
+func GetVersion(c *gin.Context) {
+	info := models.VersionInfo{
+		Version:   version.Version,
+		Commit:    version.BuildCommit(),
+		GoVersion: runtime.Version(),
+	}
+	var err error
+	if info.Migrations, info.LatestMigration, err = svc.SchemaVersion(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read schema version"})
+		return
+	}
+	if user := CurrentUser(c); user == nil || user.Role == models.RoleAdmin {
+		size, err := svc.DatabaseSize()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read database size"})
+			return
+		}
+		info.Database = &models.DatabaseInfo{Path: cfg.DataSource(), SizeBytes: size}
+	}
+	c.JSON(http.StatusOK, info)
+}
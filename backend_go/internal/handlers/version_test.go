@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+	"backend_go/internal/version"
+)
+
+func TestGetVersion(t *testing.T) {
+	router, s := newTestServer(t, nil)
+	defer func(v, c string) { version.Version, version.Commit = v, c }(version.Version, version.Commit)
+	version.Version, version.Commit = "v1.2.3", "abc1234"
+
+	migrations, err := filepath.Glob(filepath.Join("..", "..", "db", "migrations", "*.sql"))
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("listing the migrations: %v", err)
+	}
+	sort.Strings(migrations)
+
+	w := request(router, http.MethodGet, "/api/version", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+	var fields map[string]interface{}
+	decode(t, w, &fields)
+	for _, field := range []string{"version", "commit", "go_version", "migrations", "latest_migration", "database"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("no %s in %s", field, w.Body)
+		}
+	}
+	var info models.VersionInfo
+	decode(t, w, &info)
+	if info.Version != "v1.2.3" || info.Commit != "abc1234" || info.GoVersion != runtime.Version() {
+		t.Errorf("build = %s %s %s, want v1.2.3 abc1234 %s", info.Version, info.Commit, info.GoVersion, runtime.Version())
+	}
+	latest := filepath.Base(migrations[len(migrations)-1])
+	if info.Migrations != len(migrations) || info.LatestMigration == nil || *info.LatestMigration != latest {
+		t.Errorf("schema = %d migrations up to %v, want %d up to %s", info.Migrations, info.LatestMigration, len(migrations), latest)
+	}
+	if info.Database == nil || info.Database.SizeBytes <= 0 || info.Database.Path == "" {
+		t.Errorf("database = %+v, want its path and size", info.Database)
+	}
+
+	// The schema reported follows the migrations the runner applies
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "9999_version_test.sql"), []byte("CREATE TABLE version_test (id INTEGER)"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.MigrateDir(s.DB, dir); err != nil {
+		t.Fatal(err)
+	}
+	decode(t, request(router, http.MethodGet, "/api/version", nil), &info)
+	if info.Migrations != len(migrations)+1 || info.LatestMigration == nil || *info.LatestMigration != "9999_version_test.sql" {
+		t.Errorf("after a migration: %d up to %v, want %d up to 9999_version_test.sql", info.Migrations, info.LatestMigration, len(migrations)+1)
+	}
+}
+
+func TestGetVersionAuth(t *testing.T) {
+	router, s := newTestServer(t, authEnv)
+	createUsers(t, s)
+
+	if w := request(router, http.MethodGet, "/api/version", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("without credentials: status %d, want 401", w.Code)
+	}
+	for _, tt := range []struct {
+		name     string
+		headers  []string
+		database bool
+	}{
+		{"viewer", nil, false},
+		{"editor", nil, false},
+		{"admin", nil, true},
+		{"API key", []string{"X-API-Key", "script-key"}, true},
+	} {
+		headers := tt.headers
+		if headers == nil {
+			_, tokens := login(t, router, tt.name, "password123")
+			headers = bearer(tokens.AccessToken)
+		}
+		w := request(router, http.MethodGet, "/api/version", nil, headers...)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status %d, want 200", tt.name, w.Code)
+			continue
+		}
+		var info models.VersionInfo
+		decode(t, w, &info)
+		if (info.Database != nil) != tt.database || info.Migrations == 0 {
+			t.Errorf("%s: database %+v after %d migrations, want database details %v", tt.name, info.Database, info.Migrations, tt.database)
+		}
+	}
+}
@@ -0,0 +1,796 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_go/internal/media"
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+)
+
+// MergeWords handles POST /api/words/:id/merge
+func MergeWords(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	var req struct {
+		SourceWordID int `json:"source_word_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	errs := fieldErrors{}
+	errs.requirePositive("source_word_id", req.SourceWordID)
+	if errs.respond(c) {
+		return
+	}
+	result, err := svc.MergeWords(id, req.SourceWordID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMergeSameWord):
+			errs.add("source_word_id", "must differ from the target word")
+			errs.respond(c)
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge words"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListDuplicateWords handles GET /api/words/duplicates
+func ListDuplicateWords(c *gin.Context) {
+	duplicates, err := svc.FindDuplicateWords()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find duplicate words"})
+		return
+	}
+	c.JSON(http.StatusOK, duplicates)
+}
+
+// GetWordSchedule handles GET /api/words/:id/schedule
+func GetWordSchedule(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	schedule, err := userService(c).GetWordSchedule(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word has not been reviewed yet"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word schedule"})
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// defaultSearchLimit and maxSearchLimit bound the limit parameter of the full-text word search.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchWords handles GET /api/words/search. With q it runs a full-text search over the Japanese,
+// romaji, English and meanings of the words, and every result tells which fields matched with a
+// highlighted snippet. Otherwise it searches by romaji prefix or English meaning.
+func SearchWords(c *gin.Context) {
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		searchWordsFullText(c, q)
+		return
+	}
+	romaji := strings.TrimSpace(c.Query("romaji"))
+	english := strings.TrimSpace(c.Query("english"))
+	if romaji == "" && english == "" {
+		errs := fieldErrors{"q": "q, romaji or english is required"}
+		errs.respond(c)
+		return
+	}
+	includeMnemonic := false
+	switch c.Query("search_in") {
+	case "":
+	case "mnemonic":
+		includeMnemonic = true
+	default:
+		errs := fieldErrors{"search_in": "must be mnemonic"}
+		errs.respond(c)
+		return
+	}
+	var words []models.Word
+	var err error
+	if romaji != "" {
+		words, err = svc.SearchWordsByRomaji(romaji)
+	} else {
+		words, err = svc.SearchWordsByMeaning(english, includeMnemonic)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search words"})
+		return
+	}
+	c.JSON(http.StatusOK, words)
+}
+
+// searchWordsFullText answers a full-text word search for q, see SearchWords.
+func searchWordsFullText(c *gin.Context, q string) {
+	limit := defaultSearchLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			errs := fieldErrors{"limit": fmt.Sprintf("must be an integer between 1 and %d", maxSearchLimit)}
+			errs.respond(c)
+			return
+		}
+		limit = n
+	}
+	results, err := svc.SearchWords(q, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search words"})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// CheckWordAnswer handles POST /api/words/:id/check
+func CheckWordAnswer(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	var req struct {
+		Answer string `json:"answer"`
+		Field  string `json:"field"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	errs := fieldErrors{}
+	errs.require("answer", strings.TrimSpace(req.Answer))
+	if req.Field != "" && req.Field != "romaji" && req.Field != "english" && req.Field != "reading" {
+		errs.add("field", "must be romaji, english or reading")
+	}
+	if errs.respond(c) {
+		return
+	}
+	switch req.Field {
+	case "english":
+		checkMeaningAnswer(c, id, req.Answer)
+		return
+	case "reading":
+		checkReadingAnswer(c, id, req.Answer)
+		return
+	}
+	correct, word, err := svc.CheckRomajiAnswer(id, req.Answer)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check answer"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"word_id": id,
+		"answer":  req.Answer,
+		"correct": correct,
+		"romaji":  word.Romaji,
+	})
+}
+
+// checkReadingAnswer answers a POST /api/words/:id/check of a kana answer, compared with the
+// reading of the word or, for a word in kana only, its japanese text.
+func checkReadingAnswer(c *gin.Context, id int, answer string) {
+	correct, word, err := svc.CheckReadingAnswer(id, answer)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+		case errors.Is(err, service.ErrNoReading):
+			errs := fieldErrors{}
+			errs.add("field", "cannot be reading, the word has no reading")
+			errs.respond(c)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check answer"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"word_id": id,
+		"answer":  answer,
+		"correct": correct,
+		"reading": service.WordReading(word),
+	})
+}
+
+// checkMeaningAnswer answers a POST /api/words/:id/check of an english answer, which may match any
+// meaning of the word.
+func checkMeaningAnswer(c *gin.Context, id int, answer string) {
+	correct, matched, word, err := svc.CheckMeaningAnswer(id, answer)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check answer"})
+		return
+	}
+	response := gin.H{
+		"word_id":  id,
+		"answer":   answer,
+		"correct":  correct,
+		"meanings": word.Meanings,
+	}
+	if correct {
+		response["matched"] = matched
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListWordMeanings handles GET /api/words/:id/meanings
+func ListWordMeanings(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	meanings, err := svc.ListWordMeanings(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word meanings"})
+		return
+	}
+	c.JSON(http.StatusOK, meanings)
+}
+
+// AddWordMeaning handles POST /api/words/:id/meanings
+func AddWordMeaning(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	var req struct {
+		Meaning  string `json:"meaning"`
+		Position *int   `json:"position"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	errs := fieldErrors{}
+	errs.text("meaning", &req.Meaning, maxWordTextLength)
+	errs.require("meaning", req.Meaning)
+	if errs.respond(c) {
+		return
+	}
+	meaning, err := svc.AddWordMeaning(id, req.Meaning, req.Position)
+	if err != nil {
+		respondMeaningError(c, err, "Failed to add word meaning")
+		return
+	}
+	c.JSON(http.StatusCreated, meaning)
+}
+
+// UpdateWordMeaning handles PUT /api/words/:id/meanings/:meaning_id
+func UpdateWordMeaning(c *gin.Context) {
+	id, meaningID, ok := parseMeaningIDs(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Meaning  *string `json:"meaning"`
+		Position *int    `json:"position"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	errs := fieldErrors{}
+	if req.Meaning != nil {
+		errs.text("meaning", req.Meaning, maxWordTextLength)
+		errs.require("meaning", *req.Meaning)
+	}
+	if errs.respond(c) {
+		return
+	}
+	meaning, err := svc.UpdateWordMeaning(id, meaningID, req.Meaning, req.Position)
+	if err != nil {
+		respondMeaningError(c, err, "Failed to update word meaning")
+		return
+	}
+	c.JSON(http.StatusOK, meaning)
+}
+
+// DeleteWordMeaning handles DELETE /api/words/:id/meanings/:meaning_id
+func DeleteWordMeaning(c *gin.Context) {
+	id, meaningID, ok := parseMeaningIDs(c)
+	if !ok {
+		return
+	}
+	if err := svc.DeleteWordMeaning(id, meaningID); err != nil {
+		respondMeaningError(c, err, "Failed to delete word meaning")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Meaning deleted successfully"})
+}
+
+// parseMeaningIDs parses the word and meaning IDs of a meaning route, answering 400 if either is invalid.
+func parseMeaningIDs(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return 0, 0, false
+	}
+	meaningID, err := strconv.Atoi(c.Param("meaning_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meaning ID"})
+		return 0, 0, false
+	}
+	return id, meaningID, true
+}
+
+// respondMeaningError maps the errors of the word meaning operations to responses.
+func respondMeaningError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Word or meaning not found"})
+	case errors.Is(err, service.ErrDuplicateMeaning), errors.Is(err, service.ErrLastMeaning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// GetRelatedWords handles GET /api/words/:id/related
+func GetRelatedWords(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	related, err := svc.GetRelatedWords(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch related words"})
+		return
+	}
+	c.JSON(http.StatusOK, related)
+}
+
+// CreateWordRelation handles POST /api/words/:id/related
+func CreateWordRelation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	var req struct {
+		RelatedWordID int    `json:"related_word_id"`
+		Type          string `json:"type"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	errs := fieldErrors{}
+	errs.requirePositive("related_word_id", req.RelatedWordID)
+	if req.RelatedWordID == id {
+		errs.add("related_word_id", service.ErrSelfRelation.Error())
+	}
+	validType := false
+	for _, t := range service.RelationTypes {
+		validType = validType || req.Type == t
+	}
+	if !validType {
+		errs.add("type", "must be one of "+strings.Join(service.RelationTypes, ", "))
+	}
+	if errs.respond(c) {
+		return
+	}
+	relation, err := svc.CreateWordRelation(id, req.RelatedWordID, req.Type)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+		case errors.Is(err, service.ErrDuplicateRelation):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create word relation"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, relation)
+}
+
+// DeleteWordRelation handles DELETE /api/words/:id/related/:related_id
+func DeleteWordRelation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	relatedID, err := strconv.Atoi(c.Param("related_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid related word ID"})
+		return
+	}
+	if err := svc.DeleteWordRelation(id, relatedID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word relation not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete word relation"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Word relation deleted successfully"})
+}
+
+// GetWordExamples handles GET /api/words/:id/examples
+func GetWordExamples(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	examples, err := svc.GetWordExamples(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word examples"})
+		return
+	}
+	c.JSON(http.StatusOK, examples)
+}
+
+// AddWordExample handles POST /api/words/:id/examples
+func AddWordExample(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	var req struct {
+		Japanese string `json:"japanese"`
+		English  string `json:"english"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	errs := fieldErrors{}
+	errs.text("japanese", &req.Japanese, maxWordTextLength)
+	errs.text("english", &req.English, maxWordTextLength)
+	errs.require("japanese", req.Japanese)
+	errs.require("english", req.English)
+	if errs.respond(c) {
+		return
+	}
+	example, err := svc.AddWordExample(id, req.Japanese, req.English)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add word example"})
+		return
+	}
+	c.JSON(http.StatusCreated, example)
+}
+
+// ListWordsWithInvalidParts handles GET /api/admin/words/invalid_parts, which lists the words whose
+// parts could not be converted to the parts schema.
+func ListWordsWithInvalidParts(c *gin.Context) {
+	issues, err := svc.GetWordsWithInvalidParts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch words with invalid parts"})
+		return
+	}
+	c.Header(totalCountHeader, strconv.Itoa(len(issues)))
+	c.JSON(http.StatusOK, issues)
+}
+
+// ListWordsMissingReading handles GET /api/admin/words/missing_reading
+func ListWordsMissingReading(c *gin.Context) {
+	words, err := svc.GetWordsMissingReading()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch words missing a reading"})
+		return
+	}
+	c.Header(totalCountHeader, strconv.Itoa(len(words)))
+	c.JSON(http.StatusOK, words)
+}
+
+// GetWordFacets handles GET /api/words/facets
+func GetWordFacets(c *gin.Context) {
+	facets, err := svc.GetWordFacets()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word facets"})
+		return
+	}
+	c.JSON(http.StatusOK, facets)
+}
+
+// Limits of POST /api/words/import-url downloads.
+const (
+	remoteImportTimeout  = 15 * time.Second
+	remoteImportMaxBytes = 5 << 20
+)
+
+// remoteImportClient fetches remote word lists from public addresses only. Its timeout bounds the
+// whole download.
+var remoteImportClient = service.NewRemoteClient(remoteImportTimeout)
+
+// ImportWordsFromURL handles POST /api/words/import-url
+func ImportWordsFromURL(c *gin.Context) {
+	// DISABLE_REMOTE_IMPORT turns outbound fetches off for locked-down deployments
+	if cfg.DisableRemoteImport {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Importing from URLs is disabled on this server"})
+		return
+	}
+	var req struct {
+		URL     string `json:"url"`
+		GroupID int    `json:"group_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+	errs := fieldErrors{}
+	errs.require("url", req.URL)
+	if req.GroupID < 0 {
+		errs.add("group_id", "must be a positive integer")
+	}
+	if errs.respond(c) {
+		return
+	}
+
+	words, err := service.FetchRemoteCSV(c.Request.Context(), remoteImportClient, req.URL, remoteImportMaxBytes)
+	if err != nil {
+		var fetchErr *service.RemoteFetchError
+		switch {
+		case errors.Is(err, service.ErrPrivateAddress):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.As(err, &fetchErr):
+			c.JSON(http.StatusBadGateway, gin.H{"error": fetchErr.Error()})
+		case errors.Is(err, service.ErrInvalidRemoteURL), errors.Is(err, service.ErrNotCSV):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrRemoteTooLarge):
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("remote document exceeds %d bytes", remoteImportMaxBytes)})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word list"})
+		}
+		return
+	}
+	if len(words) == 0 {
+		errs.add("url", "contains no words")
+	}
+	for i := range words {
+		w := &words[i]
+		prefix := fmt.Sprintf("rows[%d].", i)
+		cleanWord(errs, prefix, w)
+		errs.require(prefix+"japanese", w.Japanese)
+		errs.require(prefix+"romaji", w.Romaji)
+		errs.require(prefix+"english", w.English)
+	}
+	if errs.respond(c) {
+		return
+	}
+
+	result, err := svc.ImportWords(req.GroupID, words, "url:"+req.URL, dryRun)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import words"})
+		return
+	}
+	c.JSON(importStatus(dryRun), result)
+}
+
+// parseDryRun reads the dry_run query parameter of an import, responding with a 400 and
+// returning false when it is not a boolean.
+func parseDryRun(c *gin.Context) (bool, bool) {
+	v := c.Query("dry_run")
+	if v == "" {
+		return false, true
+	}
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+		return false, false
+	}
+	return dryRun, true
+}
+
+// importStatus is the status of a successful import: 201 when it wrote data, 200 for a dry run.
+func importStatus(dryRun bool) int {
+	if dryRun {
+		return http.StatusOK
+	}
+	return http.StatusCreated
+}
+
+// Size limits of word media uploads.
+const (
+	maxImageBytes = 5 << 20
+	maxAudioBytes = 10 << 20
+)
+
+// audioGenerationTimeout bounds how long a request waits for text-to-speech.
+const audioGenerationTimeout = 20 * time.Second
+
+// readUpload reads the multipart file field of the request, responding with an error and
+// returning false when it is missing or larger than maxBytes.
+func readUpload(c *gin.Context, field string, maxBytes int64) ([]byte, bool) {
+	// Leave room for the multipart headers around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+64<<10)
+	header, err := c.FormFile(field)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("%s must be at most %d bytes", field, maxBytes)})
+			return nil, false
+		}
+		errs := fieldErrors{field: "is required as a multipart file"}
+		errs.respond(c)
+		return nil, false
+	}
+	if header.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("%s must be at most %d bytes", field, maxBytes)})
+		return nil, false
+	}
+	f, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload"})
+		return nil, false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload"})
+		return nil, false
+	}
+	return data, true
+}
+
+// uploadWordMedia answers an upload of the image or audio of a word, depending on kind.
+func uploadWordMedia(c *gin.Context, kind media.Kind, maxBytes int64, accepted string) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	data, ok := readUpload(c, kind.Name, maxBytes)
+	if !ok {
+		return
+	}
+	word, err := svc.SetWordMedia(id, kind, data)
+	if err != nil {
+		respondMediaError(c, err, kind, accepted)
+		return
+	}
+	c.JSON(http.StatusOK, word)
+}
+
+// respondMediaError answers a failed media operation on a word.
+func respondMediaError(c *gin.Context, err error, kind media.Kind, accepted string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Word not found"})
+	case errors.Is(err, media.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": kind.Name + " must be " + accepted})
+	case errors.Is(err, service.ErrMediaDisabled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Media storage is not configured"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store " + kind.Name})
+	}
+}
+
+// deleteWordMedia answers a removal of the image or audio of a word, depending on kind.
+func deleteWordMedia(c *gin.Context, kind media.Kind) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	if err := svc.RemoveWordMedia(id, kind); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Word or " + kind.Name + " not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete " + kind.Name})
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UploadWordImage handles POST /api/words/:id/image
+func UploadWordImage(c *gin.Context) {
+	uploadWordMedia(c, media.Image, maxImageBytes, "a JPEG, PNG or WebP file")
+}
+
+// DeleteWordImage handles DELETE /api/words/:id/image
+func DeleteWordImage(c *gin.Context) {
+	deleteWordMedia(c, media.Image)
+}
+
+// UploadWordAudio handles POST /api/words/:id/audio
+func UploadWordAudio(c *gin.Context) {
+	uploadWordMedia(c, media.Audio, maxAudioBytes, "an MP3 or Ogg file")
+}
+
+// DeleteWordAudio handles DELETE /api/words/:id/audio
+func DeleteWordAudio(c *gin.Context) {
+	deleteWordMedia(c, media.Audio)
+}
+
+// GenerateWordAudio handles POST /api/words/:id/audio/generate
+func GenerateWordAudio(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), audioGenerationTimeout)
+	defer cancel()
+	word, err := svc.GenerateWordAudio(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTTSDisabled):
+			c.JSON(http.StatusNotImplemented, gin.H{"error": "Audio generation is not configured"})
+		case errors.Is(err, context.DeadlineExceeded):
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Audio generation timed out"})
+		case errors.Is(err, sql.ErrNoRows), errors.Is(err, media.ErrUnsupportedType), errors.Is(err, service.ErrMediaDisabled):
+			respondMediaError(c, err, media.Audio, "an MP3 or Ogg file")
+		default:
+			log.Printf("Generating audio for word %d: %v", id, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Audio generation failed"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"audio_url": word.AudioURL, "word": word})
+}
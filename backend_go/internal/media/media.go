@@ -0,0 +1,118 @@
+// Package media stores uploaded files (word images and audio) on disk under content-hash names.
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// URLPrefix is the path under which stored files are served.
+const URLPrefix = "/media/"
+
+// ErrUnsupportedType is returned for content whose sniffed type is not allowed.
+var ErrUnsupportedType = errors.New("unsupported media type")
+
+// Kind is a category of media with the content types it accepts and the extension of each.
+type Kind struct {
+	Name  string
+	Types map[string]string
+}
+
+// Image accepts JPEG, PNG and WebP images.
+var Image = Kind{Name: "image", Types: map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}}
+
+// Audio accepts MP3 and Ogg audio.
+var Audio = Kind{Name: "audio", Types: map[string]string{
+	"audio/mpeg":      ".mp3",
+	"application/ogg": ".ogg",
+}}
+
+// Sniff detects the content type of data from its first bytes, ignoring any name or declared type.
+func Sniff(data []byte) string {
+	contentType := http.DetectContentType(data)
+	// MP3 files without an ID3 tag start directly with an MPEG audio frame header
+	if contentType == "application/octet-stream" && len(data) > 1 && data[0] == 0xFF && data[1]&0xE0 == 0xE0 {
+		return "audio/mpeg"
+	}
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType
+}
+
+// Store keeps media files in a directory.
+type Store struct {
+	Dir string
+}
+
+// NewStore returns a store keeping files in dir, creating the directory if needed.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{Dir: dir}, nil
+}
+
+// Save stores data if its sniffed type belongs to kind and returns the file name, which is the
+// SHA-256 of the content plus the extension of its type. Saving the same content twice yields
+// the same name and a single file.
+func (s *Store) Save(kind Kind, data []byte) (string, error) {
+	ext, ok := kind.Types[Sniff(data)]
+	if !ok {
+		return "", ErrUnsupportedType
+	}
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:]) + ext
+	path := filepath.Join(s.Dir, name)
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return name, nil
+	}
+
+	// Write to a temporary file first so a partially written file is never served
+	tmp, err := os.CreateTemp(s.Dir, ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return name, nil
+}
+
+// Remove deletes a stored file. Removing a file that does not exist is not an error.
+func (s *Store) Remove(name string) error {
+	err := os.Remove(filepath.Join(s.Dir, filepath.Base(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// URL returns the URL path a stored file is served at.
+func URL(name string) string {
+	return URLPrefix + name
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJSONLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, nil))
+	router := gin.New()
+	router.Use(RequestID(), JSONLogger(logger))
+	router.GET("/api/words", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+	router.GET("/api/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	tests := []struct {
+		target string
+		status int
+		level  string
+		path   string
+	}{
+		{"/api/words?page=2", http.StatusOK, "INFO", "/api/words?page=2"},
+		{"/api/words?access_token=secret&page=2", http.StatusOK, "INFO", "/api/words?access_token=redacted&page=2"},
+		{"/api/fail", http.StatusInternalServerError, "ERROR", "/api/fail"},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		req.Header.Set(RequestIDHeader, "req-1")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		var line map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+			t.Fatalf("%s: log line %q is not JSON: %v", tt.target, out.String(), err)
+		}
+		want := map[string]interface{}{
+			"level":      tt.level,
+			"msg":        "request",
+			"request_id": "req-1",
+			"method":     "GET",
+			"path":       tt.path,
+			"status":     float64(tt.status),
+			"client_ip":  "192.0.2.1",
+		}
+		for key, value := range want {
+			if line[key] != value {
+				t.Errorf("%s: %s = %v, want %v", tt.target, key, line[key], value)
+			}
+		}
+		for _, key := range []string{"time", "bytes", "latency_ms"} {
+			if _, ok := line[key]; !ok {
+				t.Errorf("%s: log line has no %s: %s", tt.target, key, out.String())
+			}
+		}
+		if bytes.Contains(out.Bytes(), []byte("secret")) {
+			t.Errorf("%s: access token logged: %s", tt.target, out.String())
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit allows each client IP at most limit requests per window, answering further
+// requests in the same window with 429 Too Many Requests.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	type counter struct {
+		start time.Time
+		count int
+	}
+	var mu sync.Mutex
+	counters := make(map[string]*counter)
+	lastSweep := time.Now()
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// Drop counters of finished windows now and then so the map does not grow without bound
+		if now.Sub(lastSweep) > window {
+			for key, ctr := range counters {
+				if now.Sub(ctr.start) >= window {
+					delete(counters, key)
+				}
+			}
+			lastSweep = now
+		}
+		ctr, ok := counters[ip]
+		if !ok || now.Sub(ctr.start) >= window {
+			ctr = &counter{start: now}
+			counters[ip] = ctr
+		}
+		ctr.count++
+		exceeded := ctr.count > limit
+		retryAfter := ctr.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if exceeded {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
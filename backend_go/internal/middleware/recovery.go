@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery turns a panic in a later handler into a 500 response with the API's JSON error body.
+// The panic and its stack are logged through slog together with the request id, so it must be
+// registered after RequestID.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(recovered)
+			}
+			slog.Error("panic recovered",
+				slog.String("request_id", GetRequestID(c)),
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.Request.URL.Path),
+				slog.Any("panic", recovered),
+				slog.String("stack", string(debug.Stack())),
+			)
+			// A client that went away cannot be answered
+			if brokenConnection(recovered) {
+				c.Abort()
+				return
+			}
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}()
+		c.Next()
+	}
+}
+
+// brokenConnection reports whether a panic was caused by writing to a closed client connection.
+func brokenConnection(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if errors.As(opErr, &syscallErr) {
+		return errors.Is(syscallErr, syscall.EPIPE) || errors.Is(syscallErr, syscall.ECONNRESET)
+	}
+	msg := strings.ToLower(opErr.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
@@ -1,23 +1,358 @@
 package models
 
 import (
-	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
 // Word represents a vocabulary word.
+// English is the primary meaning and Meanings lists every english meaning, primary first.
+// ImageURL and AudioURL are the paths the word's picture and pronunciation are served at,
+// nil when it has none. JLPTLevel is 1 for N1 up to 5 for N5, nil when it is not known.
+// Mnemonic is the learner's memory aid for the word, nil when none was written.
+// DeletedAt is only set for soft-deleted words, which appear in sync deltas alone.
 type Word struct {
-	ID       int            `json:"id"`
-	Japanese string         `json:"japanese"`
-	Romaji   string         `json:"romaji"`
-	English  string         `json:"english"`
-	Parts    sql.NullString `json:"parts,omitempty"`
+	ID        int        `json:"id"`
+	Japanese  string     `json:"japanese"`
+	Reading   *string    `json:"reading"`
+	Romaji    string     `json:"romaji"`
+	English   string     `json:"english"`
+	Meanings  Meanings   `json:"meanings"`
+	Parts     Parts      `json:"parts,omitempty"`
+	ImageURL  *string    `json:"image_url"`
+	AudioURL  *string    `json:"audio_url"`
+	JLPTLevel *int       `json:"jlpt_level"`
+	Mnemonic  *string    `json:"mnemonic"`
+	Source    *string    `json:"source"`
+	SourceID  *string    `json:"source_id"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
-// Group represents a thematic group of words.
+// Meanings is the list of english meanings of a word. It scans from the JSON array text built
+// by the word queries and always marshals as an array, never null.
+type Meanings []string
+
+// Scan implements sql.Scanner.
+func (m *Meanings) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*m = Meanings{}
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into Meanings", src)
+	}
+	var meanings []string
+	if err := json.Unmarshal(data, &meanings); err != nil {
+		return err
+	}
+	*m = meanings
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m Meanings) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(m))
+}
+
+// Parts is the JSON text of a word's parts (see service.ParseParts), empty when it has none. It
+// marshals as the JSON value itself rather than as a string, or as a string for legacy text that
+// is not JSON, and is left out of a Word when empty.
+type Parts []byte
+
+// Scan implements sql.Scanner.
+func (p *Parts) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*p = nil
+	case string:
+		*p = Parts(v)
+	case []byte:
+		*p = append(Parts(nil), v...)
+	default:
+		return fmt.Errorf("cannot scan %T into Parts", src)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Parts) MarshalJSON() ([]byte, error) {
+	switch {
+	case len(p) == 0:
+		return []byte("null"), nil
+	case json.Valid(p):
+		return p, nil
+	default:
+		return json.Marshal(string(p))
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *Parts) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*p = nil
+		return nil
+	}
+	// Legacy text marshaled as a string is unquoted back
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*p = Parts(text)
+		return nil
+	}
+	*p = append(Parts(nil), data...)
+	return nil
+}
+
+// WordMeaning is one english meaning of a word. Position 0 is the primary meaning, mirrored in Word.English.
+type WordMeaning struct {
+	ID       int    `json:"id"`
+	WordID   int    `json:"word_id"`
+	Meaning  string `json:"meaning"`
+	Position int    `json:"position"`
+}
+
+// WordRelation links two words as synonyms, antonyms or related words to see as well.
+type WordRelation struct {
+	WordID        int    `json:"word_id"`
+	RelatedWordID int    `json:"related_word_id"`
+	Type          string `json:"type"`
+}
+
+// RelatedWords are the words related to a word, grouped by relation type.
+type RelatedWords struct {
+	Synonyms []Word `json:"synonyms"`
+	Antonyms []Word `json:"antonyms"`
+	SeeAlso  []Word `json:"see_also"`
+}
+
+// WordExample is an example sentence using a word.
+type WordExample struct {
+	ID        int       `json:"id"`
+	WordID    int       `json:"word_id"`
+	Japanese  string    `json:"japanese"`
+	English   string    `json:"english"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WordDetail is a word with the words related to it, as returned for a single word.
+// Examples is only set when they were asked for, and is then listed even when empty.
+type WordDetail struct {
+	Word
+	Related  RelatedWords   `json:"related"`
+	Examples *[]WordExample `json:"examples,omitempty"`
+}
+
+// WordWithStats is a word annotated with its aggregated review results.
+// Accuracy is the fraction of correct reviews and is nil for words that were never reviewed.
+// ByDirection splits the reviews that name the direction the word was asked in.
+type WordWithStats struct {
+	Word
+	CorrectCount int                       `json:"correct_count"`
+	WrongCount   int                       `json:"wrong_count"`
+	Accuracy     *float64                  `json:"accuracy"`
+	ByDirection  map[string]DirectionStats `json:"by_direction"`
+}
+
+// DirectionStats are the review results in one answer direction, such as jp_to_en. Accuracy is nil
+// without answered reviews in that direction.
+type DirectionStats struct {
+	CorrectCount int      `json:"correct_count"`
+	WrongCount   int      `json:"wrong_count"`
+	Accuracy     *float64 `json:"accuracy"`
+}
+
+// WordMergeResult reports how many rows were moved from the source word to the target word by a merge.
+// GroupLinksDropped counts group links of the source that the target already had.
+type WordMergeResult struct {
+	TargetWordID      int `json:"target_word_id"`
+	SourceWordID      int `json:"source_word_id"`
+	ReviewsMoved      int `json:"reviews_moved"`
+	GroupLinksMoved   int `json:"group_links_moved"`
+	GroupLinksDropped int `json:"group_links_dropped"`
+	TagsMoved         int `json:"tags_moved"`
+}
+
+// DuplicateWords is a set of words that look like the same entry entered more than once.
+type DuplicateWords struct {
+	Normalized string `json:"normalized"`
+	Words      []Word `json:"words"`
+}
+
+// Group represents a thematic group of words. CreatedBy is the username of the user who created
+// it, nil when it was created without authentication or before authors were recorded.
+// DefaultActivityType is the type of the study activity of sessions started without one, if set.
 type Group struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID                  int       `json:"id"`
+	Name                string    `json:"name"`
+	DefaultActivityType *string   `json:"default_activity_type"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	CreatedBy           *string   `json:"created_by"`
+	Archived            bool      `json:"archived"`
+}
+
+// GroupUpdate holds the changes to a group. An empty Name keeps the name. A nil
+// DefaultActivityType keeps the default activity type and an empty one removes it.
+type GroupUpdate struct {
+	Name                string
+	DefaultActivityType *string
+}
+
+// ImportWord holds the fields of a new word, as supplied when creating or importing words.
+type ImportWord struct {
+	Japanese string `json:"japanese"`
+	// Reading is the kana reading of a word written with kanji. Without a Romaji, the romaji is
+	// generated from it, or from Japanese when that is kana only.
+	Reading   string   `json:"reading"`
+	Romaji    string   `json:"romaji"`
+	English   string   `json:"english"`
+	Meanings  []string `json:"meanings"`
+	Parts     string   `json:"parts"`
+	JLPTLevel *int     `json:"jlpt_level"`
+	Mnemonic  string   `json:"mnemonic"`
+	// Source and SourceID record where the word comes from (see the words table). An import fills
+	// in its own source for words without one.
+	Source   string `json:"source"`
+	SourceID string `json:"source_id"`
+}
+
+// WordUpdate holds the changes to a word. A nil Meanings keeps the word's other meanings, and a
+// nil JLPTLevel, Mnemonic or Reading keeps the current value. An empty Mnemonic or Reading removes it.
+type WordUpdate struct {
+	English   string
+	Meanings  []string
+	JLPTLevel *int
+	Mnemonic  *string
+	Reading   *string
+	// Parts replaces the word's parts unless nil, an empty string clearing them
+	Parts *string
+}
+
+// WordPartsIssue is a word whose parts do not follow the parts schema, and why.
+type WordPartsIssue struct {
+	Word
+	Problem string `json:"problem"`
+}
+
+// Facet is a distinct value of a word attribute and the number of words that have it.
+type Facet struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// GroupFacet is a group and the number of words in it.
+type GroupFacet struct {
+	GroupID int    `json:"group_id"`
+	Name    string `json:"name"`
+	Count   int    `json:"count"`
+}
+
+// WordFacets lists the JLPT levels, parts of speech and groups present among the words, with counts.
+type WordFacets struct {
+	JLPTLevels    []Facet      `json:"jlpt_levels"`
+	PartsOfSpeech []Facet      `json:"parts_of_speech"`
+	Groups        []GroupFacet `json:"groups"`
+}
+
+// GroupImportResult summarizes a group import: whether the group was created, the words created
+// and the existing words reused, and the links added. A dry run reports what the import would have
+// done, including the ID the group would get, without writing anything.
+type GroupImportResult struct {
+	GroupID      int  `json:"group_id"`
+	GroupCreated bool `json:"group_created"`
+	WordsCreated int  `json:"words_created"`
+	WordsReused  int  `json:"words_reused"`
+	WordsLinked  int  `json:"words_linked"`
+	DryRun       bool `json:"dry_run"`
+}
+
+// BulkResult reports the outcome of a bulk operation on words, per ID: the IDs it was applied to,
+// those it was not applied to because there was nothing to do, and those that are invalid.
+type BulkResult struct {
+	Succeeded []int         `json:"succeeded"`
+	Skipped   []BulkOutcome `json:"skipped"`
+	Failed    []BulkOutcome `json:"failed"`
+}
+
+// BulkOutcome is an ID a bulk operation skipped or failed on, and why.
+type BulkOutcome struct {
+	ID     int    `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// SeedResult summarizes the loading of a seed dataset.
+type SeedResult struct {
+	Dataset       string `json:"dataset"`
+	GroupsCreated int    `json:"groups_created"`
+	WordsCreated  int    `json:"words_created"`
+	WordsReused   int    `json:"words_reused"`
+	WordsLinked   int    `json:"words_linked"`
+}
+
+// WordImportResult summarizes an import of words into an optional existing group.
+// GroupID is nil when the words were not added to a group. A dry run reports what the import
+// would have done without writing anything.
+type WordImportResult struct {
+	GroupID      *int `json:"group_id"`
+	WordsCreated int  `json:"words_created"`
+	WordsReused  int  `json:"words_reused"`
+	WordsLinked  int  `json:"words_linked"`
+	DryRun       bool `json:"dry_run"`
+}
+
+// GroupStats summarizes the words of a group and how well they have been reviewed.
+// Accuracy is nil when none of the group's words have been reviewed.
+type GroupStats struct {
+	GroupID      int      `json:"group_id"`
+	Name         string   `json:"name"`
+	TotalWords   int      `json:"total_words"`
+	StudiedWords int      `json:"studied_words"`
+	CorrectCount int      `json:"correct_count"`
+	WrongCount   int      `json:"wrong_count"`
+	Accuracy     *float64 `json:"accuracy"`
+}
+
+// GroupSetStats combines the stats of a set of groups. A word in several of the groups is counted
+// once. GroupIDs lists the groups found and Missing the requested IDs that do not exist.
+type GroupSetStats struct {
+	GroupIDs     []int    `json:"group_ids"`
+	Missing      []int    `json:"missing"`
+	TotalWords   int      `json:"total_words"`
+	StudiedWords int      `json:"studied_words"`
+	CorrectCount int      `json:"correct_count"`
+	WrongCount   int      `json:"wrong_count"`
+	Accuracy     *float64 `json:"accuracy"`
+}
+
+// GroupOverlap is the number of words shared by a pair of groups.
+type GroupOverlap struct {
+	GroupIDs    [2]int `json:"group_ids"`
+	SharedWords int    `json:"shared_words"`
+}
+
+// ShareLink grants public read-only access to a group. ExpiresAt is nil for links that never expire.
+type ShareLink struct {
+	ID        int        `json:"id"`
+	GroupID   int        `json:"group_id"`
+	Token     string     `json:"token"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// SharedGroup is the read-only view of a group served through a share link.
+type SharedGroup struct {
+	Group Group      `json:"group"`
+	Words []Word     `json:"words"`
+	Stats GroupStats `json:"stats"`
 }
 
 // WordGroup represents the many-to-many relationship between words and groups.
@@ -27,22 +362,196 @@ type WordGroup struct {
 	GroupID int `json:"group_id"`
 }
 
+// Tag is a freeform label attached to words, e.g. "needs-practice".
+type Tag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
 // StudySession represents a record of a study session.
+// Every session is run with exactly one study activity (StudyActivityID),
+// while one study activity can be used by many sessions.
+// EndedAt is nil while the session is still open, and Notes is nil until the learner writes some.
 type StudySession struct {
-	ID              int       `json:"id"`
-	GroupID         int       `json:"group_id"`
-	CreatedAt       time.Time `json:"created_at"`
-	StudyActivityID int       `json:"study_activity_id"`
+	ID              int        `json:"id"`
+	GroupID         int        `json:"group_id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	StudyActivityID int        `json:"study_activity_id"`
+	EndedAt         *time.Time `json:"ended_at"`
+	Notes           *string    `json:"notes"`
 }
 
-// StudyActivity represents a specific study activity linked to a study session.
+// StudySessionListItem is a study session in the study sessions listing, with the name of its
+// group, nil when the group was deleted. Abandoned sessions were ended by the stale session cleanup.
+type StudySessionListItem struct {
+	StudySession
+	GroupName *string `json:"group_name"`
+	Abandoned bool    `json:"abandoned"`
+}
+
+// StudySessionFilter narrows the study sessions listing. An empty ActivityType matches every
+// activity. Abandoned sessions are left out unless IncludeAbandoned.
+type StudySessionFilter struct {
+	ActivityType     string
+	IncludeAbandoned bool
+}
+
+// StudySessionUpdate holds the changes to a study session. A zero StudyActivityID keeps the
+// activity and a nil Notes keeps the notes. Empty notes are removed.
+type StudySessionUpdate struct {
+	StudyActivityID int
+	Notes           *string
+}
+
+// CurrentStudySession is an open study session that can be resumed, with the number of words of
+// its group that have not been reviewed in it yet.
+type CurrentStudySession struct {
+	StudySession
+	RemainingWords int `json:"remaining_words"`
+}
+
+// WordSearchResult is a word found by a full-text search, with the fields that matched, among
+// japanese, romaji, english and meanings, and an HTML snippet of the best matching field in which
+// the matches are wrapped in <mark> tags.
+type WordSearchResult struct {
+	Word
+	MatchedFields []string `json:"matched_fields"`
+	Snippet       string   `json:"snippet"`
+}
+
+// SessionWord is a word reviewed in a study session and the number of times it was reviewed there.
+type SessionWord struct {
+	Word
+	TimesReviewed int `json:"times_reviewed"`
+}
+
+// SessionReport is a complete, read-only account of a study session for sharing or printing: the
+// session, its group and activity, every word reviewed in it with its results, and a summary.
+// DurationSeconds is measured as in the activity stats, nil when it is unknown.
+type SessionReport struct {
+	Session         StudySession        `json:"session"`
+	GroupName       string              `json:"group_name"`
+	ActivityName    *string             `json:"activity_name"`
+	DurationSeconds *float64            `json:"duration_seconds"`
+	Words           []SessionReportWord `json:"words"`
+	Summary         SessionSummary      `json:"summary"`
+}
+
+// SessionReportWord is a word reviewed in a study session with its results there. Correct is the
+// result of its latest attempt.
+type SessionReportWord struct {
+	Word
+	Attempts     int  `json:"attempts"`
+	CorrectCount int  `json:"correct_count"`
+	WrongCount   int  `json:"wrong_count"`
+	SkippedCount int  `json:"skipped_count"`
+	Correct      bool `json:"correct"`
+}
+
+// SessionSummary sums up the reviews of a study session. Accuracy is the share of correct reviews
+// among the answered (not skipped) ones, nil without answered reviews. ByDirection splits them by
+// answer direction, leaving out the UnknownDirectionCount reviews recorded without one.
+type SessionSummary struct {
+	WordsReviewed         int                       `json:"words_reviewed"`
+	WordsCorrect          int                       `json:"words_correct"`
+	TotalReviews          int                       `json:"total_reviews"`
+	CorrectCount          int                       `json:"correct_count"`
+	WrongCount            int                       `json:"wrong_count"`
+	SkippedCount          int                       `json:"skipped_count"`
+	Accuracy              *float64                  `json:"accuracy"`
+	ByDirection           map[string]DirectionStats `json:"by_direction"`
+	UnknownDirectionCount int                       `json:"unknown_direction_count"`
+}
+
+// QuizWord is a word selected for a quiz, its difficulty bucket (new, weak or strong) and the
+// direction to ask it in, to be sent back with its review.
+type QuizWord struct {
+	Word
+	Bucket    string `json:"bucket"`
+	Direction string `json:"direction"`
+}
+
+// RetryWord is a word whose latest answer in a study session was wrong, queued to be asked again.
+// Attempts counts its reviews in the session so far and NextAttempt is the attempt number to
+// submit the retry with.
+type RetryWord struct {
+	Word
+	Attempts       int       `json:"attempts"`
+	NextAttempt    int       `json:"next_attempt"`
+	LastAnsweredAt time.Time `json:"last_answered_at"`
+}
+
+// NextWord is the word to study next in a study session. Reason tells why it was picked: queued
+// (from the session's queue), due (per its review schedule), new (never reviewed) or scheduled (not
+// due yet). Remaining counts the words left to review in the session, including this one.
+type NextWord struct {
+	Word
+	Reason    string `json:"reason"`
+	Remaining int    `json:"remaining"`
+}
+
+// StudySessionDetail is a study session joined with the names of its group and activity
+// and the number of words reviewed in it.
+type StudySessionDetail struct {
+	ID               int       `json:"id"`
+	GroupID          int       `json:"group_id"`
+	GroupName        string    `json:"group_name"`
+	StudyActivityID  int       `json:"study_activity_id"`
+	ActivityName     string    `json:"activity_name"`
+	CreatedAt        time.Time `json:"created_at"`
+	ReviewItemsCount int       `json:"review_items_count"`
+}
+
+// StudyActivity represents a kind of study activity (e.g. "Vocabulary Quiz").
+// StudySessionID records the session the activity was first launched from;
+// the sessions that use an activity reference it through StudySession.StudyActivityID.
 type StudyActivity struct {
 	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
 	StudySessionID int       `json:"study_session_id"`
 	GroupID        int       `json:"group_id"`
 	CreatedAt      time.Time `json:"created_at"`
 }
 
+// WordSchedule is the spaced repetition schedule of a word.
+type WordSchedule struct {
+	WordID         int       `json:"word_id"`
+	Repetitions    int       `json:"repetitions"`
+	IntervalDays   int       `json:"interval_days"`
+	EaseFactor     float64   `json:"ease_factor"`
+	LastReviewedAt time.Time `json:"last_reviewed_at"`
+	NextReviewAt   time.Time `json:"next_review_at"`
+}
+
+// ReviewOutcome is the result of recording a review: whether a new review was created
+// (rather than an existing one updated) and the word's resulting schedule.
+type ReviewOutcome struct {
+	Created  bool          `json:"created"`
+	Schedule *WordSchedule `json:"schedule"`
+}
+
+// QueryTiming is the time spent running one SQL statement, in milliseconds.
+type QueryTiming struct {
+	Query   string  `json:"query"`
+	Count   int     `json:"count"`
+	TotalMs float64 `json:"total_ms"`
+	AvgMs   float64 `json:"avg_ms"`
+	MaxMs   float64 `json:"max_ms"`
+}
+
+// LiveReview is a review broadcast to the live listeners of a study session as it is recorded.
+type LiveReview struct {
+	StudySessionID int       `json:"study_session_id"`
+	WordID         int       `json:"word_id"`
+	Japanese       string    `json:"japanese"`
+	English        string    `json:"english"`
+	Correct        bool      `json:"correct"`
+	Skipped        bool      `json:"skipped"`
+	Attempt        int       `json:"attempt"`
+	ReviewedAt     time.Time `json:"reviewed_at"`
+}
+
 // WordReviewItem represents the review result of a word in a study session.
 type WordReviewItem struct {
 	WordID         int       `json:"word_id"`
@@ -50,3 +559,273 @@ type WordReviewItem struct {
 	Correct        bool      `json:"correct"`
 	CreatedAt      time.Time `json:"created_at"`
 }
+
+// ReviewRecord is a word review joined with the word it reviewed, as returned by the reviews listing.
+type ReviewRecord struct {
+	ID             int       `json:"id"`
+	WordID         int       `json:"word_id"`
+	Japanese       string    `json:"japanese"`
+	English        string    `json:"english"`
+	StudySessionID int       `json:"study_session_id"`
+	GroupID        int       `json:"group_id"`
+	Correct        bool      `json:"correct"`
+	Skipped        bool      `json:"skipped"`
+	Attempt        int       `json:"attempt"`
+	Answer         *string   `json:"answer"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// WordHistoryPoint is one review of a word in its history, with the accuracy of the word's answered
+// (not skipped) reviews up to and including this one.
+type WordHistoryPoint struct {
+	ReviewID        int       `json:"review_id"`
+	StudySessionID  int       `json:"study_session_id"`
+	Correct         bool      `json:"correct"`
+	Skipped         bool      `json:"skipped"`
+	CreatedAt       time.Time `json:"created_at"`
+	RunningAccuracy float64   `json:"running_accuracy"`
+}
+
+// ReviewFilter narrows the reviews listing. Zero values and nil pointers mean "no filter".
+// Correct false matches the wrong answers, not the skipped reviews.
+type ReviewFilter struct {
+	From    time.Time
+	To      time.Time
+	WordID  int
+	GroupID int
+	Correct *bool
+	Skipped *bool
+}
+
+// MistakeFilter narrows the mistakes log. Zero values mean "no filter". GroupID matches the
+// mistakes made in sessions for that group.
+type MistakeFilter struct {
+	From    time.Time
+	To      time.Time
+	GroupID int
+}
+
+// Mistake is a wrong answer in the mistakes log, with Misses counting the wrong answers to its word
+// in the log's range. Listed by frequency there is one Mistake per word: ReviewID, StudySessionID
+// and MissedAt are those of its latest wrong answer and Answer is the latest answer text recorded.
+// Listed by recency there is one per wrong answer, with the answer text given then.
+type Mistake struct {
+	Word
+	Misses         int       `json:"misses"`
+	ReviewID       int       `json:"review_id"`
+	StudySessionID int       `json:"study_session_id"`
+	MissedAt       time.Time `json:"missed_at"`
+	Answer         *string   `json:"answer"`
+}
+
+// RetentionBucket reports review accuracy for reviews that came a given number of days after
+// the previous review of the same word. MaxDays is nil for the open-ended last bucket.
+// Accuracy is nil when the bucket has no reviews, and InsufficientData is set when it has
+// fewer reviews than the requested minimum sample size.
+type RetentionBucket struct {
+	Label            string   `json:"label"`
+	MinDays          float64  `json:"min_days"`
+	MaxDays          *float64 `json:"max_days"`
+	Reviews          int      `json:"reviews"`
+	Correct          int      `json:"correct"`
+	Accuracy         *float64 `json:"accuracy"`
+	InsufficientData bool     `json:"insufficient_data"`
+}
+
+// StaleGroup is a group that has not been studied recently. LastStudiedAt and DaysSinceStudied are
+// nil for groups never studied. MasteryPercent is the share of its words that are mastered, 0 to 100.
+type StaleGroup struct {
+	GroupID          int        `json:"group_id"`
+	GroupName        string     `json:"group_name"`
+	LastStudiedAt    *time.Time `json:"last_studied_at"`
+	DaysSinceStudied *int       `json:"days_since_studied"`
+	TotalWords       int        `json:"total_words"`
+	MasteredWords    int        `json:"mastered_words"`
+	MasteryPercent   float64    `json:"mastery_percent"`
+}
+
+// JLPTLevelProgress is the number of words of a JLPT level ("N5" to "N1", or "uncategorized" for
+// words without a level) and how many of them are mastered.
+type JLPTLevelProgress struct {
+	Level         string `json:"level"`
+	TotalWords    int    `json:"total_words"`
+	MasteredWords int    `json:"mastered_words"`
+}
+
+// GroupRecommendation is a group suggested for the next study session, with the main reason it
+// was picked. Groups with a higher Score are recommended first.
+type GroupRecommendation struct {
+	GroupID   int     `json:"group_id"`
+	GroupName string  `json:"group_name"`
+	Reason    string  `json:"reason"`
+	Score     float64 `json:"score"`
+}
+
+// VelocityWeek is the number of words that reached mastery in the week from WeekStart up to WeekEnd.
+type VelocityWeek struct {
+	WeekStart time.Time `json:"week_start"`
+	WeekEnd   time.Time `json:"week_end"`
+	Mastered  int       `json:"mastered"`
+}
+
+// UpcomingDay is the number of words whose next review falls on Date (YYYY-MM-DD, in the configured timezone).
+type UpcomingDay struct {
+	Date  string `json:"date"`
+	Words int    `json:"words"`
+}
+
+// UpcomingReviews forecasts the review load: Due counts the words already due, and Days the words
+// that become due later on each of the coming days, starting today.
+type UpcomingReviews struct {
+	Due  int           `json:"due"`
+	Days []UpcomingDay `json:"days"`
+}
+
+// Digest summarizes one day of study, a calendar day of the configured timezone, for notifiers.
+// Accuracy is the percentage of correct reviews, 0 without reviews. StreakDays counts the
+// consecutive days with reviews ending with Date, and DueTomorrow the words due by the end of the
+// next day. Text renders the digest as plain text.
+type Digest struct {
+	Date          string  `json:"date"`
+	Timezone      string  `json:"timezone"`
+	Reviews       int     `json:"reviews"`
+	CorrectCount  int     `json:"correct_count"`
+	Accuracy      float64 `json:"accuracy"`
+	NewWords      int     `json:"new_words"`
+	WordsMastered int     `json:"words_mastered"`
+	StreakDays    int     `json:"streak_days"`
+	DueTomorrow   int     `json:"due_tomorrow"`
+	Text          string  `json:"text"`
+}
+
+// ReminderSettings configures the study reminder. TimeOfDay is an HH:MM time and Days lists the
+// weekdays (mon to sun) of the configured timezone on which the reminder is sent to WebhookURL,
+// unless DailyGoal reviews were already made that day. LastFiredDate is the date of the last
+// reminder sent. Timezone names the configured timezone and cannot be changed through the settings.
+type ReminderSettings struct {
+	Enabled       bool     `json:"enabled"`
+	TimeOfDay     string   `json:"time_of_day"`
+	Days          []string `json:"days"`
+	WebhookURL    string   `json:"webhook_url"`
+	DailyGoal     int      `json:"daily_goal"`
+	LastFiredDate *string  `json:"last_fired_date"`
+	Timezone      string   `json:"timezone"`
+}
+
+// GoalProgress is the progress towards the daily review goal on Date, a day of Timezone.
+type GoalProgress struct {
+	Date      string `json:"date"`
+	Timezone  string `json:"timezone"`
+	Goal      int    `json:"goal"`
+	Reviews   int    `json:"reviews"`
+	Remaining int    `json:"remaining"`
+	Met       bool   `json:"met"`
+}
+
+// SessionCleanupResult reports a stale session cleanup: the sessions created before Cutoff without
+// reviews that were never ended, and whether they were marked abandoned or deleted. A dry run only
+// lists them.
+type SessionCleanupResult struct {
+	Action     string    `json:"action"`
+	DryRun     bool      `json:"dry_run"`
+	Cutoff     time.Time `json:"cutoff"`
+	Sessions   int       `json:"sessions"`
+	SessionIDs []int     `json:"session_ids"`
+}
+
+// OptimizeResult reports the size of the database file before and after an optimization.
+type OptimizeResult struct {
+	SizeBeforeBytes int64 `json:"size_before_bytes"`
+	SizeAfterBytes  int64 `json:"size_after_bytes"`
+	ReclaimedBytes  int64 `json:"reclaimed_bytes"`
+	DurationMS      int64 `json:"duration_ms"`
+}
+
+// VersionInfo identifies the running server and the schema of its database. Database is only
+// given to admins.
+type VersionInfo struct {
+	Version         string        `json:"version"`
+	Commit          string        `json:"commit"`
+	GoVersion       string        `json:"go_version"`
+	Migrations      int           `json:"migrations"`
+	LatestMigration *string       `json:"latest_migration"`
+	Database        *DatabaseInfo `json:"database,omitempty"`
+}
+
+// DatabaseInfo describes the database file.
+type DatabaseInfo struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// ActivityStats summarizes the study sessions run with one activity type. Accuracy is nil when no
+// reviews were made, and AverageSessionSeconds when no session has a measurable length.
+type ActivityStats struct {
+	Activity              string   `json:"activity"`
+	TotalSessions         int      `json:"total_sessions"`
+	TotalReviews          int      `json:"total_reviews"`
+	CorrectCount          int      `json:"correct_count"`
+	Accuracy              *float64 `json:"accuracy"`
+	AverageSessionSeconds *float64 `json:"average_session_seconds"`
+}
+
+// StudyTimeWeek is the time spent in study sessions started in the week from WeekStart up to WeekEnd.
+type StudyTimeWeek struct {
+	WeekStart time.Time `json:"week_start"`
+	WeekEnd   time.Time `json:"week_end"`
+	Minutes   float64   `json:"minutes"`
+}
+
+// Event is an entry of the activity timeline. Payload is a small JSON object naming the entities
+// involved, e.g. {"word_id": 12, "japanese": "猫"}.
+type Event struct {
+	ID        int             `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Achievement is a badge the learner can earn. EarnedAt is nil until it is earned.
+type Achievement struct {
+	Key         string     `json:"key"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	EarnedAt    *time.Time `json:"earned_at"`
+}
+
+// Pagination describes where a page sits within a paginated list response.
+type Pagination struct {
+	CurrentPage  int `json:"current_page"`
+	TotalPages   int `json:"total_pages"`
+	TotalItems   int `json:"total_items"`
+	ItemsPerPage int `json:"items_per_page"`
+}
+
+// NewPagination builds the Pagination for the given page, page size, and total item count.
+func NewPagination(page, perPage, totalItems int) Pagination {
+	totalPages := 0
+	if perPage > 0 {
+		totalPages = (totalItems + perPage - 1) / perPage
+	}
+	return Pagination{
+		CurrentPage:  page,
+		TotalPages:   totalPages,
+		TotalItems:   totalItems,
+		ItemsPerPage: perPage,
+	}
+}
+
+// User roles, from least to most privileged.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// User is an account that can sign in to the API.
+type User struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
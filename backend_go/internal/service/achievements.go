@@ -0,0 +1,204 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+// Achievement keys.
+const (
+	AchievementFirstReview    = "first_review"
+	AchievementStreak7        = "streak_7_days"
+	AchievementReviews100     = "reviews_100"
+	AchievementReviews500     = "reviews_500"
+	AchievementReviews1000    = "reviews_1000"
+	AchievementMastered50     = "mastered_50"
+	AchievementPerfectSession = "perfect_session"
+)
+
+// achievementDefinition names and describes an achievement.
+type achievementDefinition struct {
+	key, name, description string
+}
+
+// achievements lists every achievement in the order they are listed.
+var achievements = []achievementDefinition{
+	{AchievementFirstReview, "First steps", "Review a word for the first time"},
+	{AchievementStreak7, "Week streak", "Review words on 7 days in a row"},
+	{AchievementReviews100, "100 reviews", "Review words 100 times"},
+	{AchievementReviews500, "500 reviews", "Review words 500 times"},
+	{AchievementReviews1000, "1,000 reviews", "Review words 1,000 times"},
+	{AchievementMastered50, "50 words mastered", "Master 50 words"},
+	{AchievementPerfectSession, "Perfect session", "End a study session without a wrong answer"},
+}
+
+// reviewCountAchievements are the achievements earned by a total number of reviews.
+var reviewCountAchievements = []struct {
+	key     string
+	reviews int
+}{
+	{AchievementReviews100, 100},
+	{AchievementReviews500, 500},
+	{AchievementReviews1000, 1000},
+}
+
+// masteredAchievementWords is the number of mastered words that earns AchievementMastered50.
+const masteredAchievementWords = 50
+
+// streakAchievementDays is the number of consecutive days of reviews that earns AchievementStreak7.
+const streakAchievementDays = 7
+
+// earnedAchievements returns the keys of the achievements the learner earned so far.
+func (s *Service) earnedAchievements() (map[string]bool, error) {
+	rows, err := s.DB.Query("SELECT key FROM achievements WHERE " + s.scheduleCond(""))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	earned := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		earned[key] = true
+	}
+	return earned, rows.Err()
+}
+
+// awardAchievement records an achievement and its achievement_earned event, unless it was already
+// earned. Like recordEvent, failures are only logged.
+func (s *Service) awardAchievement(key string, payload map[string]interface{}) {
+	result, err := s.DB.Exec("INSERT OR IGNORE INTO achievements (user_id, key) VALUES (?, ?)", s.userID, key)
+	if err != nil {
+		log.Printf("Awarding achievement %s: %v", key, err)
+		return
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return
+	}
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	payload["achievement"] = key
+	s.recordEvent(EventAchievementEarned, payload)
+}
+
+// checkReviewAchievements awards the achievements a newly recorded review may have earned. Only
+// achievements not earned yet are checked, each with a cheap query: the learner's reviews are only
+// counted up to the next threshold, through the user_id index, and the streak looks up one day at a
+// time through the created_at index.
+func (s *Service) checkReviewAchievements(now time.Time) {
+	earned, err := s.earnedAchievements()
+	if err != nil {
+		log.Printf("Checking achievements: %v", err)
+		return
+	}
+	if !earned[AchievementFirstReview] {
+		s.awardAchievement(AchievementFirstReview, nil)
+	}
+
+	for _, a := range reviewCountAchievements {
+		if earned[a.key] {
+			continue
+		}
+		reviews, err := s.countUpTo("SELECT 1 FROM word_review_items WHERE "+s.userCond(""), a.reviews)
+		if err != nil {
+			log.Printf("Checking achievements: %v", err)
+			break
+		}
+		if reviews < a.reviews {
+			break
+		}
+		s.awardAchievement(a.key, map[string]interface{}{"reviews": reviews})
+	}
+
+	if !earned[AchievementStreak7] {
+		days, err := s.reviewStreak(now, streakAchievementDays)
+		if err != nil {
+			log.Printf("Checking achievements: %v", err)
+			return
+		}
+		if days >= streakAchievementDays {
+			s.awardAchievement(AchievementStreak7, map[string]interface{}{"days": streakAchievementDays})
+		}
+	}
+}
+
+// countUpTo counts the rows selected by query, stopping at limit.
+func (s *Service) countUpTo(query string, limit int, args ...interface{}) (int, error) {
+	var n int
+	err := s.DB.QueryRow("SELECT COUNT(*) FROM ("+query+" LIMIT ?)", append(args, limit)...).Scan(&n)
+	return n, err
+}
+
+// reviewStreak counts the consecutive calendar days, in the configured timezone, with at least one
+// review, ending with the day of now. It looks up one day at a time through the created_at index and
+// stops counting at max.
+func (s *Service) reviewStreak(now time.Time, max int) (int, error) {
+	loc := s.Location()
+	today := now.In(loc)
+	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	for i := 0; i < max; i++ {
+		from, to := start.AddDate(0, 0, -i), start.AddDate(0, 0, 1-i)
+		var exists bool
+		if err := s.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM word_review_items WHERE created_at >= ? AND created_at < ? AND "+s.userCond("")+")",
+			from.UTC().Format(sqliteTimeFormat), to.UTC().Format(sqliteTimeFormat)).Scan(&exists); err != nil {
+			return 0, err
+		}
+		if !exists {
+			return i, nil
+		}
+	}
+	return max, nil
+}
+
+// checkPerfectSession awards AchievementPerfectSession when the study session that just ended has
+// reviews and none of them is wrong. Skipped reviews are not wrong.
+func (s *Service) checkPerfectSession(sessionID, reviews int) {
+	if reviews == 0 {
+		return
+	}
+	var wrong int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM word_review_items WHERE study_session_id = ? AND NOT correct AND NOT skipped", sessionID).Scan(&wrong); err != nil {
+		log.Printf("Checking achievements: %v", err)
+		return
+	}
+	if wrong == 0 {
+		s.awardAchievement(AchievementPerfectSession, map[string]interface{}{"study_session_id": sessionID, "reviews": reviews})
+	}
+}
+
+// ListAchievements returns every achievement, with the time the learner earned it or nil if they
+// did not.
+func (s *Service) ListAchievements() ([]models.Achievement, error) {
+	rows, err := s.DB.Query("SELECT key, earned_at FROM achievements WHERE " + s.scheduleCond(""))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	earnedAt := make(map[string]time.Time)
+	for rows.Next() {
+		var key string
+		var at time.Time
+		if err := rows.Scan(&key, &at); err != nil {
+			return nil, err
+		}
+		earnedAt[key] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := make([]models.Achievement, 0, len(achievements))
+	for _, def := range achievements {
+		a := models.Achievement{Key: def.key, Name: def.name, Description: def.description}
+		if at, ok := earnedAt[def.key]; ok {
+			a.EarnedAt = &at
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
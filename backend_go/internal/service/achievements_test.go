@@ -0,0 +1,123 @@
+package service_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"backend_go/internal/service"
+	"backend_go/internal/testutil"
+)
+
+// earned returns the keys of the achievements svc earned.
+func earned(t *testing.T, svc *service.Service) map[string]bool {
+	t.Helper()
+	list, err := svc.ListAchievements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[string]bool)
+	for _, a := range list {
+		if a.EarnedAt != nil {
+			keys[a.Key] = true
+		}
+	}
+	return keys
+}
+
+// milestones returns the mastered word counts of the milestone events, oldest first.
+func milestones(t *testing.T, svc *service.Service) []int {
+	t.Helper()
+	events, _, err := svc.ListEvents([]string{service.EventMilestoneReached}, 1, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var counts []int
+	for i := len(events) - 1; i >= 0; i-- {
+		var payload struct {
+			MasteredWords int `json:"mastered_words"`
+		}
+		if err := json.Unmarshal(events[i].Payload, &payload); err != nil {
+			t.Fatal(err)
+		}
+		counts = append(counts, payload.MasteredWords)
+	}
+	return counts
+}
+
+func TestReviewCountAchievements(t *testing.T) {
+	svc := testutil.NewService(t)
+	f := testutil.NewFixture(t, svc.DB).Group("N5").Word("水", "mizu", "water").Session()
+	for i := 0; i < 98; i++ {
+		f.Review(false)
+	}
+
+	// The 99th review earns the first review only, the 100th reviews_100, once
+	for attempt := 99; attempt <= 101; attempt++ {
+		if _, err := svc.ReviewWord(f.SessionID(), f.WordID("水"), false, false, attempt, "", ""); err != nil {
+			t.Fatal(err)
+		}
+		if got := earned(t, svc); got[service.AchievementReviews100] != (attempt >= 100) || !got[service.AchievementFirstReview] || got[service.AchievementReviews500] {
+			t.Errorf("after review %d earned %v", attempt, got)
+		}
+	}
+	events, total, err := svc.ListEvents([]string{service.EventAchievementEarned}, 1, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || string(events[0].Payload) != `{"achievement":"reviews_100","reviews":100}` {
+		t.Errorf("%d achievement events, the last %s, want reviews_100 after first_review", total, events[0].Payload)
+	}
+}
+
+func TestMasteryMilestones(t *testing.T) {
+	svc := testutil.NewService(t)
+	f := testutil.NewFixture(t, svc.DB).Group("N5").Session()
+	for _, japanese := range []string{"一", "二", "三", "四", "五", "六", "七", "八", "九", "十"} {
+		f.Word(japanese, "kazu", "number")
+	}
+	master := func(japanese string) {
+		t.Helper()
+		for attempt := 1; attempt <= service.MasteryCorrectReviews+1; attempt++ {
+			if _, err := svc.ReviewWord(f.SessionID(), f.WordID(japanese), true, false, attempt, "", ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, japanese := range []string{"一", "二", "三", "四", "五", "六", "七", "八", "九"} {
+		master(japanese)
+	}
+	if got := milestones(t, svc); len(got) != 0 {
+		t.Errorf("milestones %v after 9 mastered words, want none", got)
+	}
+	// Reviewing a mastered word again records the milestone once
+	master("十")
+	master("十")
+	if got := milestones(t, svc); len(got) != 1 || got[0] != 10 {
+		t.Errorf("milestones %v after 10 mastered words, want [10]", got)
+	}
+
+	// A milestone passed without a check is recorded when the next word is mastered
+	for i := 0; i < 40; i++ {
+		f.Word("語"+string(rune('a'+i)), "go", "word").Review(true).Review(true).Review(true)
+	}
+	f.Word("百", "hyaku", "hundred")
+	master("百")
+	if got := milestones(t, svc); len(got) != 2 || got[1] != 50 {
+		t.Errorf("milestones %v after 51 mastered words, want [10 50]", got)
+	}
+	if !earned(t, svc)[service.AchievementMastered50] {
+		t.Errorf("mastered_50 not earned after 51 mastered words")
+	}
+
+	// Resetting the history lets the learner reach the milestones again
+	if err := svc.ResetHistory(); err != nil {
+		t.Fatal(err)
+	}
+	for _, japanese := range []string{"一", "二", "三", "四", "五", "六", "七", "八", "九", "十"} {
+		master(japanese)
+	}
+	if got := milestones(t, svc); len(got) != 3 || got[2] != 10 {
+		t.Errorf("milestones %v after the reset and 10 mastered words, want [10 50 10]", got)
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mattn/go-sqlite3"
+	"golang.org/x/crypto/bcrypt"
+
+	"backend_go/internal/models"
+)
+
+var (
+	// ErrInvalidCredentials is returned by Login when the username or password is wrong.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrInvalidToken is returned for access or refresh tokens that are malformed, expired or revoked.
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrDuplicateUsername is returned by CreateUser when the username is taken.
+	ErrDuplicateUsername = errors.New("a user with this username already exists")
+)
+
+// dummyPasswordHash is compared against when a login names an unknown user, so that
+// unknown usernames take as long to reject as wrong passwords.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// AuthConfig configures JWT login.
+type AuthConfig struct {
+	// Secret signs access tokens with HMAC-SHA256.
+	Secret []byte
+	// AccessTTL is how long an access token stays valid.
+	AccessTTL time.Duration
+	// RefreshTTL is how long a refresh token stays valid.
+	RefreshTTL time.Duration
+}
+
+// TokenPair is the result of a successful login or refresh.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// accessClaims are the claims carried by an access token.
+type accessClaims struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// EnableAuth turns on JWT login with the given configuration.
+func (s *Service) EnableAuth(cfg AuthConfig) {
+	s.auth = &cfg
+}
+
+// AuthEnabled reports whether JWT login has been enabled.
+func (s *Service) AuthEnabled() bool {
+	return s.auth != nil
+}
+
+// CreateUser creates an account with a bcrypt hash of password and returns its ID, or
+// ErrDuplicateUsername when the username is taken.
+func (s *Service) CreateUser(username, password, role string) (int, error) {
+	if role != models.RoleViewer && role != models.RoleEditor && role != models.RoleAdmin {
+		return 0, fmt.Errorf("unknown role %q", role)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+	result, err := s.DB.Exec("INSERT INTO users (username, password_hash, role) VALUES (?, ?, ?)", username, string(hash), role)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, ErrDuplicateUsername
+		}
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// EnsureAdminUser creates an admin account with the given credentials if no accounts exist yet.
+func (s *Service) EnsureAdminUser(username, password string) error {
+	var count int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if _, err := s.CreateUser(username, password, models.RoleAdmin); err != nil {
+		return err
+	}
+	log.Printf("Created initial admin user %q", username)
+	return nil
+}
+
+// Login checks the username and password and issues a new token pair for the user.
+func (s *Service) Login(username, password string) (*TokenPair, *models.User, error) {
+	var user models.User
+	var hash string
+	err := s.DB.QueryRow("SELECT id, username, role, created_at, password_hash FROM users WHERE username = ?", username).
+		Scan(&user.ID, &user.Username, &user.Role, &user.CreatedAt, &hash)
+	if err == sql.ErrNoRows {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, nil, ErrInvalidCredentials
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	tokens, err := s.issueTokens(&user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, &user, nil
+}
+
+// RefreshTokens exchanges a valid refresh token for a new token pair. The presented refresh
+// token is revoked, so each refresh token can be used only once.
+func (s *Service) RefreshTokens(refreshToken string) (*TokenPair, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var tokenID int
+	var expiresAt time.Time
+	var revokedAt sql.NullTime
+	var user models.User
+	err = tx.QueryRow(`SELECT rt.id, rt.expires_at, rt.revoked_at, u.id, u.username, u.role, u.created_at
+	                   FROM refresh_tokens rt
+	                   JOIN users u ON rt.user_id = u.id
+	                   WHERE rt.token_hash = ?`, hashToken(refreshToken)).
+		Scan(&tokenID, &expiresAt, &revokedAt, &user.ID, &user.Username, &user.Role, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid || time.Now().After(expiresAt) {
+		return nil, ErrInvalidToken
+	}
+	if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE id = ?", time.Now().UTC(), tokenID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(&user)
+}
+
+// Logout revokes the given refresh token. Revoking an unknown or already revoked token is not an error.
+func (s *Service) Logout(refreshToken string) error {
+	_, err := s.DB.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE token_hash = ? AND revoked_at IS NULL", time.Now().UTC(), hashToken(refreshToken))
+	return err
+}
+
+// ParseAccessToken validates an access token and returns the user it was issued to.
+func (s *Service) ParseAccessToken(token string) (*models.User, error) {
+	if s.auth == nil {
+		return nil, ErrInvalidToken
+	}
+	var claims accessClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return s.auth.Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &models.User{ID: id, Username: claims.Username, Role: claims.Role}, nil
+}
+
+// issueTokens signs a new access token for user and stores a new refresh token for them.
+func (s *Service) issueTokens(user *models.User) (*TokenPair, error) {
+	if s.auth == nil {
+		return nil, errors.New("authentication is not enabled")
+	}
+	now := time.Now()
+	claims := accessClaims{
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.auth.AccessTTL)),
+		},
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.auth.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(b)
+	if _, err := s.DB.Exec("INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES (?, ?, ?)",
+		user.ID, hashToken(refreshToken), now.Add(s.auth.RefreshTTL).UTC()); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.auth.AccessTTL.Seconds()),
+	}, nil
+}
+
+// hashToken returns the hex SHA-256 of a refresh token as stored in refresh_tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// busyRetries is the number of times a statement failing because the database is busy is retried,
+// waiting busyBackoff before the first retry and twice as long before each next one.
+const (
+	busyRetries = 3
+	busyBackoff = 10 * time.Millisecond
+)
+
+// isBusy reports whether err is a transient SQLITE_BUSY or SQLITE_LOCKED error, which another
+// connection holding a lock causes and which clears once that lock is released.
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+// retryBusy runs fn, running it again with backoff while it fails with a busy error, up to
+// busyRetries times. It stops early, returning the last error, when ctx is done.
+func retryBusy(ctx context.Context, fn func() error) error {
+	err := fn()
+	backoff := busyBackoff
+	for i := 0; i < busyRetries && isBusy(err); i++ {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+		err = fn()
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+// Actions of the stale session cleanup, reported in SessionCleanupResult.Action.
+const (
+	SessionCleanupAbandon = "abandoned"
+	SessionCleanupDelete  = "deleted"
+)
+
+// CleanupStaleSessions ends the study sessions created before cutoff that have no reviews and were
+// never completed, flagging them abandoned, or deletes them when deleteSessions. Sessions ended by
+// CloseStaleStudySessions without reviews end at their creation and count as never completed. A
+// dry run only lists the sessions. It acts on the sessions of every learner.
+func (s *Service) CleanupStaleSessions(cutoff time.Time, deleteSessions, dryRun bool) (*models.SessionCleanupResult, error) {
+	result := &models.SessionCleanupResult{Action: SessionCleanupAbandon, DryRun: dryRun, Cutoff: cutoff.UTC(), SessionIDs: make([]int, 0)}
+	if deleteSessions {
+		result.Action = SessionCleanupDelete
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id FROM study_sessions ss
+	                       WHERE NOT abandoned AND created_at < ? AND (ended_at IS NULL OR ended_at = created_at)
+	                         AND NOT EXISTS (SELECT 1 FROM word_review_items wr WHERE wr.study_session_id = ss.id)
+	                       ORDER BY id`, cutoff.UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.SessionIDs = append(result.SessionIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result.Sessions = len(result.SessionIDs)
+	if dryRun || result.Sessions == 0 {
+		return result, nil
+	}
+
+	placeholders, args := inPlaceholders(result.SessionIDs)
+	statements := []string{"UPDATE study_sessions SET abandoned = 1, ended_at = COALESCE(ended_at, created_at) WHERE id IN (" + placeholders + ")"}
+	if deleteSessions {
+		statements = []string{
+			"DELETE FROM study_session_queue WHERE study_session_id IN (" + placeholders + ")",
+			"DELETE FROM study_sessions WHERE id IN (" + placeholders + ")",
+		}
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, args...); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RunSessionCleanup runs CleanupStaleSessions every interval on the sessions older than maxAge,
+// until ctx is done. Failures are logged and the cleanup is tried again at the next tick.
+func (s *Service) RunSessionCleanup(ctx context.Context, interval, maxAge time.Duration, deleteSessions bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		result, err := s.CleanupStaleSessions(time.Now().Add(-maxAge), deleteSessions, false)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Cleaning up stale study sessions: %v", err)
+		} else if result != nil && result.Sessions > 0 {
+			log.Printf("Stale session cleanup %s %d study sessions", result.Action, result.Sessions)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+	"backend_go/internal/testutil"
+)
+
+// staleSessions adds the sessions the cleanup tells apart around cutoff and returns the ones it
+// must clean up, in order, and the ones it must keep.
+func staleSessions(t *testing.T, svc *service.Service, cutoff time.Time) (stale, kept []int) {
+	t.Helper()
+	f := testutil.NewFixture(t, svc.DB).Group("N5").Word("水", "mizu", "water")
+	f.SessionAt(cutoff.Add(-time.Second))
+	stale = append(stale, f.SessionID())
+	f.SessionAt(cutoff)
+	kept = append(kept, f.SessionID())
+	f.SessionAt(cutoff.Add(-time.Hour)).ReviewAt(true, cutoff.Add(-time.Hour))
+	kept = append(kept, f.SessionID())
+	f.SessionAt(cutoff.Add(-time.Hour)).SkipAt(cutoff.Add(-time.Hour))
+	kept = append(kept, f.SessionID())
+	// Closed by CloseStaleStudySessions without reviews, so never completed
+	f.SessionAt(cutoff.Add(-2 * time.Hour)).End(cutoff.Add(-2 * time.Hour))
+	stale = append(stale, f.SessionID())
+	f.SessionAt(cutoff.Add(-2 * time.Hour)).End(cutoff.Add(-time.Hour))
+	kept = append(kept, f.SessionID())
+	return stale, kept
+}
+
+func listedSessions(t *testing.T, svc *service.Service, filter models.StudySessionFilter) map[int]models.StudySessionListItem {
+	t.Helper()
+	sessions, err := svc.ListStudySessions(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := make(map[int]models.StudySessionListItem, len(sessions))
+	for _, session := range sessions {
+		listed[session.ID] = session
+	}
+	return listed
+}
+
+func TestCleanupStaleSessions(t *testing.T) {
+	svc := testutil.NewService(t)
+	cutoff := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	stale, kept := staleSessions(t, svc, cutoff)
+
+	// A dry run lists the sessions and changes nothing
+	result, err := svc.CleanupStaleSessions(cutoff, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.DryRun || result.Action != service.SessionCleanupAbandon || result.Sessions != len(stale) || !reflect.DeepEqual(result.SessionIDs, stale) {
+		t.Errorf("dry run = %+v, want %s of %v", result, service.SessionCleanupAbandon, stale)
+	}
+	if listed := listedSessions(t, svc, models.StudySessionFilter{}); len(listed) != len(stale)+len(kept) {
+		t.Errorf("after the dry run %d sessions are listed, want %d", len(listed), len(stale)+len(kept))
+	}
+
+	result, err = svc.CleanupStaleSessions(cutoff, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DryRun || !reflect.DeepEqual(result.SessionIDs, stale) {
+		t.Errorf("cleanup = %+v, want %v abandoned", result, stale)
+	}
+
+	// Abandoned sessions leave the history unless asked for, ended at their creation
+	listed := listedSessions(t, svc, models.StudySessionFilter{})
+	for _, id := range stale {
+		if _, ok := listed[id]; ok {
+			t.Errorf("abandoned session %d is listed", id)
+		}
+	}
+	for _, id := range kept {
+		if session, ok := listed[id]; !ok || session.Abandoned {
+			t.Errorf("session %d = %+v, want it kept", id, session)
+		}
+	}
+	listed = listedSessions(t, svc, models.StudySessionFilter{IncludeAbandoned: true})
+	for _, id := range stale {
+		session, ok := listed[id]
+		if !ok || !session.Abandoned || session.EndedAt == nil || !session.EndedAt.Equal(session.CreatedAt) {
+			t.Errorf("session %d = %+v, want it abandoned and ended at its creation", id, session)
+		}
+	}
+
+	// Abandoned sessions are not cleaned up again
+	if result, err = svc.CleanupStaleSessions(cutoff, false, false); err != nil || result.Sessions != 0 || len(result.SessionIDs) != 0 {
+		t.Errorf("second cleanup = %+v, %v, want no sessions", result, err)
+	}
+}
+
+func TestCleanupStaleSessionsDelete(t *testing.T) {
+	svc := testutil.NewService(t)
+	cutoff := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	stale, kept := staleSessions(t, svc, cutoff)
+	for i, id := range stale {
+		if _, err := svc.DB.Exec("INSERT INTO study_session_queue (study_session_id, word_id, position) SELECT ?, id, ? FROM words", id, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := svc.CleanupStaleSessions(cutoff, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Action != service.SessionCleanupDelete || !reflect.DeepEqual(result.SessionIDs, stale) {
+		t.Errorf("cleanup = %+v, want %v deleted", result, stale)
+	}
+	listed := listedSessions(t, svc, models.StudySessionFilter{IncludeAbandoned: true})
+	if len(listed) != len(kept) {
+		t.Errorf("%d sessions are left, want %d", len(listed), len(kept))
+	}
+	for _, id := range kept {
+		if _, ok := listed[id]; !ok {
+			t.Errorf("session %d was deleted", id)
+		}
+	}
+	var queued int
+	if err := svc.DB.QueryRow("SELECT COUNT(*) FROM study_session_queue").Scan(&queued); err != nil || queued != 0 {
+		t.Errorf("%d queued words are left, %v, want none", queued, err)
+	}
+}
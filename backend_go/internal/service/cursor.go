@@ -0,0 +1,143 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cursorTTL is how long the next_cursor of a page can be used to fetch the following one.
+const cursorTTL = 24 * time.Hour
+
+// ErrInvalidCursor is returned for a pagination cursor that cannot be decoded, was issued by
+// another listing or for another order, or has expired.
+var ErrInvalidCursor = errors.New("invalid or expired cursor")
+
+// keyset is the order of a listing paginated by cursor: the listing's name and its sort keys,
+// the last of which must be unique, such as the row ID, so that every row has its own position.
+type keyset struct {
+	listing string
+	keys    []keysetKey
+}
+
+// keysetKey is one sort key of a keyset, an SQL expression sorted ascending unless desc, whose
+// values are compared as integers when integer and as text otherwise. Expressions must not be
+// NULL, wrap nullable columns in COALESCE.
+type keysetKey struct {
+	expr    string
+	desc    bool
+	integer bool
+}
+
+// cursorToken is the decoded form of a cursor: the listing and order it was issued for, the sort
+// key values of the last row of its page, as text, and its expiry as a Unix time.
+type cursorToken struct {
+	Listing string   `json:"l"`
+	Order   string   `json:"o"`
+	Values  []string `json:"v"`
+	Expires int64    `json:"e"`
+}
+
+// order describes the sort keys, so that a cursor issued for one order is refused for another.
+func (k keyset) order() string {
+	keys := make([]string, len(k.keys))
+	for i, key := range k.keys {
+		keys[i] = key.expr
+		if key.desc {
+			keys[i] += " DESC"
+		}
+	}
+	return strings.Join(keys, ", ")
+}
+
+// orderBy builds the ORDER BY clause of the keyset.
+func (k keyset) orderBy() string {
+	return " ORDER BY " + k.order()
+}
+
+// columns returns the sort keys as text, to be selected after the row's own columns and scanned
+// into the values of the next cursor.
+func (k keyset) columns() string {
+	var b strings.Builder
+	for _, key := range k.keys {
+		b.WriteString(", CAST(" + key.expr + " AS TEXT)")
+	}
+	return b.String()
+}
+
+// scanValues returns the values of the sort keys of a row and the destinations to scan them into,
+// following the row's own columns.
+func (k keyset) scanValues() ([]string, []interface{}) {
+	values := make([]string, len(k.keys))
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	return values, dest
+}
+
+// after returns the condition that keeps the rows sorting after the row whose sort keys are
+// values, as returned by decode, and its arguments. It is empty when values is nil.
+func (k keyset) after(values []interface{}) (string, []interface{}) {
+	if values == nil {
+		return "", nil
+	}
+	var or []string
+	var args []interface{}
+	for i, key := range k.keys {
+		var and []string
+		for j := 0; j < i; j++ {
+			and = append(and, k.keys[j].expr+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if key.desc {
+			op = " < ?"
+		}
+		and = append(and, key.expr+op)
+		args = append(args, values[i])
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	return "(" + strings.Join(or, " OR ") + ")", args
+}
+
+// encode returns the cursor of the page ending with the row whose sort keys are values.
+func (k keyset) encode(values []string, now time.Time) *string {
+	b, _ := json.Marshal(cursorToken{Listing: k.listing, Order: k.order(), Values: values, Expires: now.Add(cursorTTL).Unix()})
+	cursor := base64.RawURLEncoding.EncodeToString(b)
+	return &cursor
+}
+
+// decode returns the sort key values of cursor, nil for the empty cursor of the first page, or
+// ErrInvalidCursor.
+func (k keyset) decode(cursor string, now time.Time) ([]interface{}, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var token cursorToken
+	if err := json.Unmarshal(b, &token); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if token.Listing != k.listing || token.Order != k.order() || len(token.Values) != len(k.keys) || now.Unix() > token.Expires {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]interface{}, len(token.Values))
+	for i, v := range token.Values {
+		values[i] = v
+		if k.keys[i].integer {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = n
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKeysetCursor(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	words := WordSort{{Field: "romaji", Desc: true}}.keyset()
+
+	cursor := words.encode([]string{"mizu", "42"}, now)
+	values, err := words.decode(*cursor, now.Add(cursorTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"mizu", int64(42)}; !reflect.DeepEqual(values, want) {
+		t.Errorf("decoded %v, want %v", values, want)
+	}
+	if values, err := words.decode("", now); values != nil || err != nil {
+		t.Errorf("empty cursor decoded to %v, %v, want the first page", values, err)
+	}
+
+	tampered := []byte(*cursor)
+	tampered[len(tampered)/2] ^= 1
+	invalid := map[string]struct {
+		ks     keyset
+		cursor string
+		now    time.Time
+	}{
+		"expired":          {words, *cursor, now.Add(cursorTTL + time.Second)},
+		"other order":      {WordSort{{Field: "romaji"}}.keyset(), *cursor, now},
+		"other listing":    {reviewsKeyset, *cursor, now},
+		"not base64":       {words, "not a cursor!", now},
+		"tampered":         {words, string(tampered), now},
+		"not JSON":         {words, base64.RawURLEncoding.EncodeToString([]byte("mizu,42")), now},
+		"too few values":   {words, *words.encode([]string{"mizu"}, now), now},
+		"non-integer ID":   {words, *words.encode([]string{"mizu", "4x"}, now), now},
+		"sessions by word": {studySessionsKeyset, *WordSort{{Field: "id"}}.keyset().encode([]string{"1"}, now), now},
+	}
+	for name, tt := range invalid {
+		if _, err := tt.ks.decode(tt.cursor, tt.now); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: error %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
+
+func TestKeysetAfter(t *testing.T) {
+	if cond, args := reviewsKeyset.after(nil); cond != "" || args != nil {
+		t.Errorf("after the first page: %q %v, want no condition", cond, args)
+	}
+	cond, args := reviewsKeyset.after([]interface{}{"2024-01-01 00:00:00", int64(7)})
+	if want := "((wr.created_at < ?) OR (wr.created_at = ? AND wr.id < ?))"; cond != want {
+		t.Errorf("condition %q, want %q", cond, want)
+	}
+	if want := []interface{}{"2024-01-01 00:00:00", "2024-01-01 00:00:00", int64(7)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("arguments %v, want %v", args, want)
+	}
+}
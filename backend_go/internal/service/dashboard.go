@@ -0,0 +1,417 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+// retentionBounds are the lower bounds, in days, of the retention buckets. Each bucket ends
+// where the next one starts and the last bucket is open-ended.
+var retentionBounds = []float64{0, 1, 3, 7, 30}
+
+// retentionLabels names the buckets delimited by retentionBounds.
+var retentionLabels = []string{"0-1d", "1-3d", "3-7d", "7-30d", "30d+"}
+
+// GetDashboardRetention measures how recall decays with time. Every review that follows an earlier
+// review of the same word is bucketed by the gap since that previous review, and the accuracy of
+// each bucket is reported. Buckets with fewer than minSamples reviews are flagged as insufficient.
+// Skipped reviews count as previous reviews but are not bucketed.
+func (s *Service) GetDashboardRetention(minSamples int) ([]models.RetentionBucket, error) {
+	query := `WITH ordered AS (
+	              SELECT correct, skipped,
+	                     julianday(created_at) - julianday(LAG(created_at) OVER (PARTITION BY word_id ORDER BY created_at, id)) AS gap_days
+	              FROM word_review_items
+	              WHERE ` + s.userCond("") + `
+	          )
+	          SELECT CASE
+	                     WHEN gap_days < 1 THEN 0
+	                     WHEN gap_days < 3 THEN 1
+	                     WHEN gap_days < 7 THEN 2
+	                     WHEN gap_days < 30 THEN 3
+	                     ELSE 4
+	                 END AS bucket,
+	                 COUNT(*),
+	                 SUM(CASE WHEN correct THEN 1 ELSE 0 END)
+	          FROM ordered
+	          WHERE gap_days IS NOT NULL AND NOT skipped
+	          GROUP BY bucket`
+	rows, err := s.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make([]models.RetentionBucket, len(retentionBounds))
+	for i := range buckets {
+		buckets[i] = models.RetentionBucket{Label: retentionLabels[i], MinDays: retentionBounds[i]}
+		if i+1 < len(retentionBounds) {
+			max := retentionBounds[i+1]
+			buckets[i].MaxDays = &max
+		}
+	}
+	for rows.Next() {
+		var bucket, reviews, correct int
+		if err := rows.Scan(&bucket, &reviews, &correct); err != nil {
+			return nil, err
+		}
+		buckets[bucket].Reviews = reviews
+		buckets[bucket].Correct = correct
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range buckets {
+		if buckets[i].Reviews > 0 {
+			accuracy := float64(buckets[i].Correct) / float64(buckets[i].Reviews)
+			buckets[i].Accuracy = &accuracy
+		}
+		buckets[i].InsufficientData = buckets[i].Reviews < minSamples
+	}
+	return buckets, nil
+}
+
+// Weights of the recommendation score. A group earns points for every word that is due for review
+// and, at a lower rate, for every word never reviewed. Time since the group was last studied adds
+// points per day up to a cap, with never-studied groups counted at the cap. Poor recent accuracy adds
+// up to recentAccuracyWeight points: a group answered 40% right in the last recentAccuracyDays days
+// earns 0.6 * recentAccuracyWeight.
+const (
+	dueWordWeight        = 3.0
+	unseenWordWeight     = 1.0
+	neglectDayWeight     = 0.5
+	maxNeglectDays       = 30.0
+	recentAccuracyWeight = 10.0
+	recentAccuracyDays   = 7
+)
+
+// recommendationInput is what the recommendation score of a group is computed from.
+// DaysSinceStudied is nil for groups that were never studied and RecentAccuracy is nil for
+// groups without reviews in the last recentAccuracyDays days.
+type recommendationInput struct {
+	DueWords         int
+	UnseenWords      int
+	DaysSinceStudied *float64
+	RecentAccuracy   *float64
+}
+
+// scoreRecommendation computes the score of a group and describes the component that contributed most.
+func scoreRecommendation(in recommendationInput) (float64, string) {
+	due := float64(in.DueWords) * dueWordWeight
+	unseen := float64(in.UnseenWords) * unseenWordWeight
+
+	neglect := maxNeglectDays * neglectDayWeight
+	neglectReason := "never studied"
+	if in.DaysSinceStudied != nil {
+		days := math.Min(*in.DaysSinceStudied, maxNeglectDays)
+		neglect = days * neglectDayWeight
+		neglectReason = fmt.Sprintf("not studied for %d days", int(*in.DaysSinceStudied))
+	}
+
+	accuracy := 0.0
+	accuracyReason := ""
+	if in.RecentAccuracy != nil {
+		accuracy = (1 - *in.RecentAccuracy) * recentAccuracyWeight
+		accuracyReason = fmt.Sprintf("%d%% recent accuracy", int(math.Round(*in.RecentAccuracy*100)))
+	}
+
+	score, reason := due, fmt.Sprintf("%d words due", in.DueWords)
+	for _, c := range []struct {
+		points float64
+		reason string
+	}{
+		{unseen, fmt.Sprintf("%d new words", in.UnseenWords)},
+		{neglect, neglectReason},
+		{accuracy, accuracyReason},
+	} {
+		if c.points > score {
+			score, reason = c.points, c.reason
+		}
+	}
+	if score == 0 {
+		reason = "nothing due"
+	}
+	return due + unseen + neglect + accuracy, reason
+}
+
+// GetRecommendations ranks the groups that have words by how much they need studying at time now
+// (see scoreRecommendation) and returns the top limit. Archived groups are left out unless
+// includeArchived.
+func (s *Service) GetRecommendations(now time.Time, limit int, includeArchived bool) ([]models.GroupRecommendation, error) {
+	nowStr := now.UTC().Format(sqliteTimeFormat)
+	since := now.UTC().AddDate(0, 0, -recentAccuracyDays).Format(sqliteTimeFormat)
+	query := `SELECT g.id, g.name,
+	                 SUM(CASE WHEN srs.next_review_at <= ? THEN 1 ELSE 0 END),
+	                 SUM(CASE WHEN srs.word_id IS NULL THEN 1 ELSE 0 END),
+	                 julianday(?) - julianday(MAX(ls.last_studied_at)),
+	                 (SELECT AVG(CASE WHEN r.correct THEN 1.0 ELSE 0.0 END)
+	                  FROM word_review_items r
+	                  JOIN word_groups rwg ON rwg.word_id = r.word_id
+	                  WHERE rwg.group_id = g.id AND r.created_at >= ? AND NOT r.skipped AND ` + s.userCond("r") + `)
+	          FROM groups g
+	          JOIN word_groups wg ON wg.group_id = g.id
+	          JOIN words w ON w.id = wg.word_id AND w.deleted_at IS NULL
+	          LEFT JOIN word_srs srs ON srs.word_id = w.id AND ` + s.scheduleCond("srs") + `
+	          JOIN (` + s.groupLastStudiedSQL() + `) ls ON ls.group_id = g.id
+	          WHERE ? OR NOT g.archived
+	          GROUP BY g.id, g.name`
+	rows, err := s.DB.Query(query, nowStr, nowStr, since, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recommendations := make([]models.GroupRecommendation, 0)
+	for rows.Next() {
+		var rec models.GroupRecommendation
+		var in recommendationInput
+		var daysSince, accuracy sql.NullFloat64
+		if err := rows.Scan(&rec.GroupID, &rec.GroupName, &in.DueWords, &in.UnseenWords, &daysSince, &accuracy); err != nil {
+			return nil, err
+		}
+		if daysSince.Valid {
+			in.DaysSinceStudied = &daysSince.Float64
+		}
+		if accuracy.Valid {
+			in.RecentAccuracy = &accuracy.Float64
+		}
+		score, reason := scoreRecommendation(in)
+		rec.Score = math.Round(score*100) / 100
+		rec.Reason = reason
+		recommendations = append(recommendations, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].GroupID < recommendations[j].GroupID
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations, nil
+}
+
+// MasteryCorrectReviews is the number of correct reviews after which a word counts as mastered.
+const MasteryCorrectReviews = 3
+
+// masteredWordCondition returns the condition, true for a word w the learner answered correctly
+// at least MasteryCorrectReviews times.
+func (s *Service) masteredWordCondition() string {
+	return fmt.Sprintf("(SELECT COUNT(*) FROM word_review_items r WHERE r.word_id = w.id AND r.correct AND %s) >= %d",
+		s.userCond("r"), MasteryCorrectReviews)
+}
+
+// masteredWordsSQL selects the words the learner mastered that are not deleted and when each was
+// mastered (word_id, mastered_at): the time of its MasteryCorrectReviews-th correct review.
+func (s *Service) masteredWordsSQL() string {
+	return fmt.Sprintf(`SELECT word_id, created_at AS mastered_at
+FROM (SELECT r.word_id, r.created_at,
+             ROW_NUMBER() OVER (PARTITION BY r.word_id ORDER BY r.created_at, r.id) AS n
+      FROM word_review_items r
+      JOIN words w ON w.id = r.word_id AND w.deleted_at IS NULL
+      WHERE r.correct AND %s)
+WHERE n = %d`, s.userCond("r"), MasteryCorrectReviews)
+}
+
+// calendarWeeks returns the bounds of the given number of calendar weeks of the configured
+// timezone, Monday to Monday, ending with the week of now: the start of each week, oldest first,
+// followed by the end of the last one.
+func (s *Service) calendarWeeks(now time.Time, weeks int) []time.Time {
+	now = now.In(s.Location())
+	monday := time.Date(now.Year(), now.Month(), now.Day()-(int(now.Weekday())+6)%7, 0, 0, 0, 0, now.Location())
+	bounds := make([]time.Time, weeks+1)
+	for i := range bounds {
+		bounds[i] = monday.AddDate(0, 0, 7*(i-weeks+1))
+	}
+	return bounds
+}
+
+// weekOf returns the index of the week of bounds (see calendarWeeks) that contains t, or -1.
+func weekOf(bounds []time.Time, t time.Time) int {
+	i := sort.Search(len(bounds), func(i int) bool { return bounds[i].After(t) }) - 1
+	if i >= len(bounds)-1 {
+		return -1
+	}
+	return i
+}
+
+// GetDashboardVelocity counts the words that were mastered in each of the given number of calendar
+// weeks ending with the week of now (see calendarWeeks), oldest week first. A word is mastered at
+// its MasteryCorrectReviews-th correct review (see masteredWordsSQL), so every word is counted at
+// most once, in the week it crossed the threshold.
+func (s *Service) GetDashboardVelocity(now time.Time, weeks int) ([]models.VelocityWeek, error) {
+	bounds := s.calendarWeeks(now, weeks)
+	velocity := make([]models.VelocityWeek, weeks)
+	for i := range velocity {
+		velocity[i] = models.VelocityWeek{WeekStart: bounds[i], WeekEnd: bounds[i+1]}
+	}
+
+	// Week boundaries depend on the timezone rules, so the words are bucketed here rather than in SQL
+	rows, err := s.DB.Query(`SELECT datetime(mastered_at) FROM (`+s.masteredWordsSQL()+`)
+	                         WHERE mastered_at >= ? AND mastered_at < ?`,
+		bounds[0].UTC().Format(sqliteTimeFormat), bounds[weeks].UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var masteredAt string
+		if err := rows.Scan(&masteredAt); err != nil {
+			return nil, err
+		}
+		at, err := time.Parse(sqliteTimeFormat, masteredAt)
+		if err != nil {
+			return nil, err
+		}
+		if i := weekOf(bounds, at); i >= 0 {
+			velocity[i].Mastered++
+		}
+	}
+	return velocity, rows.Err()
+}
+
+// GetUpcomingReviews counts the words due for review at time now and, for each of the given number
+// of days starting with today, the words whose next review falls on that day after now. Days are
+// calendar days of the configured timezone, so they may be shorter or longer than 24 hours.
+func (s *Service) GetUpcomingReviews(now time.Time, days int) (*models.UpcomingReviews, error) {
+	now = now.In(s.Location()).Truncate(time.Second)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end := today.AddDate(0, 0, days)
+
+	upcoming := &models.UpcomingReviews{Days: make([]models.UpcomingDay, days)}
+	dayIndex := make(map[string]int, days)
+	for i := range upcoming.Days {
+		date := today.AddDate(0, 0, i).Format("2006-01-02")
+		upcoming.Days[i].Date = date
+		dayIndex[date] = i
+	}
+	err := s.DB.QueryRow(`SELECT COUNT(*) FROM word_srs srs
+	                      JOIN words w ON w.id = srs.word_id AND w.deleted_at IS NULL
+	                      WHERE srs.next_review_at <= ? AND `+s.scheduleCond("srs"), now.UTC().Format(sqliteTimeFormat)).Scan(&upcoming.Due)
+	if err != nil {
+		return nil, err
+	}
+
+	// Day boundaries depend on the timezone rules, so the reviews are bucketed here rather than in SQL
+	rows, err := s.DB.Query(`SELECT srs.next_review_at FROM word_srs srs
+	                         JOIN words w ON w.id = srs.word_id AND w.deleted_at IS NULL
+	                         WHERE srs.next_review_at > ? AND srs.next_review_at < ? AND `+s.scheduleCond("srs"),
+		now.UTC().Format(sqliteTimeFormat), end.UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var next time.Time
+		if err := rows.Scan(&next); err != nil {
+			return nil, err
+		}
+		if i, ok := dayIndex[next.In(now.Location()).Format("2006-01-02")]; ok {
+			upcoming.Days[i].Words++
+		}
+	}
+	return upcoming, rows.Err()
+}
+
+// GetActivityStats compares the study activity types, identified by activity name: the sessions run
+// with each, the reviews made in them, their accuracy and the average session length, measured as
+// described at sessionStatsSQL. Activity types without sessions are listed with zero counts.
+// It returns one page of activity types by name and the number of activity types.
+func (s *Service) GetActivityStats(page, perPage int) ([]models.ActivityStats, int, error) {
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(DISTINCT name) FROM study_activities").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := `SELECT sa.name,
+	                 COUNT(ss.id),
+	                 COALESCE(SUM(ss.reviews), 0),
+	                 COALESCE(SUM(ss.correct), 0),
+	                 AVG(ss.duration_seconds)
+	          FROM (SELECT DISTINCT name FROM study_activities) sa
+	          LEFT JOIN (SELECT ss.*, a.name FROM (` + s.sessionStatsSQL() + `) ss
+	                     JOIN study_activities a ON a.id = ss.study_activity_id) ss
+	                 ON ss.name = sa.name
+	          GROUP BY sa.name
+	          ORDER BY sa.name
+	          LIMIT ? OFFSET ?`
+	rows, err := s.DB.Query(query, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	stats := make([]models.ActivityStats, 0)
+	for rows.Next() {
+		var st models.ActivityStats
+		var duration sql.NullFloat64
+		if err := rows.Scan(&st.Activity, &st.TotalSessions, &st.TotalReviews, &st.CorrectCount, &duration); err != nil {
+			return nil, 0, err
+		}
+		if st.TotalReviews > 0 {
+			accuracy := float64(st.CorrectCount) / float64(st.TotalReviews)
+			st.Accuracy = &accuracy
+		}
+		if duration.Valid {
+			seconds := math.Round(duration.Float64)
+			st.AverageSessionSeconds = &seconds
+		}
+		stats = append(stats, st)
+	}
+	return stats, total, rows.Err()
+}
+
+// uncategorizedLevel is the JLPT level reported for words without one.
+const uncategorizedLevel = "uncategorized"
+
+// GetJLPTBreakdown counts the words and the mastered words of each JLPT level, from N5 to N1, then
+// the words without a level. Every level is listed, with zero counts if it has no words.
+func (s *Service) GetJLPTBreakdown() ([]models.JLPTLevelProgress, error) {
+	rows, err := s.DB.Query(`SELECT w.jlpt_level, COUNT(*),
+	                                SUM(CASE WHEN ` + s.masteredWordCondition() + ` THEN 1 ELSE 0 END)
+	                         FROM words w
+	                         WHERE w.deleted_at IS NULL
+	                         GROUP BY w.jlpt_level`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	breakdown := make([]models.JLPTLevelProgress, 0, 6)
+	index := make(map[string]int)
+	for level := 5; level >= 1; level-- {
+		index[fmt.Sprintf("N%d", level)] = len(breakdown)
+		breakdown = append(breakdown, models.JLPTLevelProgress{Level: fmt.Sprintf("N%d", level)})
+	}
+	index[uncategorizedLevel] = len(breakdown)
+	breakdown = append(breakdown, models.JLPTLevelProgress{Level: uncategorizedLevel})
+
+	for rows.Next() {
+		var level sql.NullInt64
+		var total, mastered int
+		if err := rows.Scan(&level, &total, &mastered); err != nil {
+			return nil, err
+		}
+		name := uncategorizedLevel
+		if level.Valid {
+			name = fmt.Sprintf("N%d", level.Int64)
+		}
+		// Levels outside N5 to N1 count as uncategorized
+		i, ok := index[name]
+		if !ok {
+			i = index[uncategorizedLevel]
+		}
+		breakdown[i].TotalWords += total
+		breakdown[i].MasteredWords += mastered
+	}
+	return breakdown, rows.Err()
+}
@@ -0,0 +1,237 @@
+package service_test
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"backend_go/internal/testutil"
+)
+
+// day is the length of a day in the synthetic review histories.
+const day = 24 * time.Hour
+
+func TestGetDashboardRetention(t *testing.T) {
+	svc := testutil.NewService(t)
+	start := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	testutil.NewFixture(t, svc.DB).
+		Group("N5").SessionAt(start).
+		// 水: gaps of 12h (right), 4.8h (wrong), 2d (wrong) and 5d (right)
+		Word("水", "mizu", "water").
+		ReviewAt(true, start).
+		ReviewAt(true, start.Add(12*time.Hour)).
+		ReviewAt(false, start.Add(12*time.Hour+day/5)).
+		ReviewAt(false, start.Add(12*time.Hour+day/5+2*day)).
+		ReviewAt(true, start.Add(12*time.Hour+day/5+7*day)).
+		// 火: gaps of 10d (right), 1d (skipped, not bucketed) and 39d (wrong)
+		Word("火", "hi", "fire").
+		ReviewAt(false, start).
+		ReviewAt(true, start.Add(10*day)).
+		SkipAt(start.Add(11*day)).
+		ReviewAt(false, start.Add(50*day))
+
+	buckets, err := svc.GetDashboardRetention(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		label            string
+		reviews, correct int
+		accuracy         float64
+		insufficient     bool
+	}{
+		{"0-1d", 2, 1, 0.5, false},
+		{"1-3d", 1, 0, 0, true},
+		{"3-7d", 1, 1, 1, true},
+		{"7-30d", 1, 1, 1, true},
+		{"30d+", 1, 0, 0, true},
+	}
+	if len(buckets) != len(want) {
+		t.Fatalf("got %d buckets, want %d", len(buckets), len(want))
+	}
+	for i, w := range want {
+		b := buckets[i]
+		if b.Label != w.label || b.Reviews != w.reviews || b.Correct != w.correct || b.InsufficientData != w.insufficient {
+			t.Errorf("bucket %d = %+v, want %+v", i, b, w)
+			continue
+		}
+		if b.Accuracy == nil || *b.Accuracy != w.accuracy {
+			t.Errorf("bucket %s accuracy = %v, want %v", b.Label, b.Accuracy, w.accuracy)
+		}
+	}
+	if buckets[4].MaxDays != nil || buckets[0].MaxDays == nil || *buckets[0].MaxDays != 1 {
+		t.Errorf("bucket bounds = %v and %v, want 1 and open", buckets[0].MaxDays, buckets[4].MaxDays)
+	}
+}
+
+func TestGetDashboardRetentionEmpty(t *testing.T) {
+	svc := testutil.NewService(t)
+	testutil.NewFixture(t, svc.DB).Group("N5").Word("水", "mizu", "water").Session().Review(true)
+
+	buckets, err := svc.GetDashboardRetention(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range buckets {
+		if b.Reviews != 0 || b.Accuracy != nil || !b.InsufficientData {
+			t.Errorf("bucket %+v, want it empty and flagged: a first review has no gap", b)
+		}
+	}
+}
+
+// schedule makes the word with ID wordID due for review at next, as if it was last reviewed a
+// day before.
+func schedule(t *testing.T, db *sql.DB, wordID int, next time.Time) {
+	t.Helper()
+	if _, err := db.Exec(`INSERT INTO word_srs (user_id, word_id, repetitions, interval_days, last_reviewed_at, next_review_at)
+	                      VALUES (0, ?, 1, 1, ?, ?)`,
+		wordID, next.Add(-day).UTC().Format("2006-01-02 15:04:05"), next.UTC().Format("2006-01-02 15:04:05")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetRecommendations(t *testing.T) {
+	svc := testutil.NewService(t)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	f := testutil.NewFixture(t, svc.DB).
+		// Never studied, with two words never reviewed
+		Group("Fresh").
+		Word("犬", "inu", "dog").InGroup("Fresh").
+		Word("猫", "neko", "cat").InGroup("Fresh").
+		// Answered right yesterday and not due for ten days
+		Group("Mastered").
+		Word("水", "mizu", "water").InGroup("Mastered").SessionAt(now.Add(-day)).ReviewAt(true, now.Add(-day)).
+		Word("火", "hi", "fire").InGroup("Mastered").ReviewAt(true, now.Add(-day)).
+		// Answered right just as well, but forty days ago
+		Group("Neglected").
+		Word("山", "yama", "mountain").InGroup("Neglected").SessionAt(now.Add(-40*day)).ReviewAt(true, now.Add(-40*day)).
+		Word("川", "kawa", "river").InGroup("Neglected").ReviewAt(true, now.Add(-40*day)).
+		// Answered right once in three two days ago, and due since yesterday
+		Group("Struggling").
+		Word("雨", "ame", "rain").InGroup("Struggling").SessionAt(now.Add(-2*day)).
+		ReviewAt(false, now.Add(-2*day)).ReviewAt(false, now.Add(-2*day)).ReviewAt(true, now.Add(-2*day))
+	for _, japanese := range []string{"水", "火", "山", "川"} {
+		schedule(t, svc.DB, f.WordID(japanese), now.Add(10*day))
+	}
+	schedule(t, svc.DB, f.WordID("雨"), now.Add(-day))
+
+	recommendations, err := svc.GetRecommendations(now, 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		group  string
+		score  float64
+		reason string
+	}{
+		// 2 new words and the neglect cap of 30 days
+		{"Fresh", 2*1 + 30*0.5, "never studied"},
+		// 40 days capped at 30
+		{"Neglected", 30 * 0.5, "not studied for 40 days"},
+		// 1 due word, 2 days and a recent accuracy of a third
+		{"Struggling", 1*3 + 2*0.5 + 6.67, "33% recent accuracy"},
+		{"Mastered", 1 * 0.5, "not studied for 1 days"},
+	}
+	if len(recommendations) != len(want) {
+		t.Fatalf("got %d recommendations, want %d: %+v", len(recommendations), len(want), recommendations)
+	}
+	for i, w := range want {
+		r := recommendations[i]
+		if r.GroupID != f.GroupID(w.group) || math.Abs(r.Score-w.score) > 0.005 || r.Reason != w.reason {
+			t.Errorf("recommendation %d = %+v, want %s scoring %.2f for %q", i, r, w.group, w.score, w.reason)
+		}
+	}
+
+	top, err := svc.GetRecommendations(now, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 2 || top[0].GroupID != f.GroupID("Fresh") || top[1].GroupID != f.GroupID("Neglected") {
+		t.Errorf("top 2 = %+v, want Fresh and Neglected", top)
+	}
+
+	// Archived groups are only recommended when asked for
+	if _, err := svc.DB.Exec("UPDATE groups SET archived = 1 WHERE id = ?", f.GroupID("Fresh")); err != nil {
+		t.Fatal(err)
+	}
+	if top, err = svc.GetRecommendations(now, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 || top[0].GroupID != f.GroupID("Neglected") {
+		t.Errorf("top without archived groups = %+v, want Neglected", top)
+	}
+	if top, err = svc.GetRecommendations(now, 1, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 || top[0].GroupID != f.GroupID("Fresh") {
+		t.Errorf("top with archived groups = %+v, want Fresh", top)
+	}
+}
+
+func TestGetActivityStats(t *testing.T) {
+	svc := testutil.NewService(t)
+	start := time.Date(2024, 2, 5, 18, 0, 0, 0, time.UTC)
+	testutil.NewFixture(t, svc.DB).Group("N5").
+		Word("水", "mizu", "water").
+		Word("火", "hi", "fire").
+		Activity("Matching", "matching").
+		// Two flashcards sessions: 3 of 4 answered right over 10 minutes, with a skip that does not
+		// count, then 1 of 2 in a session left open, measured from its first to its last review
+		Activity("Flashcards", "flashcards").
+		SessionAt(start).End(start.Add(10*time.Minute)).
+		ReviewAt(true, start).ReviewAt(true, start).ReviewAt(true, start).ReviewAt(false, start).SkipAt(start).
+		SessionAt(start.Add(day)).
+		ReviewAt(true, start.Add(day)).ReviewAt(false, start.Add(day+5*time.Minute)).
+		// One typing session of 20 minutes with both answers wrong
+		Activity("Typing", "typing").
+		SessionAt(start).End(start.Add(20*time.Minute)).
+		ReviewAt(false, start).ReviewAt(false, start.Add(time.Minute))
+
+	stats, total, err := svc.GetActivityStats(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 {
+		t.Errorf("total = %d, want 3 activity types", total)
+	}
+	want := []struct {
+		activity                 string
+		sessions, reviews, right int
+		accuracy, seconds        float64
+	}{
+		{"Flashcards", 2, 6, 4, 4.0 / 6, 450},
+		{"Matching", 0, 0, 0, -1, -1},
+		{"Typing", 1, 2, 0, 0, 1200},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("got %d activity types, want %d: %+v", len(stats), len(want), stats)
+	}
+	for i, w := range want {
+		st := stats[i]
+		if st.Activity != w.activity || st.TotalSessions != w.sessions || st.TotalReviews != w.reviews || st.CorrectCount != w.right {
+			t.Errorf("stats %d = %+v, want %+v", i, st, w)
+			continue
+		}
+		if w.accuracy < 0 {
+			if st.Accuracy != nil || st.AverageSessionSeconds != nil {
+				t.Errorf("%s accuracy %v and length %v, want both nil without sessions", st.Activity, st.Accuracy, st.AverageSessionSeconds)
+			}
+			continue
+		}
+		if st.Accuracy == nil || math.Abs(*st.Accuracy-w.accuracy) > 1e-9 {
+			t.Errorf("%s accuracy = %v, want %v", st.Activity, st.Accuracy, w.accuracy)
+		}
+		if st.AverageSessionSeconds == nil || *st.AverageSessionSeconds != w.seconds {
+			t.Errorf("%s average session = %v seconds, want %v", st.Activity, st.AverageSessionSeconds, w.seconds)
+		}
+	}
+
+	page, total, err := svc.GetActivityStats(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(page) != 1 || page[0].Activity != "Typing" {
+		t.Errorf("page 2 of 2 = %+v of %d, want Typing of 3", page, total)
+	}
+}
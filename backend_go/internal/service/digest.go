@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+// maxDigestStreakDays caps the streak reported by a digest.
+const maxDigestStreakDays = 365
+
+// GetDigest summarizes the study on the calendar day of date in the configured timezone, with the
+// same definitions as the dashboard: accuracy as in the quick stats, mastery as in the velocity (see
+// masteredWordsSQL) and due words as in the upcoming reviews. New words are counted from the event
+// timeline, so days whose events were trimmed report fewer. Due words come from the current
+// schedules, so for past days they tell what is due now rather than what was due then.
+func (s *Service) GetDigest(date time.Time) (*models.Digest, error) {
+	loc := s.Location()
+	date = date.In(loc)
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 0, 1)
+	digest := &models.Digest{Date: start.Format("2006-01-02"), Timezone: loc.String()}
+
+	var err error
+	if digest.Reviews, digest.CorrectCount, err = s.reviewCounts(start, end); err != nil {
+		return nil, err
+	}
+	digest.Accuracy = accuracyPercent(digest.CorrectCount, digest.Reviews)
+
+	from, to := start.UTC().Format(sqliteTimeFormat), end.UTC().Format(sqliteTimeFormat)
+	if err := s.DB.QueryRow(`SELECT COALESCE(SUM(CASE type WHEN ? THEN 1 ELSE json_extract(payload, '$.words_created') END), 0)
+	                         FROM events
+	                         WHERE type IN (?, ?) AND created_at >= ? AND created_at < ?`,
+		EventWordCreated, EventWordCreated, EventWordsImported, from, to).Scan(&digest.NewWords); err != nil {
+		return nil, err
+	}
+	if err := s.DB.QueryRow(`SELECT COUNT(*) FROM (`+s.masteredWordsSQL()+`)
+	                         WHERE mastered_at >= ? AND mastered_at < ?`, from, to).Scan(&digest.WordsMastered); err != nil {
+		return nil, err
+	}
+	if digest.StreakDays, err = s.reviewStreak(start, maxDigestStreakDays); err != nil {
+		return nil, err
+	}
+	upcoming, err := s.GetUpcomingReviews(end, 1)
+	if err != nil {
+		return nil, err
+	}
+	digest.DueTomorrow = upcoming.Due + upcoming.Days[0].Words
+
+	digest.Text = renderDigest(digest)
+	return digest, nil
+}
+
+// renderDigest renders a digest as plain text, one line per metric.
+func renderDigest(d *models.Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Study digest for %s\n", d.Date)
+	if d.Reviews == 0 {
+		b.WriteString("Reviews: none\n")
+	} else {
+		fmt.Fprintf(&b, "Reviews: %d (%.0f%% correct)\n", d.Reviews, d.Accuracy)
+	}
+	fmt.Fprintf(&b, "New words: %d\n", d.NewWords)
+	fmt.Fprintf(&b, "Newly mastered: %d\n", d.WordsMastered)
+	switch d.StreakDays {
+	case 0:
+		b.WriteString("Streak: none\n")
+	case 1:
+		b.WriteString("Streak: 1 day\n")
+	default:
+		fmt.Fprintf(&b, "Streak: %d days\n", d.StreakDays)
+	}
+	fmt.Fprintf(&b, "Due tomorrow: %d\n", d.DueTomorrow)
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+
+	"backend_go/internal/models"
+)
+
+// Directions a word can be asked in, stored in word_review_items.direction. Reviews without one
+// count toward the overall accuracy but not toward any direction's.
+const (
+	// DirectionJapaneseToEnglish shows the japanese and asks for the english.
+	DirectionJapaneseToEnglish = "jp_to_en"
+	// DirectionEnglishToJapanese shows the english and asks for the japanese.
+	DirectionEnglishToJapanese = "en_to_jp"
+)
+
+// ReviewDirections lists the directions in the order they are reported.
+var ReviewDirections = []string{DirectionJapaneseToEnglish, DirectionEnglishToJapanese}
+
+// directionColumns returns the SQL expressions counting, for each of ReviewDirections in order, the
+// correct and the wrong reviews wr in that direction. They follow a comma and are scanned by
+// directionCounts.
+func directionColumns() string {
+	var b strings.Builder
+	for _, d := range ReviewDirections {
+		b.WriteString(",\n COALESCE(SUM(CASE WHEN wr.direction = '" + d + "' AND wr.correct THEN 1 ELSE 0 END), 0)")
+		b.WriteString(",\n COALESCE(SUM(CASE WHEN wr.direction = '" + d + "' AND NOT wr.correct AND NOT wr.skipped THEN 1 ELSE 0 END), 0)")
+	}
+	return b.String()
+}
+
+// directionCounts holds the correct and wrong counts of each of ReviewDirections, as selected by
+// directionColumns.
+type directionCounts [][2]int
+
+func newDirectionCounts() directionCounts {
+	return make(directionCounts, len(ReviewDirections))
+}
+
+// dest returns the destinations to scan the directionColumns into.
+func (c directionCounts) dest() []interface{} {
+	dest := make([]interface{}, 0, 2*len(c))
+	for i := range c {
+		dest = append(dest, &c[i][0], &c[i][1])
+	}
+	return dest
+}
+
+// stats returns the counts by direction, with the accuracy of those that have answered reviews.
+func (c directionCounts) stats() map[string]models.DirectionStats {
+	stats := make(map[string]models.DirectionStats, len(c))
+	for i, d := range ReviewDirections {
+		s := models.DirectionStats{CorrectCount: c[i][0], WrongCount: c[i][1]}
+		if total := s.CorrectCount + s.WrongCount; total > 0 {
+			accuracy := float64(s.CorrectCount) / float64(total)
+			s.Accuracy = &accuracy
+		}
+		stats[d] = s
+	}
+	return stats
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"backend_go/internal/models"
+)
+
+// Event types of the activity timeline.
+const (
+	EventWordCreated       = "word_created"
+	EventWordsImported     = "words_imported"
+	EventGroupCreated      = "group_created"
+	EventSessionCompleted  = "session_completed"
+	EventMilestoneReached  = "milestone_reached"
+	EventAchievementEarned = "achievement_earned"
+	EventHistoryReset      = "history_reset"
+	EventFullReset         = "full_reset"
+)
+
+// EventTypes lists the event types in the order they are documented.
+var EventTypes = []string{
+	EventWordCreated, EventWordsImported, EventGroupCreated, EventSessionCompleted,
+	EventMilestoneReached, EventAchievementEarned, EventHistoryReset, EventFullReset,
+}
+
+// learnerEvents are the event types about the study of the learner the service acts for. They are
+// stored with the learner and listed only to them, while the other events are listed to everyone.
+var learnerEvents = map[string]bool{
+	EventSessionCompleted: true, EventMilestoneReached: true, EventAchievementEarned: true, EventHistoryReset: true,
+}
+
+// MaxEvents is how many of the most recent events are kept. Older ones are trimmed as new events are recorded.
+const MaxEvents = 5000
+
+// masteryMilestones are the numbers of mastered words that are celebrated with a milestone event,
+// in increasing order.
+var masteryMilestones = []int{10, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// recordEvent appends an event to the timeline and trims it to MaxEvents. Learner events are stored
+// with the learner (see learnerEvents). It is called after the operation it reports has been
+// committed, and failures are only logged: the timeline must never fail the operation itself.
+func (s *Service) recordEvent(eventType string, payload map[string]interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Recording %s event: %v", eventType, err)
+		return
+	}
+	var owner interface{}
+	if learnerEvents[eventType] {
+		owner = s.owner()
+	}
+	result, err := s.DB.Exec("INSERT INTO events (type, payload, user_id) VALUES (?, ?, ?)", eventType, string(data), owner)
+	if err != nil {
+		log.Printf("Recording %s event: %v", eventType, err)
+		return
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+	if _, err := s.DB.Exec("DELETE FROM events WHERE id <= ?", id-MaxEvents); err != nil {
+		log.Printf("Trimming events: %v", err)
+	}
+}
+
+// recordMasteryMilestone records a milestone event when the correct review just recorded for
+// wordID made it mastered and the learner reached the next of masteryMilestones, and awards
+// AchievementMastered50. The learner's mastered words are only counted up to the next milestone or
+// achievement to reach, and mastery_milestones records each milestone once.
+func (s *Service) recordMasteryMilestone(wordID int) {
+	var correct int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM word_review_items WHERE word_id = ? AND correct AND "+s.userCond(""), wordID).Scan(&correct); err != nil {
+		log.Printf("Checking mastery milestone: %v", err)
+		return
+	}
+	if correct != MasteryCorrectReviews {
+		return
+	}
+	next, err := s.nextMasteryMilestone()
+	if err != nil {
+		log.Printf("Checking mastery milestone: %v", err)
+		return
+	}
+	earned, err := s.earnedAchievements()
+	if err != nil {
+		log.Printf("Checking mastery milestone: %v", err)
+		return
+	}
+	limit := next
+	if !earned[AchievementMastered50] && limit < masteredAchievementWords {
+		limit = masteredAchievementWords
+	}
+	if limit == 0 {
+		return
+	}
+	mastered, err := s.countUpTo("SELECT 1 FROM words w WHERE w.deleted_at IS NULL AND "+s.masteredWordCondition(), limit)
+	if err != nil {
+		log.Printf("Checking mastery milestone: %v", err)
+		return
+	}
+	if !earned[AchievementMastered50] && mastered >= masteredAchievementWords {
+		s.awardAchievement(AchievementMastered50, map[string]interface{}{"mastered_words": mastered})
+	}
+	if next == 0 || mastered < next {
+		return
+	}
+	result, err := s.DB.Exec("INSERT OR IGNORE INTO mastery_milestones (user_id, mastered_words) VALUES (?, ?)", s.userID, next)
+	if err != nil {
+		log.Printf("Recording mastery milestone: %v", err)
+		return
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return
+	}
+	s.recordEvent(EventMilestoneReached, map[string]interface{}{"mastered_words": next, "word_id": wordID})
+}
+
+// nextMasteryMilestone returns the smallest of masteryMilestones the learner has not reached, 0 if
+// they reached them all.
+func (s *Service) nextMasteryMilestone() (int, error) {
+	var reached sql.NullInt64
+	if err := s.DB.QueryRow("SELECT MAX(mastered_words) FROM mastery_milestones WHERE " + s.scheduleCond("")).Scan(&reached); err != nil {
+		return 0, err
+	}
+	for _, m := range masteryMilestones {
+		if int64(m) > reached.Int64 {
+			return m, nil
+		}
+	}
+	return 0, nil
+}
+
+// recordImportEvent records how many words an import created, unless it created none.
+func (s *Service) recordImportEvent(groupID, created int) {
+	if created == 0 {
+		return
+	}
+	payload := map[string]interface{}{"words_created": created}
+	if groupID != 0 {
+		payload["group_id"] = groupID
+	}
+	s.recordEvent(EventWordsImported, payload)
+}
+
+// ListEvents retrieves one page of events, newest first, along with the total number of events.
+// A non-empty types restricts the listing to those event types. A learner sees their own learner
+// events and the events of everyone else (see learnerEvents).
+func (s *Service) ListEvents(types []string, page, perPage int) ([]models.Event, int, error) {
+	where := "WHERE 1"
+	var args []interface{}
+	if s.userID != 0 {
+		where = "WHERE (user_id IS NULL OR " + s.userCond("") + ")"
+	}
+	if len(types) > 0 {
+		where += " AND type IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(types)), ", ") + ")"
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM events "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := s.DB.Query("SELECT id, type, payload, created_at FROM events "+where+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		append(args, perPage, (page-1)*perPage)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := make([]models.Event, 0)
+	for rows.Next() {
+		var e models.Event
+		var payload string
+		if err := rows.Scan(&e.ID, &e.Type, &payload, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		e.Payload = json.RawMessage(payload)
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
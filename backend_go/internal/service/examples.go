@@ -0,0 +1,52 @@
+package service
+
+import (
+	"strings"
+
+	"backend_go/internal/models"
+)
+
+// AddWordExample adds an example sentence to a word, or returns sql.ErrNoRows if the word does not exist.
+func (s *Service) AddWordExample(wordID int, japanese, english string) (*models.WordExample, error) {
+	if _, err := s.GetWordByID(wordID); err != nil {
+		return nil, err
+	}
+	result, err := s.DB.Exec("INSERT INTO word_examples (word_id, japanese, english) VALUES (?, ?, ?)",
+		wordID, strings.TrimSpace(japanese), strings.TrimSpace(english))
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	var example models.WordExample
+	err = s.DB.QueryRow("SELECT id, word_id, japanese, english, created_at FROM word_examples WHERE id = ?", id).
+		Scan(&example.ID, &example.WordID, &example.Japanese, &example.English, &example.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &example, nil
+}
+
+// GetWordExamples retrieves the example sentences of a word, oldest first, or sql.ErrNoRows if the
+// word does not exist.
+func (s *Service) GetWordExamples(wordID int) ([]models.WordExample, error) {
+	if _, err := s.GetWordByID(wordID); err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query("SELECT id, word_id, japanese, english, created_at FROM word_examples WHERE word_id = ? ORDER BY id", wordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	examples := make([]models.WordExample, 0)
+	for rows.Next() {
+		var example models.WordExample
+		if err := rows.Scan(&example.ID, &example.WordID, &example.Japanese, &example.English, &example.CreatedAt); err != nil {
+			return nil, err
+		}
+		examples = append(examples, example)
+	}
+	return examples, rows.Err()
+}
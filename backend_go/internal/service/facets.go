@@ -0,0 +1,139 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"backend_go/internal/models"
+)
+
+// GetWordFacets counts the JLPT levels, parts of speech and groups of the words. Only values that
+// at least one word has are listed, so clients can build filters that never come up empty.
+func (s *Service) GetWordFacets() (*models.WordFacets, error) {
+	facets := &models.WordFacets{
+		JLPTLevels:    make([]models.Facet, 0),
+		PartsOfSpeech: make([]models.Facet, 0),
+		Groups:        make([]models.GroupFacet, 0),
+	}
+
+	rows, err := s.DB.Query(`SELECT jlpt_level, COUNT(*) FROM words
+	                         WHERE deleted_at IS NULL AND jlpt_level IS NOT NULL
+	                         GROUP BY jlpt_level ORDER BY jlpt_level DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var level, count int
+		if err := rows.Scan(&level, &count); err != nil {
+			return nil, err
+		}
+		facets.JLPTLevels = append(facets.JLPTLevels, models.Facet{Value: fmt.Sprintf("N%d", level), Count: count})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if facets.PartsOfSpeech, err = s.partOfSpeechFacets(); err != nil {
+		return nil, err
+	}
+
+	groupRows, err := s.DB.Query(`SELECT g.id, g.name, COUNT(*) FROM groups g
+	                              JOIN word_groups wg ON wg.group_id = g.id
+	                              JOIN words w ON w.id = wg.word_id AND w.deleted_at IS NULL
+	                              GROUP BY g.id ORDER BY g.name, g.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer groupRows.Close()
+	for groupRows.Next() {
+		var g models.GroupFacet
+		if err := groupRows.Scan(&g.GroupID, &g.Name, &g.Count); err != nil {
+			return nil, err
+		}
+		facets.Groups = append(facets.Groups, g)
+	}
+	return facets, groupRows.Err()
+}
+
+// partOfSpeechFacets counts the parts of speech of the words, most common first. The parts column
+// is freeform JSON, so the distinct values are grouped in SQL and the parts of speech read from
+// each of them by partsOfSpeech.
+func (s *Service) partOfSpeechFacets() ([]models.Facet, error) {
+	rows, err := s.DB.Query(`SELECT parts, COUNT(*) FROM words
+	                         WHERE deleted_at IS NULL AND COALESCE(parts, '') <> ''
+	                         GROUP BY parts`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var parts string
+		var count int
+		if err := rows.Scan(&parts, &count); err != nil {
+			return nil, err
+		}
+		for _, pos := range partsOfSpeech(parts) {
+			counts[pos] += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	facets := make([]models.Facet, 0, len(counts))
+	for pos, count := range counts {
+		facets = append(facets, models.Facet{Value: pos, Count: count})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+	return facets, nil
+}
+
+// partsOfSpeech reads the parts of speech from the parts of a word: a JSON string, a JSON array of
+// strings, or an object with a "part_of_speech" or "type" field holding either. Parts that are not
+// JSON are taken as a single part of speech. Values are trimmed and lower-cased, and each is
+// returned once.
+func partsOfSpeech(parts string) []string {
+	var value interface{}
+	if err := json.Unmarshal([]byte(parts), &value); err != nil {
+		value = parts
+	}
+	if obj, ok := value.(map[string]interface{}); ok {
+		value = obj["part_of_speech"]
+		if value == nil {
+			value = obj["type"]
+		}
+	}
+
+	var list []string
+	seen := make(map[string]bool)
+	add := func(v interface{}) {
+		str, ok := v.(string)
+		if !ok {
+			return
+		}
+		str = strings.ToLower(strings.TrimSpace(str))
+		if str != "" && !seen[str] {
+			seen[str] = true
+			list = append(list, str)
+		}
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			add(item)
+		}
+	default:
+		add(v)
+	}
+	return list
+}
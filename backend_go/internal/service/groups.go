@@ -0,0 +1,425 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"backend_go/internal/models"
+)
+
+// ErrDuplicateGroupName is returned when a group is given the name of another group, compared
+// without regard to case.
+var ErrDuplicateGroupName = errors.New("a group with this name already exists")
+
+// groupNameError returns ErrDuplicateGroupName for an error violating the unique index on group
+// names, and err otherwise.
+func groupNameError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique && strings.Contains(sqliteErr.Error(), "groups.name") {
+		return ErrDuplicateGroupName
+	}
+	return err
+}
+
+// inPlaceholders returns "?, ?, ..." with one placeholder per id, and the ids as query arguments.
+func inPlaceholders(ids []int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", "), args
+}
+
+// groupColumns lists the columns of the groups table (aliased g) read by scanGroup, in order.
+const groupColumns = "g.id, g.name, g.default_activity_type, g.created_at, g.updated_at, g.created_by, g.archived"
+
+// scanGroup scans the groupColumns of a row into grp.
+func scanGroup(row rowScanner, grp *models.Group) error {
+	var createdAt, updatedAt sql.NullTime
+	if err := row.Scan(&grp.ID, &grp.Name, &grp.DefaultActivityType, &createdAt, &updatedAt, &grp.CreatedBy, &grp.Archived); err != nil {
+		return err
+	}
+	grp.CreatedAt = createdAt.Time
+	grp.UpdatedAt = updatedAt.Time
+	return nil
+}
+
+// ListGroupStats computes the stats of each of the given groups. Unknown group IDs are skipped,
+// so the result may be shorter than ids. Results are ordered by group ID.
+func (s *Service) ListGroupStats(ids []int) ([]models.GroupStats, error) {
+	stats := make([]models.GroupStats, 0, len(ids))
+	if len(ids) == 0 {
+		return stats, nil
+	}
+	placeholders, args := inPlaceholders(ids)
+	query := `SELECT g.id, g.name,
+	                 COUNT(DISTINCT gw.word_id),
+	                 COUNT(DISTINCT wr.word_id),
+	                 COALESCE(SUM(CASE WHEN wr.correct THEN 1 ELSE 0 END), 0),
+	                 COALESCE(SUM(CASE WHEN wr.id IS NOT NULL AND NOT wr.correct AND NOT wr.skipped THEN 1 ELSE 0 END), 0)
+	          FROM groups g
+	          LEFT JOIN (
+	              SELECT DISTINCT wg.group_id, wg.word_id
+	              FROM word_groups wg
+	              JOIN words w ON w.id = wg.word_id AND w.deleted_at IS NULL
+	          ) gw ON gw.group_id = g.id
+	          LEFT JOIN word_review_items wr ON wr.word_id = gw.word_id AND ` + s.userCond("wr") + `
+	          WHERE g.id IN (` + placeholders + `)
+	          GROUP BY g.id, g.name
+	          ORDER BY g.id`
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st models.GroupStats
+		if err := rows.Scan(&st.GroupID, &st.Name, &st.TotalWords, &st.StudiedWords, &st.CorrectCount, &st.WrongCount); err != nil {
+			return nil, err
+		}
+		if total := st.CorrectCount + st.WrongCount; total > 0 {
+			accuracy := float64(st.CorrectCount) / float64(total)
+			st.Accuracy = &accuracy
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+// GetGroupStats computes the stats of a single group, returning sql.ErrNoRows if it does not exist.
+func (s *Service) GetGroupStats(id int) (*models.GroupStats, error) {
+	stats, err := s.ListGroupStats([]int{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &stats[0], nil
+}
+
+// GetGroupSetStats computes the combined stats of the given groups, counting each of their words
+// once however many of the groups it is in.
+func (s *Service) GetGroupSetStats(ids []int) (*models.GroupSetStats, error) {
+	stats := &models.GroupSetStats{GroupIDs: make([]int, 0, len(ids)), Missing: make([]int, 0)}
+	if len(ids) == 0 {
+		return stats, nil
+	}
+	placeholders, args := inPlaceholders(ids)
+	rows, err := s.DB.Query("SELECT id FROM groups WHERE id IN ("+placeholders+") ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		found[id] = true
+		stats.GroupIDs = append(stats.GroupIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if !found[id] {
+			stats.Missing = append(stats.Missing, id)
+		}
+	}
+
+	query := `SELECT COUNT(DISTINCT gw.word_id),
+	                 COUNT(DISTINCT wr.word_id),
+	                 COALESCE(SUM(CASE WHEN wr.correct THEN 1 ELSE 0 END), 0),
+	                 COALESCE(SUM(CASE WHEN wr.id IS NOT NULL AND NOT wr.correct AND NOT wr.skipped THEN 1 ELSE 0 END), 0)
+	          FROM (
+	              SELECT DISTINCT wg.word_id
+	              FROM word_groups wg
+	              JOIN words w ON w.id = wg.word_id AND w.deleted_at IS NULL
+	              WHERE wg.group_id IN (` + placeholders + `)
+	          ) gw
+	          LEFT JOIN word_review_items wr ON wr.word_id = gw.word_id AND ` + s.userCond("wr")
+	if err := s.DB.QueryRow(query, args...).Scan(&stats.TotalWords, &stats.StudiedWords,
+		&stats.CorrectCount, &stats.WrongCount); err != nil {
+		return nil, err
+	}
+	if total := stats.CorrectCount + stats.WrongCount; total > 0 {
+		accuracy := float64(stats.CorrectCount) / float64(total)
+		stats.Accuracy = &accuracy
+	}
+	return stats, nil
+}
+
+// GetGroupOverlaps counts the words shared by every pair of the given groups.
+// Pairs without shared words are included with a count of zero.
+func (s *Service) GetGroupOverlaps(ids []int) ([]models.GroupOverlap, error) {
+	overlaps := make([]models.GroupOverlap, 0)
+	index := make(map[[2]int]int)
+	for i := 0; i < len(ids); i++ {
+		for j := i + 1; j < len(ids); j++ {
+			pair := [2]int{ids[i], ids[j]}
+			if pair[0] > pair[1] {
+				pair[0], pair[1] = pair[1], pair[0]
+			}
+			if _, seen := index[pair]; seen || pair[0] == pair[1] {
+				continue
+			}
+			index[pair] = len(overlaps)
+			overlaps = append(overlaps, models.GroupOverlap{GroupIDs: pair})
+		}
+	}
+	if len(overlaps) == 0 {
+		return overlaps, nil
+	}
+
+	placeholders, args := inPlaceholders(ids)
+	query := `SELECT a.group_id, b.group_id, COUNT(DISTINCT a.word_id)
+	          FROM word_groups a
+	          JOIN word_groups b ON a.word_id = b.word_id AND a.group_id < b.group_id
+	          JOIN words w ON w.id = a.word_id AND w.deleted_at IS NULL
+	          WHERE a.group_id IN (` + placeholders + `) AND b.group_id IN (` + placeholders + `)
+	          GROUP BY a.group_id, b.group_id`
+	rows, err := s.DB.Query(query, append(args, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pair [2]int
+		var shared int
+		if err := rows.Scan(&pair[0], &pair[1], &shared); err != nil {
+			return nil, err
+		}
+		if i, ok := index[pair]; ok {
+			overlaps[i].SharedWords = shared
+		}
+	}
+	return overlaps, rows.Err()
+}
+
+// groupLastStudiedSQL selects when the learner last studied each group (group_id,
+// last_studied_at): their latest study session of it that was not abandoned or their latest review
+// of any of its words, whichever is later, NULL if they never did.
+func (s *Service) groupLastStudiedSQL() string {
+	return `SELECT g.id AS group_id,
+                   MAX(COALESCE(ls.at, lr.at), COALESCE(lr.at, ls.at)) AS last_studied_at
+            FROM groups g
+            LEFT JOIN (SELECT group_id, MAX(created_at) AS at FROM study_sessions
+                       WHERE NOT abandoned AND ` + s.userCond("") + ` GROUP BY group_id) ls ON ls.group_id = g.id
+            LEFT JOIN (SELECT wg.group_id, MAX(r.created_at) AS at
+                       FROM word_review_items r
+                       JOIN word_groups wg ON wg.word_id = r.word_id
+                       WHERE ` + s.userCond("r") + `
+                       GROUP BY wg.group_id) lr ON lr.group_id = g.id`
+}
+
+// GetStaleGroups lists the groups not studied in the days before now (see groupLastStudiedSQL),
+// with their size and mastery. Groups never studied come first, then the longest unstudied.
+// Archived groups are left out unless includeArchived.
+func (s *Service) GetStaleGroups(now time.Time, days int, includeArchived bool) ([]models.StaleGroup, error) {
+	nowStr := now.UTC().Format(sqliteTimeFormat)
+	query := `SELECT g.id, g.name, datetime(ls.last_studied_at), julianday(?) - julianday(ls.last_studied_at),
+	                 COUNT(DISTINCT w.id),
+	                 COUNT(DISTINCT CASE WHEN ` + s.masteredWordCondition() + ` THEN w.id END)
+	          FROM groups g
+	          JOIN (` + s.groupLastStudiedSQL() + `) ls ON ls.group_id = g.id
+	          LEFT JOIN word_groups wg ON wg.group_id = g.id
+	          LEFT JOIN words w ON w.id = wg.word_id AND w.deleted_at IS NULL
+	          WHERE (ls.last_studied_at IS NULL OR ls.last_studied_at < ?) AND (? OR NOT g.archived)
+	          GROUP BY g.id, g.name, ls.last_studied_at
+	          ORDER BY ls.last_studied_at IS NOT NULL, ls.last_studied_at, g.id`
+	rows, err := s.DB.Query(query, nowStr, now.UTC().AddDate(0, 0, -days).Format(sqliteTimeFormat), includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.StaleGroup, 0)
+	for rows.Next() {
+		var g models.StaleGroup
+		var lastStudied sql.NullString
+		var daysSince sql.NullFloat64
+		if err := rows.Scan(&g.GroupID, &g.GroupName, &lastStudied, &daysSince, &g.TotalWords, &g.MasteredWords); err != nil {
+			return nil, err
+		}
+		if lastStudied.Valid {
+			at, err := time.Parse(sqliteTimeFormat, lastStudied.String)
+			if err != nil {
+				return nil, err
+			}
+			whole := int(daysSince.Float64)
+			g.LastStudiedAt = &at
+			g.DaysSinceStudied = &whole
+		}
+		if g.TotalWords > 0 {
+			g.MasteryPercent = math.Round(float64(g.MasteredWords)/float64(g.TotalWords)*1000) / 10
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// MoveGroupWords moves the given words from the group fromGroupID to the group toGroupID in one
+// transaction. Words that are not in the source group are skipped, and words already in the
+// destination are only removed from the source. It returns sql.ErrNoRows if either group does not
+// exist.
+func (s *Service) MoveGroupWords(toGroupID, fromGroupID int, wordIDs []int) (*models.BulkResult, error) {
+	candidates, result := bulkIDs(wordIDs)
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var found int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM groups WHERE id IN (?, ?)", toGroupID, fromGroupID).Scan(&found); err != nil {
+		return nil, err
+	}
+	if found != 2 {
+		return nil, sql.ErrNoRows
+	}
+	if len(candidates) == 0 {
+		return result, nil
+	}
+
+	placeholders, args := inPlaceholders(candidates)
+	rows, err := tx.Query("SELECT word_id FROM word_groups WHERE group_id = ? AND word_id IN ("+placeholders+")",
+		append([]interface{}{fromGroupID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	inSource := make(map[int]bool, len(candidates))
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		inSource[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range candidates {
+		if !inSource[id] {
+			result.Skipped = append(result.Skipped, models.BulkOutcome{ID: id, Reason: "word not in the source group"})
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM word_groups WHERE word_id = ? AND group_id = ?", id, fromGroupID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT INTO word_groups (word_id, group_id)
+		                      SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM word_groups WHERE word_id = ? AND group_id = ?)`,
+			id, toGroupID, id, toGroupID); err != nil {
+			return nil, err
+		}
+		result.Succeeded = append(result.Succeeded, id)
+	}
+	if len(result.Succeeded) > 0 {
+		if _, err := tx.Exec("UPDATE groups SET updated_at = CURRENT_TIMESTAMP WHERE id IN (?, ?)", toGroupID, fromGroupID); err != nil {
+			return nil, err
+		}
+	}
+	return result, tx.Commit()
+}
+
+// UnknownWordsError is returned by BulkAddWordsToGroup when some of the word IDs are not those of
+// existing words.
+type UnknownWordsError struct {
+	IDs []int
+}
+
+func (e *UnknownWordsError) Error() string {
+	return fmt.Sprintf("unknown word ids %v", e.IDs)
+}
+
+// BulkAddWordsToGroup adds the given words to the group groupID in one transaction. Words already
+// in the group, and IDs listed more than once, are skipped. It returns the number of words added
+// and skipped, sql.ErrNoRows if the group does not exist, or an *UnknownWordsError, adding nothing,
+// if any ID is not that of a live word.
+func (s *Service) BulkAddWordsToGroup(groupID int, wordIDs []int) (added, skipped int, err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM groups WHERE id = ?", groupID).Scan(&exists); err != nil {
+		return 0, 0, err
+	}
+	if exists == 0 {
+		return 0, 0, sql.ErrNoRows
+	}
+
+	unique := make([]int, 0, len(wordIDs))
+	seen := make(map[int]bool, len(wordIDs))
+	for _, id := range wordIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	placeholders, args := inPlaceholders(unique)
+	rows, err := tx.Query("SELECT id FROM words WHERE deleted_at IS NULL AND id IN ("+placeholders+")", args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	live := make(map[int]bool, len(unique))
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		live[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	var unknown []int
+	for _, id := range unique {
+		if !live[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		return 0, 0, &UnknownWordsError{IDs: unknown}
+	}
+
+	for _, id := range unique {
+		result, err := tx.Exec(`INSERT INTO word_groups (word_id, group_id)
+		                        SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM word_groups WHERE word_id = ? AND group_id = ?)`,
+			id, groupID, id, groupID)
+		if err != nil {
+			return 0, 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, 0, err
+		}
+		added += int(n)
+	}
+	if added > 0 {
+		if _, err := tx.Exec("UPDATE groups SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", groupID); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return added, len(wordIDs) - added, nil
+}
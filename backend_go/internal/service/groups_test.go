@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend_go/internal/service"
+)
+
+// migratedBefore opens a service on a temporary database migrated up to, but not including, the
+// migration whose file name starts with prefix.
+func migratedBefore(t *testing.T, prefix string) *service.Service {
+	t.Helper()
+	migrations, err := filepath.Glob(filepath.Join("..", "..", "db", "migrations", "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := t.TempDir()
+	for _, path := range migrations {
+		if filepath.Base(path) >= prefix {
+			continue
+		}
+		script, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(before, filepath.Base(path)), script, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc, err := service.Open(filepath.Join(t.TempDir(), "words.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { svc.Close() })
+	if err := service.MigrateDir(svc.DB, before); err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestUniqueGroupNamesMigration(t *testing.T) {
+	svc := migratedBefore(t, "0038")
+	// "Verbs (2)" is taken already, so the duplicates of Verbs get 3 and 4
+	if _, err := svc.DB.Exec(`INSERT INTO groups (id, name) VALUES
+		(1, 'Verbs'), (2, 'verbs'), (3, 'Verbs (2)'), (4, ' VERBS '),
+		(5, 'Basic Greetings'), (6, 'Basic Greetings (3)'), (7, 'basic greetings'), (8, 'N5  ')`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.MigrateDir(svc.DB, filepath.Join("..", "..", "db", "migrations")); err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{
+		1: "Verbs", 2: "verbs (3)", 3: "Verbs (2)", 4: "VERBS (4)",
+		5: "Basic Greetings", 6: "Basic Greetings (3)", 7: "basic greetings (2)", 8: "N5",
+	}
+	for id, name := range want {
+		var got string
+		if err := svc.DB.QueryRow("SELECT name FROM groups WHERE id = ?", id).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != name {
+			t.Errorf("group %d is named %q, want %q", id, got, name)
+		}
+	}
+
+	// Names stay unique regardless of case
+	if _, err := svc.CreateGroup("n5", ""); !errors.Is(err, service.ErrDuplicateGroupName) {
+		t.Errorf("creating n5: %v, want ErrDuplicateGroupName", err)
+	}
+}
+
+func TestDefaultActivityTypeMigration(t *testing.T) {
+	// Migrate up to the default study activity IDs, then set one
+	svc := migratedBefore(t, "0043")
+	for _, q := range []string{
+		"INSERT INTO study_activities (id, name, type, study_session_id, group_id) VALUES (7, 'Typing', 'typing', 0, 0)",
+		"INSERT INTO groups (id, name, default_study_activity_id) VALUES (1, 'N5', 7), (2, 'N4', NULL)",
+	} {
+		if _, err := svc.DB.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The groups keep the type of their default activity
+	if err := service.MigrateDir(svc.DB, filepath.Join("..", "..", "db", "migrations")); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[int]string{1: "typing", 2: ""} {
+		group, err := svc.GetGroupByID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := group.DefaultActivityType; (got == nil) != (want == "") || (got != nil && *got != want) {
+			t.Errorf("group %d default activity type = %v, want %q", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"backend_go/internal/models"
+)
+
+// ErrNoReading is returned when an answer is checked against the reading of a word written with
+// kanji that has none.
+var ErrNoReading = errors.New("word has no reading")
+
+// Scripts a word's japanese text is written in, stored in words.script and matched by the script
+// filter of WordFilter. Text with neither kana nor kanji, such as latin letters only, has none.
+const (
+	// ScriptKana is text in hiragana or katakana only.
+	ScriptKana = "kana"
+	// ScriptKanji is text in kanji only.
+	ScriptKanji = "kanji"
+	// ScriptMixed is text with both kanji and kana, such as 食べる.
+	ScriptMixed = "mixed"
+)
+
+// Scripts lists the values of the script filter.
+var Scripts = []string{ScriptKana, ScriptKanji, ScriptMixed}
+
+// isKana reports whether r is a hiragana or katakana character, the prolonged sound mark ー
+// included.
+func isKana(r rune) bool {
+	return unicode.In(r, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+// WordScript returns the script japanese is written in, one of Scripts, or "" when it has neither
+// kana nor kanji.
+func WordScript(japanese string) string {
+	var kana, kanji bool
+	for _, r := range japanese {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			kanji = true
+		case isKana(r):
+			kana = true
+		}
+	}
+	switch {
+	case kana && kanji:
+		return ScriptMixed
+	case kanji:
+		return ScriptKanji
+	case kana:
+		return ScriptKana
+	}
+	return ""
+}
+
+// NonKana returns the first letter of s that is not kana, and false when there is none. Spaces,
+// punctuation and the middle dot ・ are not letters.
+func NonKana(s string) (rune, bool) {
+	for _, r := range s {
+		if unicode.IsLetter(r) && !isKana(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// FoldKana folds katakana to hiragana and drops whitespace, so that a reading typed in either
+// kana compares equal.
+func FoldKana(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return -1
+		case r >= 'ァ' && r <= 'ヶ':
+			return r - 'ァ' + 'ぁ'
+		}
+		return r
+	}, s)
+}
+
+// kanaSyllables maps hiragana, alone or followed by a small kana, to Hepburn romaji. Katakana is
+// folded to hiragana first.
+var kanaSyllables = map[string]string{
+	"あ": "a", "い": "i", "う": "u", "え": "e", "お": "o",
+	"か": "ka", "き": "ki", "く": "ku", "け": "ke", "こ": "ko",
+	"が": "ga", "ぎ": "gi", "ぐ": "gu", "げ": "ge", "ご": "go",
+	"さ": "sa", "し": "shi", "す": "su", "せ": "se", "そ": "so",
+	"ざ": "za", "じ": "ji", "ず": "zu", "ぜ": "ze", "ぞ": "zo",
+	"た": "ta", "ち": "chi", "つ": "tsu", "て": "te", "と": "to",
+	"だ": "da", "ぢ": "ji", "づ": "zu", "で": "de", "ど": "do",
+	"な": "na", "に": "ni", "ぬ": "nu", "ね": "ne", "の": "no",
+	"は": "ha", "ひ": "hi", "ふ": "fu", "へ": "he", "ほ": "ho",
+	"ば": "ba", "び": "bi", "ぶ": "bu", "べ": "be", "ぼ": "bo",
+	"ぱ": "pa", "ぴ": "pi", "ぷ": "pu", "ぺ": "pe", "ぽ": "po",
+	"ま": "ma", "み": "mi", "む": "mu", "め": "me", "も": "mo",
+	"や": "ya", "ゆ": "yu", "よ": "yo",
+	"ら": "ra", "り": "ri", "る": "ru", "れ": "re", "ろ": "ro",
+	"わ": "wa", "ゐ": "i", "ゑ": "e", "を": "o", "ゔ": "vu",
+	"ぁ": "a", "ぃ": "i", "ぅ": "u", "ぇ": "e", "ぉ": "o",
+	"ゃ": "ya", "ゅ": "yu", "ょ": "yo", "ゎ": "wa",
+
+	"きゃ": "kya", "きゅ": "kyu", "きょ": "kyo", "ぎゃ": "gya", "ぎゅ": "gyu", "ぎょ": "gyo",
+	"しゃ": "sha", "しゅ": "shu", "しょ": "sho", "しぇ": "she",
+	"じゃ": "ja", "じゅ": "ju", "じょ": "jo", "じぇ": "je",
+	"ちゃ": "cha", "ちゅ": "chu", "ちょ": "cho", "ちぇ": "che",
+	"ぢゃ": "ja", "ぢゅ": "ju", "ぢょ": "jo",
+	"にゃ": "nya", "にゅ": "nyu", "にょ": "nyo",
+	"ひゃ": "hya", "ひゅ": "hyu", "ひょ": "hyo", "びゃ": "bya", "びゅ": "byu", "びょ": "byo",
+	"ぴゃ": "pya", "ぴゅ": "pyu", "ぴょ": "pyo",
+	"みゃ": "mya", "みゅ": "myu", "みょ": "myo",
+	"りゃ": "rya", "りゅ": "ryu", "りょ": "ryo",
+	"ふぁ": "fa", "ふぃ": "fi", "ふぇ": "fe", "ふぉ": "fo",
+	"てぃ": "ti", "でぃ": "di", "とぅ": "tu", "どぅ": "du",
+	"うぃ": "wi", "うぇ": "we", "うぉ": "wo",
+	"ゔぁ": "va", "ゔぃ": "vi", "ゔぇ": "ve", "ゔぉ": "vo",
+}
+
+// KanaToRomaji writes kana in Hepburn romaji: っ doubles the next consonant (ch becoming tch), ん
+// is n, written n' before a vowel or y, and ー repeats the previous vowel. It returns "" when s
+// has anything but kana and spaces.
+func KanaToRomaji(s string) string {
+	kana := []rune(FoldKana(s))
+	var b strings.Builder
+	geminate := false
+	for i := 0; i < len(kana); i++ {
+		r := kana[i]
+		switch r {
+		case 'っ':
+			geminate = true
+			continue
+		case 'ん':
+			next := ""
+			if i+1 < len(kana) {
+				next = kanaSyllables[string(kana[i+1])]
+			}
+			b.WriteString("n")
+			if next != "" && strings.ContainsRune("aiueoy", rune(next[0])) {
+				b.WriteString("'")
+			}
+			continue
+		case 'ー':
+			written := b.String()
+			if written == "" || !strings.ContainsRune("aiueo", rune(written[len(written)-1])) {
+				return ""
+			}
+			b.WriteByte(written[len(written)-1])
+			continue
+		}
+
+		syllable, ok := "", false
+		if i+1 < len(kana) {
+			if syllable, ok = kanaSyllables[string(kana[i:i+2])]; ok {
+				i++
+			}
+		}
+		if !ok {
+			if syllable, ok = kanaSyllables[string(r)]; !ok {
+				return ""
+			}
+		}
+		if geminate {
+			if strings.HasPrefix(syllable, "ch") {
+				b.WriteByte('t')
+			} else if !strings.ContainsRune("aiueo", rune(syllable[0])) {
+				b.WriteByte(syllable[0])
+			}
+			geminate = false
+		}
+		b.WriteString(syllable)
+	}
+	return b.String()
+}
+
+// GenerateRomaji returns the romaji of a word without one, written from its reading or, for a word
+// in kana only, from its japanese text. It returns "" when neither is available.
+func GenerateRomaji(japanese, reading string) string {
+	if strings.TrimSpace(reading) != "" {
+		return KanaToRomaji(reading)
+	}
+	if WordScript(japanese) == ScriptKana {
+		return KanaToRomaji(japanese)
+	}
+	return ""
+}
+
+// WordReading returns the kana a word is read as: its reading, or its japanese text when that is
+// kana only. It returns "" when there is neither.
+func WordReading(word *models.Word) string {
+	if word.Reading != nil && *word.Reading != "" {
+		return *word.Reading
+	}
+	if WordScript(word.Japanese) == ScriptKana {
+		return word.Japanese
+	}
+	return ""
+}
+
+// CheckReadingAnswer reports whether the answer is the reading of the word (see WordReading),
+// katakana and hiragana comparing equal. It returns ErrNoReading when the word has no reading.
+func (s *Service) CheckReadingAnswer(wordID int, answer string) (bool, *models.Word, error) {
+	word, err := s.GetWordByID(wordID)
+	if err != nil {
+		return false, nil, err
+	}
+	reading := WordReading(word)
+	if reading == "" {
+		return false, word, ErrNoReading
+	}
+	return FoldKana(answer) == FoldKana(reading), word, nil
+}
+
+// backfillWordScripts fills words.script for the words written before the column existed. The
+// script is found by character ranges in Go, so the migration that adds the column cannot do it.
+func backfillWordScripts(db execQuerier) error {
+	rows, err := db.Query("SELECT id, japanese FROM words")
+	if err != nil {
+		return err
+	}
+	scripts := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var japanese string
+		if err := rows.Scan(&id, &japanese); err != nil {
+			rows.Close()
+			return err
+		}
+		scripts[id] = WordScript(japanese)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, script := range scripts {
+		if _, err := db.Exec("UPDATE words SET script = NULLIF(?, '') WHERE id = ?", script, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+// liveBuffer is how many reviews a subscriber may fall behind before further ones are dropped for it.
+const liveBuffer = 32
+
+// liveHub fans the reviews recorded in a study session out to the subscribers of that session.
+type liveHub struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan models.LiveReview]struct{}
+}
+
+// SubscribeStudySession returns a channel receiving every review recorded in the study session from
+// now on, and a function that unsubscribes and closes it. It returns sql.ErrNoRows if the session
+// does not exist. A subscriber that does not keep up misses reviews rather than blocking ReviewWord.
+func (s *Service) SubscribeStudySession(sessionID int) (<-chan models.LiveReview, func(), error) {
+	if _, err := s.GetStudySessionByID(sessionID); err != nil {
+		return nil, nil, err
+	}
+	ch := make(chan models.LiveReview, liveBuffer)
+
+	s.live.mu.Lock()
+	if s.live.subscribers == nil {
+		s.live.subscribers = make(map[int]map[chan models.LiveReview]struct{})
+	}
+	if s.live.subscribers[sessionID] == nil {
+		s.live.subscribers[sessionID] = make(map[chan models.LiveReview]struct{})
+	}
+	s.live.subscribers[sessionID][ch] = struct{}{}
+	s.live.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.live.mu.Lock()
+			defer s.live.mu.Unlock()
+			delete(s.live.subscribers[sessionID], ch)
+			if len(s.live.subscribers[sessionID]) == 0 {
+				delete(s.live.subscribers, sessionID)
+			}
+			close(ch)
+		})
+	}
+	return ch, unsubscribe, nil
+}
+
+// publishReview broadcasts a review to the subscribers of its study session. The word is only looked
+// up when someone is listening, without holding the hub lock.
+func (s *Service) publishReview(sessionID, wordID int, correct, skipped bool, attempt int) {
+	s.live.mu.Lock()
+	listening := len(s.live.subscribers[sessionID]) > 0
+	s.live.mu.Unlock()
+	if !listening {
+		return
+	}
+	review := models.LiveReview{
+		StudySessionID: sessionID,
+		WordID:         wordID,
+		Correct:        correct,
+		Skipped:        skipped,
+		Attempt:        attempt,
+		ReviewedAt:     time.Now().UTC(),
+	}
+	if word, err := s.GetWordByID(wordID); err == nil {
+		review.Japanese = word.Japanese
+		review.English = word.English
+	}
+
+	s.live.mu.Lock()
+	defer s.live.mu.Unlock()
+	for ch := range s.live.subscribers[sessionID] {
+		select {
+		case ch <- review:
+		default:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"time"
+
+	"backend_go/internal/models"
+)
+
+// OptimizeDB rebuilds the database file with VACUUM, returning the space left behind by deleted
+// rows to the file system, and refreshes the query planner statistics with ANALYZE. It reports the
+// size of the database before and after.
+func (s *Service) OptimizeDB() (*models.OptimizeResult, error) {
+	start := time.Now()
+	result := &models.OptimizeResult{}
+	var err error
+	if result.SizeBeforeBytes, err = s.DatabaseSize(); err != nil {
+		return nil, err
+	}
+	if _, err := s.DB.Exec("VACUUM"); err != nil {
+		return nil, err
+	}
+	if _, err := s.DB.Exec("ANALYZE"); err != nil {
+		return nil, err
+	}
+	if result.SizeAfterBytes, err = s.DatabaseSize(); err != nil {
+		return nil, err
+	}
+	result.ReclaimedBytes = result.SizeBeforeBytes - result.SizeAfterBytes
+	result.DurationMS = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// SchemaVersion returns the number of migrations applied to the database and the name of the
+// latest one, nil when none was.
+func (s *Service) SchemaVersion() (int, *string, error) {
+	var count int
+	var latest *string
+	err := s.DB.QueryRow("SELECT COUNT(*), MAX(name) FROM schema_migrations").Scan(&count, &latest)
+	return count, latest, err
+}
+
+// DatabaseSize returns the size of the main database file in bytes, computed from its page count
+// so that it also works when the file path is not known.
+func (s *Service) DatabaseSize() (int64, error) {
+	var pageCount, pageSize int64
+	if err := s.DB.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, err
+	}
+	if err := s.DB.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pageCount * pageSize, nil
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"unicode"
+
+	"backend_go/internal/models"
+)
+
+var (
+	// ErrDuplicateMeaning is returned when a word is given a meaning it already has.
+	ErrDuplicateMeaning = errors.New("word already has this meaning")
+	// ErrLastMeaning is returned when the only meaning of a word is deleted.
+	ErrLastMeaning = errors.New("cannot delete the only meaning of a word")
+)
+
+// splitMeanings splits an english value holding several meanings separated by ";" or "/", the way
+// they used to be crammed into one string, into the individual meanings.
+func splitMeanings(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '/' })
+}
+
+// wordMeanings combines the english field and the meanings list of a word write into the primary
+// meaning and the full list, primary first. Meanings are trimmed and blank or repeated ones dropped.
+// An english holding several meanings is split by splitMeanings. An empty english takes the first
+// meaning, and an english missing from meanings is put first.
+func wordMeanings(english string, meanings []string) (string, []string) {
+	list := make([]string, 0, len(meanings)+1)
+	seen := make(map[string]bool)
+	add := func(m string) {
+		m = strings.TrimSpace(m)
+		if m != "" && !seen[m] {
+			seen[m] = true
+			list = append(list, m)
+		}
+	}
+	for _, m := range splitMeanings(english) {
+		add(m)
+	}
+	for _, m := range meanings {
+		add(m)
+	}
+	if len(list) == 0 {
+		return "", list
+	}
+	return list[0], list
+}
+
+// setWordMeanings replaces the meanings of a word with the given list, in order.
+func setWordMeanings(db execQuerier, wordID int64, meanings []string) error {
+	if _, err := db.Exec("DELETE FROM word_meanings WHERE word_id = ?", wordID); err != nil {
+		return err
+	}
+	for i, m := range meanings {
+		if _, err := db.Exec("INSERT INTO word_meanings (word_id, meaning, position) VALUES (?, ?, ?)", wordID, m, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertWord inserts a word with its meanings and returns its ID.
+func insertWord(db execQuerier, w models.ImportWord) (int64, error) {
+	english, meanings := wordMeanings(w.English, w.Meanings)
+	if strings.TrimSpace(w.Romaji) == "" {
+		w.Romaji = GenerateRomaji(w.Japanese, w.Reading)
+	}
+	result, err := db.Exec(`INSERT INTO words (japanese, japanese_normalized, reading, script, romaji, romaji_normalized, english, parts, jlpt_level, mnemonic, source, source_id, updated_at)
+	                        VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), CURRENT_TIMESTAMP)`,
+		w.Japanese, NormalizeJapanese(w.Japanese), strings.TrimSpace(w.Reading), WordScript(w.Japanese), w.Romaji, NormalizeRomaji(w.Romaji), english, w.Parts, w.JLPTLevel,
+		strings.TrimSpace(w.Mnemonic), w.Source, w.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, setWordMeanings(db, id, meanings)
+}
+
+// SearchWordsByMeaning retrieves the words with any english meaning containing the query,
+// ignoring ASCII case. With includeMnemonic, words whose mnemonic contains the query match too.
+func (s *Service) SearchWordsByMeaning(query string, includeMnemonic bool) ([]models.Word, error) {
+	pattern := "%" + escapeLike(strings.TrimSpace(query)) + "%"
+	rows, err := s.DB.Query(`SELECT `+wordColumns+` FROM words w
+	                         WHERE w.deleted_at IS NULL
+	                           AND (EXISTS (SELECT 1 FROM word_meanings wm WHERE wm.word_id = w.id AND wm.meaning LIKE ? ESCAPE '\')
+	                                OR (? AND w.mnemonic LIKE ? ESCAPE '\'))
+	                         ORDER BY w.id`, pattern, includeMnemonic, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanWords(rows)
+}
+
+// escapeLike escapes the LIKE wildcards in s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// getWordMeanings retrieves the meanings of a word in order.
+func (s *Service) getWordMeanings(db execQuerier, wordID int) ([]string, error) {
+	rows, err := db.Query("SELECT meaning FROM word_meanings WHERE word_id = ? ORDER BY position", wordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var meanings []string
+	for rows.Next() {
+		var m string
+		if err := rows.Scan(&m); err != nil {
+			return nil, err
+		}
+		meanings = append(meanings, m)
+	}
+	return meanings, rows.Err()
+}
+
+// ListWordMeanings retrieves the meanings of a word in order, or sql.ErrNoRows if the word does not exist.
+func (s *Service) ListWordMeanings(wordID int) ([]models.WordMeaning, error) {
+	return loadWordMeanings(s.DB, wordID)
+}
+
+// AddWordMeaning adds a meaning to a word at position, or after its other meanings when position is
+// nil or past the end. Adding at position 0 makes it the primary meaning. It returns
+// ErrDuplicateMeaning if the word already has the meaning and sql.ErrNoRows if the word does not exist.
+func (s *Service) AddWordMeaning(wordID int, meaning string, position *int) (*models.WordMeaning, error) {
+	meaning = strings.TrimSpace(meaning)
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	meanings, err := loadWordMeanings(tx, wordID)
+	if err != nil {
+		return nil, err
+	}
+	if hasMeaning(meanings, meaning, 0) {
+		return nil, ErrDuplicateMeaning
+	}
+	result, err := tx.Exec("INSERT INTO word_meanings (word_id, meaning, position) VALUES (?, ?, ?)", wordID, meaning, len(meanings))
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	added := models.WordMeaning{ID: int(id), WordID: wordID, Meaning: meaning}
+	meanings = moveMeaning(append(meanings, added), len(meanings), position)
+	if err := saveMeaningOrder(tx, wordID, meanings); err != nil {
+		return nil, err
+	}
+	return findMeaning(meanings, int(id)), tx.Commit()
+}
+
+// UpdateWordMeaning changes the text and/or position of a meaning of a word. Nil arguments are
+// left unchanged. It returns ErrDuplicateMeaning if another meaning of the word has the new text
+// and sql.ErrNoRows if the word or the meaning does not exist.
+func (s *Service) UpdateWordMeaning(wordID, meaningID int, meaning *string, position *int) (*models.WordMeaning, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	meanings, err := loadWordMeanings(tx, wordID)
+	if err != nil {
+		return nil, err
+	}
+	current := findMeaning(meanings, meaningID)
+	if current == nil {
+		return nil, sql.ErrNoRows
+	}
+	if meaning != nil {
+		text := strings.TrimSpace(*meaning)
+		if hasMeaning(meanings, text, meaningID) {
+			return nil, ErrDuplicateMeaning
+		}
+		if _, err := tx.Exec("UPDATE word_meanings SET meaning = ? WHERE id = ?", text, meaningID); err != nil {
+			return nil, err
+		}
+		current.Meaning = text
+	}
+	if position != nil {
+		meanings = moveMeaning(meanings, current.Position, position)
+	}
+	if err := saveMeaningOrder(tx, wordID, meanings); err != nil {
+		return nil, err
+	}
+	return findMeaning(meanings, meaningID), tx.Commit()
+}
+
+// DeleteWordMeaning removes a meaning from a word. Deleting the primary meaning promotes the next
+// one. It returns ErrLastMeaning for the word's only meaning and sql.ErrNoRows if the word or the
+// meaning does not exist.
+func (s *Service) DeleteWordMeaning(wordID, meaningID int) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	meanings, err := loadWordMeanings(tx, wordID)
+	if err != nil {
+		return err
+	}
+	current := findMeaning(meanings, meaningID)
+	if current == nil {
+		return sql.ErrNoRows
+	}
+	if len(meanings) == 1 {
+		return ErrLastMeaning
+	}
+	if _, err := tx.Exec("DELETE FROM word_meanings WHERE id = ?", meaningID); err != nil {
+		return err
+	}
+	meanings = append(meanings[:current.Position], meanings[current.Position+1:]...)
+	if err := saveMeaningOrder(tx, wordID, meanings); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// loadWordMeanings retrieves the meanings of a word in order, or sql.ErrNoRows if the word does not exist.
+func loadWordMeanings(db execQuerier, wordID int) ([]models.WordMeaning, error) {
+	var exists int
+	if err := db.QueryRow("SELECT 1 FROM words WHERE id = ? AND deleted_at IS NULL", wordID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT id, word_id, meaning FROM word_meanings WHERE word_id = ? ORDER BY position", wordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	meanings := make([]models.WordMeaning, 0)
+	for rows.Next() {
+		m := models.WordMeaning{Position: len(meanings)}
+		if err := rows.Scan(&m.ID, &m.WordID, &m.Meaning); err != nil {
+			return nil, err
+		}
+		meanings = append(meanings, m)
+	}
+	return meanings, rows.Err()
+}
+
+// hasMeaning reports whether a meaning other than the one with ID exceptID has the given text.
+func hasMeaning(meanings []models.WordMeaning, text string, exceptID int) bool {
+	for _, m := range meanings {
+		if m.ID != exceptID && m.Meaning == text {
+			return true
+		}
+	}
+	return false
+}
+
+// findMeaning returns the meaning with the given ID, or nil.
+func findMeaning(meanings []models.WordMeaning, id int) *models.WordMeaning {
+	for i := range meanings {
+		if meanings[i].ID == id {
+			return &meanings[i]
+		}
+	}
+	return nil
+}
+
+// moveMeaning moves the meaning at index from to position, clamped to the list. A nil position
+// leaves the list unchanged.
+func moveMeaning(meanings []models.WordMeaning, from int, position *int) []models.WordMeaning {
+	if position == nil {
+		return meanings
+	}
+	to := *position
+	if to < 0 {
+		to = 0
+	}
+	if to > len(meanings)-1 {
+		to = len(meanings) - 1
+	}
+	m := meanings[from]
+	meanings = append(meanings[:from], meanings[from+1:]...)
+	meanings = append(meanings[:to], append([]models.WordMeaning{m}, meanings[to:]...)...)
+	return meanings
+}
+
+// saveMeaningOrder renumbers the meanings of a word in the order given, sets their Position, and
+// copies the primary meaning to words.english.
+func saveMeaningOrder(db execQuerier, wordID int, meanings []models.WordMeaning) error {
+	// Positions are moved out of the way first so that (word_id, position) stays unique
+	if _, err := db.Exec("UPDATE word_meanings SET position = -1 - position WHERE word_id = ?", wordID); err != nil {
+		return err
+	}
+	for i := range meanings {
+		meanings[i].Position = i
+		if _, err := db.Exec("UPDATE word_meanings SET position = ? WHERE id = ?", i, meanings[i].ID); err != nil {
+			return err
+		}
+	}
+	english := ""
+	if len(meanings) > 0 {
+		english = meanings[0].Meaning
+	}
+	_, err := db.Exec("UPDATE words SET english = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", english, wordID)
+	return err
+}
+
+// normalizeMeaning folds an english meaning or answer into the form answers are compared in: lower
+// case, parenthesized notes such as "(archaic)" and punctuation removed, a leading "to " of verbs
+// dropped, and whitespace collapsed.
+func normalizeMeaning(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth > 0:
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	fields := strings.Fields(b.String())
+	if len(fields) > 1 && fields[0] == "to" {
+		fields = fields[1:]
+	}
+	return strings.Join(fields, " ")
+}
+
+// CheckMeaningAnswer reports whether the answer matches any english meaning of the word, compared
+// in normalized form, and returns the meaning it matched.
+func (s *Service) CheckMeaningAnswer(wordID int, answer string) (bool, string, *models.Word, error) {
+	word, err := s.GetWordByID(wordID)
+	if err != nil {
+		return false, "", nil, err
+	}
+	normalized := normalizeMeaning(answer)
+	if normalized == "" {
+		return false, "", word, nil
+	}
+	for _, m := range word.Meanings {
+		if normalizeMeaning(m) == normalized {
+			return true, m, word, nil
+		}
+	}
+	return false, "", word, nil
+}
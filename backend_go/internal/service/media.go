@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"backend_go/internal/media"
+	"backend_go/internal/models"
+	"backend_go/internal/tts"
+)
+
+var (
+	// ErrMediaDisabled is returned by media operations when no media store has been configured.
+	ErrMediaDisabled = errors.New("media storage is not configured")
+	// ErrTTSDisabled is returned by GenerateWordAudio when no synthesizer has been configured.
+	ErrTTSDisabled = errors.New("text-to-speech is not configured")
+)
+
+// mediaColumns maps each media kind to the words column holding the file name.
+var mediaColumns = map[string]string{
+	media.Image.Name: "image_path",
+	media.Audio.Name: "audio_path",
+}
+
+// EnableMedia stores word images and audio in the given store.
+func (s *Service) EnableMedia(store *media.Store) {
+	s.media = store
+}
+
+// MediaStore returns the configured media store, or nil.
+func (s *Service) MediaStore() *media.Store {
+	return s.media
+}
+
+// EnableTTS generates word audio with the given synthesizer.
+func (s *Service) EnableTTS(synth tts.Synthesizer) {
+	s.tts = synth
+}
+
+// SetWordMedia stores data as the image or audio of a word, depending on kind, replacing the
+// previous file. It returns media.ErrUnsupportedType when data is not of an accepted type and
+// sql.ErrNoRows when the word does not exist.
+func (s *Service) SetWordMedia(wordID int, kind media.Kind, data []byte) (*models.Word, error) {
+	if s.media == nil {
+		return nil, ErrMediaDisabled
+	}
+	column := mediaColumns[kind.Name]
+	var previous sql.NullString
+	if err := s.DB.QueryRow("SELECT "+column+" FROM words WHERE id = ? AND deleted_at IS NULL", wordID).Scan(&previous); err != nil {
+		return nil, err
+	}
+	name, err := s.media.Save(kind, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.DB.Exec("UPDATE words SET "+column+" = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", name, wordID); err != nil {
+		return nil, err
+	}
+	if previous.String != name {
+		s.releaseMedia(previous.String)
+	}
+	return s.GetWordByID(wordID)
+}
+
+// RemoveWordMedia removes the image or audio of a word, depending on kind. It returns
+// sql.ErrNoRows when the word does not exist or has no such file.
+func (s *Service) RemoveWordMedia(wordID int, kind media.Kind) error {
+	column := mediaColumns[kind.Name]
+	var previous sql.NullString
+	err := s.DB.QueryRow("SELECT "+column+" FROM words WHERE id = ? AND deleted_at IS NULL", wordID).Scan(&previous)
+	if err != nil {
+		return err
+	}
+	if !previous.Valid {
+		return sql.ErrNoRows
+	}
+	if _, err := s.DB.Exec("UPDATE words SET "+column+" = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?", wordID); err != nil {
+		return err
+	}
+	s.releaseMedia(previous.String)
+	return nil
+}
+
+// GenerateWordAudio synthesizes the pronunciation of a word's japanese text and stores it as the
+// word's audio. The synthesizer is abandoned when ctx is done.
+func (s *Service) GenerateWordAudio(ctx context.Context, wordID int) (*models.Word, error) {
+	if s.tts == nil {
+		return nil, ErrTTSDisabled
+	}
+	if s.media == nil {
+		return nil, ErrMediaDisabled
+	}
+	word, err := s.GetWordByID(wordID)
+	if err != nil {
+		return nil, err
+	}
+	audio, err := s.tts.Synthesize(ctx, word.Japanese)
+	if err != nil {
+		return nil, err
+	}
+	return s.SetWordMedia(wordID, media.Audio, audio)
+}
+
+// clearWordMedia detaches the media files of the given words, as done when the words are deleted,
+// and returns the names of the detached files for releaseMedia once the change is committed.
+func clearWordMedia(db execQuerier, ids []int) ([]string, error) {
+	placeholders, args := inPlaceholders(ids)
+	rows, err := db.Query("SELECT image_path, audio_path FROM words WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var image, audio sql.NullString
+		if err := rows.Scan(&image, &audio); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, image.String, audio.String)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec("UPDATE words SET image_path = NULL, audio_path = NULL WHERE id IN ("+placeholders+")", args...); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// releaseMedia deletes the named files unless another word still uses them. Files are shared
+// because they are named after their content. Failures are only logged since the database no
+// longer refers to the files.
+func (s *Service) releaseMedia(names ...string) {
+	if s.media == nil {
+		return
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		var users int
+		if err := s.DB.QueryRow("SELECT COUNT(*) FROM words WHERE image_path = ? OR audio_path = ?", name, name).Scan(&users); err != nil {
+			log.Printf("Checking media file %s: %v", name, err)
+			continue
+		}
+		if users > 0 {
+			continue
+		}
+		if err := s.media.Remove(name); err != nil {
+			log.Printf("Removing media file %s: %v", name, err)
+		}
+	}
+}
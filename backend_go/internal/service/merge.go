@@ -0,0 +1,208 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+
+	"backend_go/internal/models"
+)
+
+// ErrMergeSameWord is returned when a word is merged into itself.
+var ErrMergeSameWord = errors.New("cannot merge a word into itself")
+
+// NormalizeJapanese folds the japanese text of a word into the form used to detect duplicates:
+// full-width latin letters and half-width katakana are folded to their canonical widths,
+// whitespace is removed, and latin letters are lower-cased.
+func NormalizeJapanese(s string) string {
+	s = width.Fold.String(s)
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// backfillJapaneseNormalized fills words.japanese_normalized, added by 0039_japanese_normalized.sql,
+// for the words that existed before it.
+func backfillJapaneseNormalized(db execQuerier) error {
+	rows, err := db.Query("SELECT id, japanese FROM words")
+	if err != nil {
+		return err
+	}
+	normalized := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var japanese string
+		if err := rows.Scan(&id, &japanese); err != nil {
+			rows.Close()
+			return err
+		}
+		normalized[id] = NormalizeJapanese(japanese)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, key := range normalized {
+		if _, err := db.Exec("UPDATE words SET japanese_normalized = ? WHERE id = ?", key, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindDuplicateWords groups the words that look like the same entry entered twice: words sharing
+// their normalized japanese text, and words sharing both their normalized romaji and english
+// meaning (the same word written in kana once and in kanji once). Normalized is the normalized
+// japanese text of the first word of each group.
+func (s *Service) FindDuplicateWords() ([]models.DuplicateWords, error) {
+	words, err := s.GetWords(nil, WordFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Union-find over word indexes, joining words that share either key
+	parent := make([]int, len(words))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	firstByKey := make(map[string]int)
+	join := func(key string, i int) {
+		if first, ok := firstByKey[key]; ok {
+			parent[find(i)] = find(first)
+			return
+		}
+		firstByKey[key] = i
+	}
+	for i, w := range words {
+		join("japanese:"+NormalizeJapanese(w.Japanese), i)
+		if romaji := NormalizeRomaji(w.Romaji); romaji != "" {
+			join("romaji:"+romaji+"|"+strings.ToLower(strings.TrimSpace(w.English)), i)
+		}
+	}
+
+	byRoot := make(map[int][]models.Word)
+	for i, w := range words {
+		root := find(i)
+		byRoot[root] = append(byRoot[root], w)
+	}
+	duplicates := make([]models.DuplicateWords, 0)
+	for _, group := range byRoot {
+		if len(group) > 1 {
+			duplicates = append(duplicates, models.DuplicateWords{Normalized: NormalizeJapanese(group[0].Japanese), Words: group})
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].Words[0].ID < duplicates[j].Words[0].ID
+	})
+	return duplicates, nil
+}
+
+// MergeWords folds the source word into the target word in one transaction: the source's reviews,
+// group memberships, tags, relations and examples are re-pointed at the target (the ones the target already
+// has are dropped), the target's schedule is recomputed, and the source is soft-deleted. Reviews of
+// both words in the same session are kept as successive attempts. It returns ErrMergeSameWord if the IDs are equal and sql.ErrNoRows
+// if either word does not exist.
+func (s *Service) MergeWords(targetID, sourceID int) (*models.WordMergeResult, error) {
+	if targetID == sourceID {
+		return nil, ErrMergeSameWord
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var found int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM words WHERE id IN (?, ?) AND deleted_at IS NULL", targetID, sourceID).Scan(&found); err != nil {
+		return nil, err
+	}
+	if found != 2 {
+		return nil, sql.ErrNoRows
+	}
+
+	result := &models.WordMergeResult{TargetWordID: targetID, SourceWordID: sourceID}
+	exec := func(dest *int, query string, args ...interface{}) error {
+		res, err := tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+		if dest != nil {
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			*dest = int(n)
+		}
+		return nil
+	}
+
+	// Shift the source's attempts past the target's in sessions where both were reviewed,
+	// keeping (study_session_id, word_id, attempt) unique
+	if err := exec(&result.ReviewsMoved, `UPDATE word_review_items
+	    SET word_id = ?,
+	        attempt = attempt + (SELECT COALESCE(MAX(t.attempt), 0) FROM word_review_items t
+	                             WHERE t.word_id = ? AND t.study_session_id = word_review_items.study_session_id)
+	    WHERE word_id = ?`, targetID, targetID, sourceID); err != nil {
+		return nil, err
+	}
+	if err := exec(&result.GroupLinksMoved, `UPDATE word_groups SET word_id = ?
+	    WHERE word_id = ? AND group_id NOT IN (SELECT group_id FROM word_groups WHERE word_id = ?)`, targetID, sourceID, targetID); err != nil {
+		return nil, err
+	}
+	if err := exec(&result.GroupLinksDropped, "DELETE FROM word_groups WHERE word_id = ?", sourceID); err != nil {
+		return nil, err
+	}
+	if err := exec(&result.TagsMoved, "UPDATE OR IGNORE word_tags SET word_id = ? WHERE word_id = ?", targetID, sourceID); err != nil {
+		return nil, err
+	}
+	if err := exec(nil, "DELETE FROM word_tags WHERE word_id = ?", sourceID); err != nil {
+		return nil, err
+	}
+	if err := moveWordRelations(tx, targetID, sourceID); err != nil {
+		return nil, err
+	}
+	if err := exec(nil, "UPDATE word_examples SET word_id = ? WHERE word_id = ?", targetID, sourceID); err != nil {
+		return nil, err
+	}
+	// The target's schedules are replayed from the combined review history of each learner
+	if err := exec(nil, "DELETE FROM word_srs WHERE word_id = ?", sourceID); err != nil {
+		return nil, err
+	}
+	if err := updateSchedules(tx, targetID); err != nil {
+		return nil, err
+	}
+	if err := exec(nil, "UPDATE words SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ?", sourceID); err != nil {
+		return nil, err
+	}
+	// The target keeps its own image and audio and takes the source's only where it has none
+	if err := exec(nil, `UPDATE words SET image_path = COALESCE(image_path, (SELECT image_path FROM words WHERE id = ?)),
+	                     audio_path = COALESCE(audio_path, (SELECT audio_path FROM words WHERE id = ?)),
+	                     updated_at = CURRENT_TIMESTAMP WHERE id = ?`, sourceID, sourceID, targetID); err != nil {
+		return nil, err
+	}
+	files, err := clearWordMedia(tx, []int{sourceID})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.releaseMedia(files...)
+	return result, nil
+}
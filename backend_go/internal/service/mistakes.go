@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"backend_go/internal/models"
+)
+
+// Orders of the mistakes log.
+const (
+	// MistakeSortRecency lists every wrong answer, newest first.
+	MistakeSortRecency = "recency"
+	// MistakeSortFrequency lists each word answered wrong once, most missed first.
+	MistakeSortFrequency = "frequency"
+)
+
+// ErrNoMistakes is returned when a mistakes study session is requested but nothing was answered wrong.
+var ErrNoMistakes = errors.New("no mistakes to study")
+
+// mistakesSQL returns a WITH clause naming m the learner's wrong answers (not skipped) to live words
+// matching filter, with their word's number of misses and recent, 1 for the latest wrong answer to
+// each word, and its arguments.
+func (s *Service) mistakesSQL(filter models.MistakeFilter) (string, []interface{}) {
+	conds := []string{"NOT wr.correct", "NOT wr.skipped", s.userCond("wr")}
+	var args []interface{}
+	if !filter.From.IsZero() {
+		conds = append(conds, "wr.created_at >= ?")
+		args = append(args, filter.From.UTC().Format(sqliteTimeFormat))
+	}
+	if !filter.To.IsZero() {
+		conds = append(conds, "wr.created_at <= ?")
+		args = append(args, filter.To.UTC().Format(sqliteTimeFormat))
+	}
+	if filter.GroupID > 0 {
+		conds = append(conds, "ss.group_id = ?")
+		args = append(args, filter.GroupID)
+	}
+	return `WITH m AS (
+	            SELECT wr.id, wr.word_id, wr.study_session_id, wr.created_at, wr.answer,
+	                   COUNT(*) OVER (PARTITION BY wr.word_id) AS misses,
+	                   ROW_NUMBER() OVER (PARTITION BY wr.word_id ORDER BY wr.created_at DESC, wr.id DESC) AS recent
+	            FROM word_review_items wr
+	            JOIN words w ON w.id = wr.word_id AND w.deleted_at IS NULL
+	            LEFT JOIN study_sessions ss ON ss.id = wr.study_session_id
+	            WHERE ` + strings.Join(conds, " AND ") + `
+	        ) `, args
+}
+
+// ListMistakes retrieves one page of the mistakes log matching filter in the order sortBy, one of
+// MistakeSortRecency and MistakeSortFrequency, along with the total number of rows. Frequency ties
+// are broken by the latest miss.
+func (s *Service) ListMistakes(filter models.MistakeFilter, sortBy string, page, perPage int) ([]models.Mistake, int, error) {
+	with, args := s.mistakesSQL(filter)
+	where, order := "", "m.created_at DESC, m.id DESC"
+	answer := "m.answer"
+	if sortBy == MistakeSortFrequency {
+		where, order = "WHERE m.recent = 1", "m.misses DESC, m.created_at DESC, m.id DESC"
+		answer = "(SELECT a.answer FROM m a WHERE a.word_id = m.word_id AND a.answer IS NOT NULL ORDER BY a.recent LIMIT 1)"
+	}
+
+	var total int
+	if err := s.DB.QueryRow(with+"SELECT COUNT(*) FROM m "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := with + `SELECT ` + wordColumns + `, m.misses, m.id, m.study_session_id, m.created_at, ` + answer + `
+	                 FROM m
+	                 JOIN words w ON w.id = m.word_id
+	                 ` + where + `
+	                 ORDER BY ` + order + `
+	                 LIMIT ? OFFSET ?`
+	rows, err := s.DB.Query(query, append(args, perPage, (page-1)*perPage)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	mistakes := make([]models.Mistake, 0)
+	for rows.Next() {
+		var m models.Mistake
+		if err := scanWord(rows, &m.Word, &m.Misses, &m.ReviewID, &m.StudySessionID, &m.MissedAt, &m.Answer); err != nil {
+			return nil, 0, err
+		}
+		mistakes = append(mistakes, m)
+	}
+	return mistakes, total, rows.Err()
+}
+
+// CreateMistakesSession starts a study session for the group filter.GroupID whose queue holds the
+// limit words most often answered wrong in the range of filter, most missed first. A zero
+// studyActivityID uses the group's default study activity, as in CreateStudySession. It returns the
+// new session's ID and the number of words queued, or ErrNoMistakes if there is nothing to queue.
+func (s *Service) CreateMistakesSession(filter models.MistakeFilter, studyActivityID, limit int) (int64, int, error) {
+	mistakes, _, err := s.ListMistakes(filter, MistakeSortFrequency, 1, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(mistakes) == 0 {
+		return 0, 0, ErrNoMistakes
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := s.createStudySession(tx, filter.GroupID, studyActivityID)
+	if err != nil {
+		return 0, 0, err
+	}
+	for i, m := range mistakes {
+		if _, err := tx.Exec("INSERT INTO study_session_queue (study_session_id, word_id, position) VALUES (?, ?, ?)",
+			id, m.ID, i+1); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return id, len(mistakes), nil
+}
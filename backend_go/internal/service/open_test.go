@@ -0,0 +1,47 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backend_go/internal/config"
+	"backend_go/internal/service"
+)
+
+func TestNewServiceMigrationFailure(t *testing.T) {
+	// Migrations are looked up from the working directory
+	dir := t.TempDir()
+	migrations := filepath.Join(dir, "db", "migrations")
+	if err := os.MkdirAll(migrations, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	scripts := map[string]string{
+		"0001_words.sql":  "CREATE TABLE words (id INTEGER PRIMARY KEY)",
+		"0002_broken.sql": "ALTER TABLE missing ADD COLUMN name TEXT",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(migrations, name), []byte(script), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cfg := &config.Config{DBPath: filepath.Join(dir, "words.db"), MediaDir: filepath.Join(dir, "media")}
+	svc, err := service.NewService(cfg)
+	if err == nil {
+		svc.Close()
+		t.Fatal("NewService succeeded on a migration that does not apply")
+	}
+	if !strings.Contains(err.Error(), "0002_broken.sql") {
+		t.Errorf("error %q does not name the failed migration", err)
+	}
+}
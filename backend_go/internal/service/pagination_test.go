@@ -0,0 +1,169 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+	"backend_go/internal/testutil"
+)
+
+// pageThrough fetches every page of a cursor listing with fetch, calling between before each page
+// after the first, and returns the IDs listed in order. It fails the test if a page is listed more
+// than 20 times, as a listing that never ends would be.
+func pageThrough(t *testing.T, fetch func(cursor string) ([]int, *string, error), between func(page int)) []int {
+	t.Helper()
+	var ids []int
+	cursor := ""
+	for page := 1; page <= 20; page++ {
+		if page > 1 {
+			between(page)
+		}
+		pageIDs, next, err := fetch(cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, pageIDs...)
+		if next == nil {
+			return ids
+		}
+		cursor = *next
+	}
+	t.Fatal("the listing did not end after 20 pages")
+	return nil
+}
+
+// checkListed checks that ids lists each of want once and in order, and none of absent.
+func checkListed(t *testing.T, listing string, ids, want, absent []int) {
+	t.Helper()
+	seen := make(map[int]int)
+	for _, id := range ids {
+		seen[id]++
+	}
+	for id, n := range seen {
+		if n > 1 {
+			t.Errorf("%s listed %d %d times: %v", listing, id, n, ids)
+		}
+	}
+	for _, id := range absent {
+		if seen[id] > 0 {
+			t.Errorf("%s listed %d, which was added behind the cursor: %v", listing, id, ids)
+		}
+	}
+	if fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Errorf("%s listed %v, want %v", listing, ids, want)
+	}
+}
+
+func TestGetWordsByCursorWhileInserting(t *testing.T) {
+	svc := testutil.NewService(t)
+	f := testutil.NewFixture(t, svc.DB).Group("N5")
+	// Romaji with ties, so the ID decides between the words of a romaji
+	for i, romaji := range []string{"ame", "ame", "hana", "hana", "hana", "kami", "mizu", "sora", "sora", "yama"} {
+		f.Word(fmt.Sprintf("語%d", i), romaji, "word")
+	}
+	sort := service.WordSort{{Field: "romaji"}}
+	words := func(cursor string) ([]int, *string, error) {
+		page, next, err := svc.GetWordsByCursor(cursor, 3, sort, service.WordFilter{})
+		var ids []int
+		for _, w := range page {
+			ids = append(ids, w.ID)
+		}
+		return ids, next, err
+	}
+	all := pageThrough(t, words, func(int) {})
+
+	// While paging, add a word sorting before each page, one tied with the last word listed and
+	// one sorting after every word
+	var behind, ahead []int
+	listed := pageThrough(t, words, func(page int) {
+		f.Word(fmt.Sprintf("前%d", page), "aa", "word")
+		behind = append(behind, f.WordID(fmt.Sprintf("前%d", page)))
+		if page == 2 {
+			// Ties with the hana words, one of which ended the first page, and sorts after them by ID
+			f.Word("花", "hana", "flower")
+			ahead = append(ahead, f.WordID("花"))
+		}
+		f.Word(fmt.Sprintf("後%d", page), "zzz", "word")
+		ahead = append(ahead, f.WordID(fmt.Sprintf("後%d", page)))
+	})
+
+	var want []int
+	for _, id := range all {
+		want = append(want, id)
+		// The tied hana follows the last one already listed
+		if id == all[4] {
+			want = append(want, ahead[0])
+		}
+	}
+	want = append(want, ahead[1:]...)
+	checkListed(t, "words", listed, want, behind)
+}
+
+func TestListReviewsByCursorWhileInserting(t *testing.T) {
+	svc := testutil.NewService(t)
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	f := testutil.NewFixture(t, svc.DB).Group("N5").Word("水", "mizu", "water").SessionAt(start)
+	// Pairs of reviews made in the same second, so the ID decides within each pair
+	for i := 0; i < 10; i++ {
+		f.ReviewAt(i%3 == 0, start.Add(time.Duration(i/2)*time.Minute))
+	}
+	original := f.ReviewIDs()
+	reviews := func(cursor string) ([]int, *string, error) {
+		page, next, err := svc.ListReviewsByCursor(models.ReviewFilter{}, cursor, 4)
+		var ids []int
+		for _, r := range page {
+			ids = append(ids, r.ID)
+		}
+		return ids, next, err
+	}
+
+	// Newer reviews made while paging sort before the cursor, older ones after it
+	var behind []int
+	var older []int
+	listed := pageThrough(t, reviews, func(page int) {
+		f.ReviewAt(true, start.Add(time.Hour))
+		ids := f.ReviewIDs()
+		behind = append(behind, ids[len(ids)-1])
+		f.ReviewAt(false, start.Add(-time.Duration(page)*time.Minute))
+		ids = f.ReviewIDs()
+		older = append(older, ids[len(ids)-1])
+	})
+
+	// Newest first: within a second the higher ID comes first
+	var want []int
+	for i := len(original) - 1; i >= 0; i-- {
+		want = append(want, original[i])
+	}
+	want = append(want, older...)
+	checkListed(t, "reviews", listed, want, behind)
+}
+
+func TestListStudySessionsByCursorWhileInserting(t *testing.T) {
+	svc := testutil.NewService(t)
+	f := testutil.NewFixture(t, svc.DB).Group("N5")
+	for i := 0; i < 7; i++ {
+		f.Session()
+	}
+	original := f.SessionIDs()
+	sessions := func(cursor string) ([]int, *string, error) {
+		page, next, err := svc.ListStudySessionsByCursor(models.StudySessionFilter{}, cursor, 3)
+		var ids []int
+		for _, s := range page {
+			ids = append(ids, s.ID)
+		}
+		return ids, next, err
+	}
+
+	// Sessions started while paging come after the others, and removing listed ones shifts nothing
+	listed := pageThrough(t, sessions, func(page int) {
+		f.Session()
+		if _, err := svc.DB.Exec("DELETE FROM study_sessions WHERE id = ?", original[0]); err != nil {
+			t.Fatal(err)
+		}
+	})
+	all := f.SessionIDs()
+	checkListed(t, "study sessions", listed, all, nil)
+}
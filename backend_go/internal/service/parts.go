@@ -0,0 +1,292 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"backend_go/internal/models"
+)
+
+// Values allowed by the parts schema. A word's parts is a JSON object whose part_of_speech is one
+// of PartsOfSpeech. Verbs may give a verb_class and a transitivity, adjectives an adjective_type,
+// and any word a list of usage tags, such as "polite" or "written".
+var (
+	PartsOfSpeech  = []string{"noun", "verb", "adjective", "adverb", "particle", "pronoun", "conjunction", "interjection", "counter", "numeral", "prefix", "suffix", "expression"}
+	VerbClasses    = []string{"ichidan", "godan", "suru", "kuru"}
+	Transitivities = []string{"transitive", "intransitive", "both"}
+	AdjectiveTypes = []string{"i", "na", "no"}
+)
+
+// Limits of the usage tags of a word's parts.
+const (
+	maxUsageTags      = 10
+	maxUsageTagLength = 30
+)
+
+// PartsError reports why parts do not follow the schema: the keys it does not define and the
+// problems with the values of the others, by key. Problems with the parts as a whole are under "".
+type PartsError struct {
+	Unknown []string
+	Invalid map[string]string
+}
+
+func (e *PartsError) Error() string {
+	var problems []string
+	if len(e.Unknown) > 0 {
+		problems = append(problems, "unknown keys "+strings.Join(e.Unknown, ", "))
+	}
+	keys := make([]string, 0, len(e.Invalid))
+	for key := range e.Invalid {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "" {
+			problems = append(problems, e.Invalid[key])
+		} else {
+			problems = append(problems, key+" "+e.Invalid[key])
+		}
+	}
+	return "invalid parts: " + strings.Join(problems, "; ")
+}
+
+// ParseParts checks the parts JSON of a word against the schema and returns it in canonical form:
+// an object with sorted keys and trimmed, lower-cased values. Empty parts and null are returned as
+// "". Keys the schema does not define are an error unless allowUnknown, in which case they are kept
+// as given. Otherwise it returns a *PartsError.
+func ParseParts(raw string, allowUnknown bool) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return "", nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil || fields == nil {
+		return "", &PartsError{Invalid: map[string]string{"": "must be a JSON object"}}
+	}
+
+	perr := &PartsError{Invalid: make(map[string]string)}
+	parts := make(map[string]interface{}, len(fields))
+	enum := func(key string, allowed []string) {
+		value, ok := fields[key]
+		if !ok {
+			return
+		}
+		var s string
+		if json.Unmarshal(value, &s) == nil {
+			s = strings.ToLower(strings.TrimSpace(s))
+			for _, a := range allowed {
+				if s == a {
+					parts[key] = s
+					return
+				}
+			}
+		}
+		perr.Invalid[key] = "must be one of " + strings.Join(allowed, ", ")
+	}
+	enum("part_of_speech", PartsOfSpeech)
+	if _, ok := fields["part_of_speech"]; !ok {
+		perr.Invalid["part_of_speech"] = "is required"
+	}
+	for key, pos := range map[string]string{"verb_class": "verb", "transitivity": "verb", "adjective_type": "adjective"} {
+		if _, ok := fields[key]; ok && parts["part_of_speech"] != nil && parts["part_of_speech"] != pos {
+			perr.Invalid[key] = "is only allowed for a " + pos
+		}
+	}
+	enum("verb_class", VerbClasses)
+	enum("transitivity", Transitivities)
+	enum("adjective_type", AdjectiveTypes)
+	if value, ok := fields["usage"]; ok {
+		var tags []string
+		if err := json.Unmarshal(value, &tags); err != nil {
+			perr.Invalid["usage"] = "must be a list of strings"
+		} else if usage, problem := cleanUsageTags(tags); problem != "" {
+			perr.Invalid["usage"] = problem
+		} else if len(usage) > 0 {
+			parts["usage"] = usage
+		}
+	}
+
+	for key, value := range fields {
+		switch key {
+		case "part_of_speech", "verb_class", "transitivity", "adjective_type", "usage":
+		default:
+			if allowUnknown {
+				parts[key] = value
+			} else {
+				perr.Unknown = append(perr.Unknown, key)
+			}
+		}
+	}
+	sort.Strings(perr.Unknown)
+	if len(perr.Unknown) > 0 || len(perr.Invalid) > 0 {
+		return "", perr
+	}
+	b, err := json.Marshal(parts)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// cleanUsageTags trims, lower-cases and de-duplicates usage tags, returning a problem when they
+// are too many or too long.
+func cleanUsageTags(tags []string) ([]string, string) {
+	usage := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > maxUsageTagLength {
+			return nil, fmt.Sprintf("tags must be at most %d characters", maxUsageTagLength)
+		}
+		seen[tag] = true
+		usage = append(usage, tag)
+	}
+	if len(usage) > maxUsageTags {
+		return nil, fmt.Sprintf("must have at most %d tags", maxUsageTags)
+	}
+	return usage, ""
+}
+
+// legacyPartsOfSpeech maps the parts of speech found in freeform parts, lower-cased, to the
+// canonical parts they stand for.
+var legacyPartsOfSpeech = map[string]map[string]string{
+	"n":            {"part_of_speech": "noun"},
+	"noun":         {"part_of_speech": "noun"},
+	"名詞":           {"part_of_speech": "noun"},
+	"v":            {"part_of_speech": "verb"},
+	"verb":         {"part_of_speech": "verb"},
+	"動詞":           {"part_of_speech": "verb"},
+	"v1":           {"part_of_speech": "verb", "verb_class": "ichidan"},
+	"ichidan verb": {"part_of_speech": "verb", "verb_class": "ichidan"},
+	"ru-verb":      {"part_of_speech": "verb", "verb_class": "ichidan"},
+	"v5":           {"part_of_speech": "verb", "verb_class": "godan"},
+	"godan verb":   {"part_of_speech": "verb", "verb_class": "godan"},
+	"u-verb":       {"part_of_speech": "verb", "verb_class": "godan"},
+	"vs":           {"part_of_speech": "verb", "verb_class": "suru"},
+	"suru verb":    {"part_of_speech": "verb", "verb_class": "suru"},
+	"adj":          {"part_of_speech": "adjective"},
+	"adjective":    {"part_of_speech": "adjective"},
+	"i-adjective":  {"part_of_speech": "adjective", "adjective_type": "i"},
+	"adj-i":        {"part_of_speech": "adjective", "adjective_type": "i"},
+	"い形容詞":         {"part_of_speech": "adjective", "adjective_type": "i"},
+	"na-adjective": {"part_of_speech": "adjective", "adjective_type": "na"},
+	"adj-na":       {"part_of_speech": "adjective", "adjective_type": "na"},
+	"な形容詞":         {"part_of_speech": "adjective", "adjective_type": "na"},
+	"adv":          {"part_of_speech": "adverb"},
+	"adverb":       {"part_of_speech": "adverb"},
+	"副詞":           {"part_of_speech": "adverb"},
+	"prt":          {"part_of_speech": "particle"},
+	"particle":     {"part_of_speech": "particle"},
+	"助詞":           {"part_of_speech": "particle"},
+	"pn":           {"part_of_speech": "pronoun"},
+	"pronoun":      {"part_of_speech": "pronoun"},
+	"conj":         {"part_of_speech": "conjunction"},
+	"conjunction":  {"part_of_speech": "conjunction"},
+	"int":          {"part_of_speech": "interjection"},
+	"interjection": {"part_of_speech": "interjection"},
+	"ctr":          {"part_of_speech": "counter"},
+	"counter":      {"part_of_speech": "counter"},
+	"num":          {"part_of_speech": "numeral"},
+	"numeral":      {"part_of_speech": "numeral"},
+	"number":       {"part_of_speech": "numeral"},
+	"prefix":       {"part_of_speech": "prefix"},
+	"suffix":       {"part_of_speech": "suffix"},
+	"exp":          {"part_of_speech": "expression"},
+	"expression":   {"part_of_speech": "expression"},
+	"phrase":       {"part_of_speech": "expression"},
+}
+
+// normalizeLegacyParts converts freeform parts to the schema where that loses nothing: parts that
+// already follow it, and parts naming a single known part of speech as a string, a one-item list,
+// or an object with only a "type" or "part_of_speech" field. It returns false for anything else.
+func normalizeLegacyParts(raw string) (string, bool) {
+	if parts, err := ParseParts(raw, false); err == nil {
+		return parts, true
+	}
+	var obj map[string]interface{}
+	if json.Unmarshal([]byte(raw), &obj) == nil && len(obj) != 1 {
+		return "", false
+	}
+	pos := partsOfSpeech(raw)
+	if len(pos) != 1 || legacyPartsOfSpeech[pos[0]] == nil {
+		return "", false
+	}
+	b, err := json.Marshal(legacyPartsOfSpeech[pos[0]])
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// normalizeWordParts rewrites the parts of the words in the canonical form of the schema where
+// normalizeLegacyParts can. The rest are left as they are and show up in GetWordsWithInvalidParts.
+// It runs once, when the migration introducing the schema is applied.
+func normalizeWordParts(db execQuerier) error {
+	rows, err := db.Query("SELECT id, parts FROM words WHERE COALESCE(parts, '') <> ''")
+	if err != nil {
+		return err
+	}
+	normalized := make(map[int]string)
+	flagged := 0
+	for rows.Next() {
+		var id int
+		var parts string
+		if err := rows.Scan(&id, &parts); err != nil {
+			rows.Close()
+			return err
+		}
+		if canonical, ok := normalizeLegacyParts(parts); !ok {
+			flagged++
+		} else if canonical != parts {
+			normalized[id] = canonical
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, parts := range normalized {
+		if _, err := db.Exec("UPDATE words SET parts = NULLIF(?, '') WHERE id = ?", parts, id); err != nil {
+			return err
+		}
+	}
+	log.Printf("Normalized the parts of %d words, %d left that do not follow the schema", len(normalized), flagged)
+	return nil
+}
+
+// GetWordsWithInvalidParts lists the words whose parts do not follow the schema, with the reason,
+// for an admin to fix them by hand.
+func (s *Service) GetWordsWithInvalidParts() ([]models.WordPartsIssue, error) {
+	rows, err := s.DB.Query(`SELECT ` + wordColumns + ` FROM words w
+	                         WHERE w.deleted_at IS NULL AND COALESCE(w.parts, '') <> ''
+	                         ORDER BY w.id`)
+	if err != nil {
+		return nil, err
+	}
+	words, err := scanWords(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]models.WordPartsIssue, 0)
+	for _, word := range words {
+		if _, err := ParseParts(string(word.Parts), false); err != nil {
+			issues = append(issues, models.WordPartsIssue{Word: word, Problem: err.Error()})
+		}
+	}
+	return issues, nil
+}
+
+// partsField returns the SQL expression reading the value at path from the parts of the words w,
+// NULL for parts that are not JSON.
+func partsField(path string) string {
+	return "CASE WHEN json_valid(w.parts) THEN json_extract(w.parts, '" + path + "') END"
+}
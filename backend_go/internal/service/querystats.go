@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"backend_go/internal/models"
+)
+
+// maxQueryNameLength bounds the statement text used to name a query in the timings and the log.
+const maxQueryNameLength = 200
+
+// queryStats aggregates the time spent in each statement run through a timedConn, and logs the
+// ones slower than a threshold.
+type queryStats struct {
+	// slowThreshold is a time.Duration, zero when slow queries are not logged
+	slowThreshold atomic.Int64
+
+	mu      sync.Mutex
+	queries map[string]*models.QueryTiming
+}
+
+// record adds one run of query taking d. Arguments are never logged, only their number.
+func (q *queryStats) record(query string, args int, d time.Duration) {
+	name := queryName(query)
+	if threshold := time.Duration(q.slowThreshold.Load()); threshold > 0 && d >= threshold {
+		log.Printf("Slow query (%s, %d args redacted): %s", d.Round(time.Microsecond), args, name)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t, ok := q.queries[name]
+	if !ok {
+		t = &models.QueryTiming{Query: name}
+		q.queries[name] = t
+	}
+	ms := float64(d) / float64(time.Millisecond)
+	t.Count++
+	t.TotalMs += ms
+	if ms > t.MaxMs {
+		t.MaxMs = ms
+	}
+}
+
+// snapshot returns the timings of every query seen, the most total time first.
+func (q *queryStats) snapshot() []models.QueryTiming {
+	q.mu.Lock()
+	timings := make([]models.QueryTiming, 0, len(q.queries))
+	for _, t := range q.queries {
+		timing := *t
+		timing.AvgMs = timing.TotalMs / float64(timing.Count)
+		timings = append(timings, timing)
+	}
+	q.mu.Unlock()
+
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].TotalMs != timings[j].TotalMs {
+			return timings[i].TotalMs > timings[j].TotalMs
+		}
+		return timings[i].Query < timings[j].Query
+	})
+	return timings
+}
+
+// queryName collapses the whitespace of a statement so the same query is named the same wherever
+// it is indented, and cuts it to maxQueryNameLength.
+func queryName(query string) string {
+	name := strings.Join(strings.Fields(query), " ")
+	if len(name) > maxQueryNameLength {
+		name = name[:maxQueryNameLength] + "..."
+	}
+	return name
+}
+
+// timedConnector opens sqlite3 connections whose statements are timed into stats.
+type timedConnector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+	stats  *queryStats
+}
+
+func (c *timedConnector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{SQLiteConn: conn.(*sqlite3.SQLiteConn), stats: c.stats}, nil
+}
+
+func (c *timedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// timedConn times the queries and statements run on a sqlite3 connection. Outside transactions, a
+// single statement failing because the database is busy is retried (see retryBusy): it had no
+// effect, so running it again is safe, whereas within a transaction the whole transaction would
+// have to be, and so would the statements of a multi-statement Exec that ran before the busy one.
+// Everything else, such as transactions, goes straight to the embedded connection, and the context
+// is passed through unchanged so cancellation behaves as without the wrapper.
+type timedConn struct {
+	*sqlite3.SQLiteConn
+	stats *queryStats
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	err := c.retry(ctx, query, func() (err error) {
+		result, err = c.SQLiteConn.ExecContext(ctx, query, args)
+		return err
+	})
+	c.stats.record(query, len(args), time.Since(start))
+	return result, err
+}
+
+// QueryContext times a query until its rows are closed, since sqlite3 does most of the work of a
+// query while the rows are read. Only the busy errors of preparing the query are retried: those
+// met while reading the rows reach the caller.
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	err := c.retry(ctx, query, func() (err error) {
+		rows, err = c.SQLiteConn.QueryContext(ctx, query, args)
+		return err
+	})
+	if err != nil {
+		c.stats.record(query, len(args), time.Since(start))
+		return nil, err
+	}
+	return &timedRows{Rows: rows, done: func() { c.stats.record(query, len(args), time.Since(start)) }}, nil
+}
+
+// retry runs fn, which runs query, through retryBusy when query is a single statement run outside
+// transactions, and once otherwise.
+func (c *timedConn) retry(ctx context.Context, query string, fn func() error) error {
+	if !c.AutoCommit() || !singleStatement(query) {
+		return fn()
+	}
+	return retryBusy(ctx, fn)
+}
+
+// singleStatement reports whether query holds one statement: no semicolon outside string literals,
+// quoted identifiers and comments is followed by more than whitespace, comments or semicolons.
+// Statements it cannot tell apart, such as CREATE TRIGGER, count as several.
+func singleStatement(query string) bool {
+	ended := false
+	for i := 0; i < len(query); i++ {
+		switch ch := query[i]; {
+		case ch == ';':
+			ended = true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+		case ch == '-' && strings.HasPrefix(query[i:], "--"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(query)
+			}
+		case ch == '/' && strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(query)
+			}
+		case ended:
+			return false
+		case ch == '\'' || ch == '"' || ch == '`':
+			if end := strings.IndexByte(query[i+1:], ch); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(query)
+			}
+		}
+	}
+	return true
+}
+
+// timedRows calls done when closed.
+type timedRows struct {
+	driver.Rows
+	done func()
+}
+
+func (r *timedRows) Close() error {
+	err := r.Rows.Close()
+	r.done()
+	return err
+}
+
+// SetSlowQueryThreshold logs every statement taking at least threshold, with its arguments
+// redacted. A zero threshold turns the log off.
+func (s *Service) SetSlowQueryThreshold(threshold time.Duration) {
+	s.queryStats.slowThreshold.Store(int64(threshold))
+}
+
+// GetQueryTimings returns the number of runs and the time spent in each statement since the
+// database was opened, the most total time first.
+func (s *Service) GetQueryTimings() []models.QueryTiming {
+	return s.queryStats.snapshot()
+}
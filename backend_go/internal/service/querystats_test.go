@@ -0,0 +1,28 @@
+package service
+
+import "testing"
+
+func TestSingleStatement(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT 1", true},
+		{"SELECT 1;", true},
+		{"SELECT 1; \n  ;", true},
+		{"SELECT 1; -- done", true},
+		{"SELECT ';' FROM words", true},
+		{"SELECT 'it''s; fine'", true},
+		{`SELECT "a;b" FROM words`, true},
+		{"SELECT 1 /* ; */", true},
+		{"SELECT 1 -- ;\nFROM words", true},
+		{"DELETE FROM words; DELETE FROM groups", false},
+		{"SELECT 1;\nSELECT 2;", false},
+		{"SELECT 1; /* next */ SELECT 2", false},
+	}
+	for _, tt := range tests {
+		if got := singleStatement(tt.query); got != tt.want {
+			t.Errorf("singleStatement(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
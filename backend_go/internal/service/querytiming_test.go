@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"backend_go/internal/service"
+	"backend_go/internal/testutil"
+)
+
+// unwrapped returns a service over the database file of svc opened with the plain sqlite3 driver,
+// whose statements are neither timed nor retried.
+func unwrapped(tb testing.TB, svc *service.Service) *service.Service {
+	tb.Helper()
+	var file string
+	if err := svc.DB.QueryRow("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file); err != nil {
+		tb.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+	return &service.Service{DB: db}
+}
+
+// addWords adds n words to the database of svc.
+func addWords(tb testing.TB, svc *service.Service, n int) {
+	tb.Helper()
+	f := testutil.NewFixture(tb, svc.DB).Group("Words")
+	for i := 0; i < n; i++ {
+		f.Word(fmt.Sprintf("語%d", i), fmt.Sprintf("go%d", i), fmt.Sprintf("word %d", i)).InGroup("Words")
+	}
+}
+
+func BenchmarkGetWords(b *testing.B) {
+	svc := testutil.NewService(b)
+	addWords(b, svc, 500)
+	sort := service.WordSort{{Field: "romaji"}}
+	for _, bm := range []struct {
+		name string
+		svc  *service.Service
+	}{
+		{"wrapped", svc},
+		{"unwrapped", unwrapped(b, svc)},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := bm.svc.GetWords(sort, service.WordFilter{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryTimings(t *testing.T) {
+	svc := testutil.NewService(t)
+	addWords(t, svc, 20)
+
+	sort := service.WordSort{{Field: "english", Desc: true}}
+	wrapped, err := svc.GetWords(sort, service.WordFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := unwrapped(t, svc).GetWords(sort, service.WordFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wrapped) != 20 || !reflect.DeepEqual(wrapped, plain) {
+		t.Errorf("timed connection returned %d words, plain %d, want the same 20", len(wrapped), len(plain))
+	}
+
+	// The listing is timed once, named by the start of its text with the whitespace collapsed
+	var listing []string
+	for _, timing := range svc.GetQueryTimings() {
+		if strings.HasPrefix(timing.Query, "SELECT w.id, w.japanese") {
+			listing = append(listing, timing.Query)
+			if timing.Count != 1 || timing.TotalMs <= 0 || len(timing.Query) != 200+len("...") {
+				t.Errorf("timing %+v, want one run with its time under a shortened name", timing)
+			}
+		}
+	}
+	if len(listing) != 1 {
+		t.Errorf("found %d timings of the listing, want 1: %v", len(listing), listing)
+	}
+}
+
+func TestQueryCancellation(t *testing.T) {
+	svc := testutil.NewService(t)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := svc.DB.QueryContext(canceled, "SELECT 1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("query with a canceled context: error %v, want context.Canceled", err)
+	}
+
+	// A long query is interrupted at the deadline rather than run to the end
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	var n int
+	err := svc.DB.QueryRowContext(ctx, `WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 1000000000)
+	                                    SELECT COUNT(*) FROM c`).Scan(&n)
+	if !errors.Is(err, context.DeadlineExceeded) && (err == nil || !strings.Contains(err.Error(), "interrupt")) {
+		t.Errorf("long query: error %v, want it interrupted at the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("long query returned after %v, want it interrupted", elapsed)
+	}
+
+	// Busy retries give up as soon as the context ends
+	if _, err := svc.DB.ExecContext(canceled, "DELETE FROM words"); !errors.Is(err, context.Canceled) {
+		t.Errorf("exec with a canceled context: error %v, want context.Canceled", err)
+	}
+
+	// The connections stay usable
+	if err := svc.DB.QueryRow("SELECT 1").Scan(&n); err != nil || n != 1 {
+		t.Errorf("query after the cancellations: %d, %v", n, err)
+	}
+}
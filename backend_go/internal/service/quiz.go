@@ -0,0 +1,154 @@
+package service
+
+import (
+	"math/rand"
+	"sort"
+
+	"backend_go/internal/models"
+)
+
+// Difficulty buckets of quiz words. A word is new until it is first reviewed, then strong once its
+// schedule has MasteryCorrectReviews correct answers in a row and weak otherwise.
+const (
+	QuizBucketNew    = "new"
+	QuizBucketWeak   = "weak"
+	QuizBucketStrong = "strong"
+)
+
+// QuizDirectionMixed asks the words of a quiz in alternating directions, starting with the first of
+// ReviewDirections, instead of all in one.
+const QuizDirectionMixed = "mixed"
+
+// QuizDirections lists the directions a quiz can be asked in.
+var QuizDirections = []string{DirectionJapaneseToEnglish, DirectionEnglishToJapanese, QuizDirectionMixed}
+
+// quizBuckets lists the buckets in the order of a QuizMix.
+var quizBuckets = [3]string{QuizBucketNew, QuizBucketWeak, QuizBucketStrong}
+
+// QuizMix is the relative share of new, weak and strong words in a quiz, such as {2, 2, 1}.
+type QuizMix [3]int
+
+// quizCandidate is a word that may be quizzed, its bucket (an index into quizBuckets) and how many
+// times it was already reviewed in the session.
+type quizCandidate struct {
+	wordID int
+	bucket int
+	asked  int
+}
+
+// selectQuizWords picks up to count word IDs from the candidates. Buckets are interleaved by smooth
+// weighted round robin on mix, so {2, 2, 1} yields new, weak, strong, new, weak; a bucket with no
+// words left hands its turn to the next bucket in weight order. Within a bucket words are asked in
+// an order shuffled by rng and each goes to the back of its bucket once asked, so no word is asked
+// twice while its bucket has others. Counting the times it was already asked in the session, a word
+// is never asked more than wordCap times. selectQuizWords depends only on its arguments, so a rng
+// with a fixed seed always gives the same quiz.
+func selectQuizWords(candidates []quizCandidate, count int, mix QuizMix, wordCap int, rng *rand.Rand) []int {
+	var queues [3][]int
+	remaining := make(map[int]int)
+	sorted := append([]quizCandidate(nil), candidates...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].wordID < sorted[j].wordID })
+	for _, c := range sorted {
+		if left := wordCap - c.asked; left > 0 {
+			queues[c.bucket] = append(queues[c.bucket], c.wordID)
+			remaining[c.wordID] = left
+		}
+	}
+	for b := range queues {
+		rng.Shuffle(len(queues[b]), func(i, j int) { queues[b][i], queues[b][j] = queues[b][j], queues[b][i] })
+	}
+
+	// Buckets in weight order, heaviest first, for falling back from an empty bucket
+	fallback := []int{0, 1, 2}
+	sort.SliceStable(fallback, func(i, j int) bool { return mix[fallback[i]] > mix[fallback[j]] })
+	totalWeight := mix[0] + mix[1] + mix[2]
+
+	picked := make([]int, 0, count)
+	var current [3]int
+	for len(picked) < count {
+		// Smooth weighted round robin: every bucket gains its weight, the highest pays the total
+		turn := -1
+		for b := range mix {
+			current[b] += mix[b]
+			if mix[b] > 0 && (turn < 0 || current[b] > current[turn]) {
+				turn = b
+			}
+		}
+		if turn >= 0 {
+			current[turn] -= totalWeight
+		}
+		if turn < 0 || len(queues[turn]) == 0 {
+			turn = -1
+			for _, b := range fallback {
+				if len(queues[b]) > 0 {
+					turn = b
+					break
+				}
+			}
+			if turn < 0 {
+				break
+			}
+		}
+
+		id := queues[turn][0]
+		queues[turn] = queues[turn][1:]
+		picked = append(picked, id)
+		if remaining[id]--; remaining[id] > 0 {
+			queues[turn] = append(queues[turn], id)
+		}
+	}
+	return picked
+}
+
+// GetQuiz selects up to count words of a study session's group to quiz next, interleaving the
+// difficulty buckets by mix and asking no word more than wordCap times in the session (see
+// selectQuizWords). The same seed gives the same quiz for the same history. Each word is tagged
+// with direction, one of ReviewDirections or QuizDirectionMixed. It returns sql.ErrNoRows if the
+// session does not exist.
+func (s *Service) GetQuiz(sessionID, count int, mix QuizMix, wordCap int, seed int64, direction string) ([]models.QuizWord, error) {
+	session, err := s.GetStudySessionByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(`SELECT `+wordColumns+`,
+	                                CASE WHEN srs.word_id IS NULL THEN 0 WHEN srs.repetitions >= ? THEN 2 ELSE 1 END,
+	                                (SELECT COUNT(*) FROM word_review_items wr WHERE wr.study_session_id = ? AND wr.word_id = w.id)
+	                         FROM words w
+	                         JOIN word_groups wg ON wg.word_id = w.id AND wg.group_id = ?
+	                         LEFT JOIN word_srs srs ON srs.word_id = w.id AND `+s.scheduleCond("srs")+`
+	                         WHERE w.deleted_at IS NULL`, MasteryCorrectReviews, sessionID, session.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	words := make(map[int]models.Word)
+	var candidates []quizCandidate
+	for rows.Next() {
+		var word models.Word
+		var c quizCandidate
+		if err := scanWord(rows, &word, &c.bucket, &c.asked); err != nil {
+			return nil, err
+		}
+		c.wordID = word.ID
+		words[word.ID] = word
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	buckets := make(map[int]string, len(candidates))
+	for _, c := range candidates {
+		buckets[c.wordID] = quizBuckets[c.bucket]
+	}
+	quiz := make([]models.QuizWord, 0, count)
+	for i, id := range selectQuizWords(candidates, count, mix, wordCap, rand.New(rand.NewSource(seed))) {
+		asked := direction
+		if direction == QuizDirectionMixed {
+			asked = ReviewDirections[i%len(ReviewDirections)]
+		}
+		quiz = append(quiz, models.QuizWord{Word: words[id], Bucket: buckets[id], Direction: asked})
+	}
+	return quiz, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// quizCandidates returns n unasked candidates of each bucket, numbered 100 apart by bucket: new
+// words from 100, weak from 200 and strong from 300.
+func quizCandidates(n [3]int) []quizCandidate {
+	var candidates []quizCandidate
+	for b := range n {
+		for i := 0; i < n[b]; i++ {
+			candidates = append(candidates, quizCandidate{wordID: 100*(b+1) + i, bucket: b})
+		}
+	}
+	return candidates
+}
+
+// buckets returns the bucket of each picked word ID numbered as by quizCandidates.
+func buckets(picked []int) []int {
+	out := make([]int, len(picked))
+	for i, id := range picked {
+		out[i] = id/100 - 1
+	}
+	return out
+}
+
+func TestSelectQuizWordsMix(t *testing.T) {
+	tests := []struct {
+		mix  QuizMix
+		want []int
+	}{
+		{QuizMix{2, 2, 1}, []int{0, 1, 2, 0, 1, 0, 1, 2, 0, 1}},
+		{QuizMix{1, 0, 0}, []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{QuizMix{1, 1, 1}, []int{0, 1, 2, 0, 1, 2, 0, 1, 2, 0}},
+		{QuizMix{0, 3, 1}, []int{1, 1, 2, 1, 1, 1, 2, 1, 1, 1}},
+	}
+	for _, tt := range tests {
+		picked := selectQuizWords(quizCandidates([3]int{10, 10, 10}), 10, tt.mix, 3, rand.New(rand.NewSource(1)))
+		if got := buckets(picked); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mix %v picked buckets %v, want %v", tt.mix, got, tt.want)
+		}
+		seen := make(map[int]bool)
+		for _, id := range picked {
+			if seen[id] {
+				t.Errorf("mix %v asked word %d twice while its bucket had others: %v", tt.mix, id, picked)
+			}
+			seen[id] = true
+		}
+	}
+
+	// Over a long quiz the shares follow the mix
+	picked := selectQuizWords(quizCandidates([3]int{50, 50, 50}), 100, QuizMix{3, 1, 1}, 3, rand.New(rand.NewSource(7)))
+	var counts [3]int
+	for _, b := range buckets(picked) {
+		counts[b]++
+	}
+	if counts != [3]int{60, 20, 20} {
+		t.Errorf("mix {3, 1, 1} over 100 words picked %v per bucket, want 60, 20 and 20", counts)
+	}
+}
+
+func TestSelectQuizWordsFallback(t *testing.T) {
+	// Without weak words their turns go to the heaviest bucket left, then the others once it runs out
+	picked := selectQuizWords(quizCandidates([3]int{2, 0, 3}), 10, QuizMix{1, 2, 1}, 1, rand.New(rand.NewSource(1)))
+	if got := buckets(picked); !reflect.DeepEqual(got, []int{0, 0, 2, 2, 2}) {
+		t.Errorf("picked buckets %v, want both new words then the three strong ones", got)
+	}
+	if picked := selectQuizWords(nil, 5, QuizMix{2, 2, 1}, 3, rand.New(rand.NewSource(1))); len(picked) != 0 {
+		t.Errorf("without candidates picked %v", picked)
+	}
+}
+
+func TestSelectQuizWordsCap(t *testing.T) {
+	candidates := []quizCandidate{
+		{wordID: 1, bucket: 0},
+		{wordID: 2, bucket: 1, asked: 1},
+		{wordID: 3, bucket: 1, asked: 3},
+		{wordID: 4, bucket: 2, asked: 5},
+	}
+	picked := selectQuizWords(candidates, 20, QuizMix{1, 1, 1}, 3, rand.New(rand.NewSource(1)))
+	asked := make(map[int]int)
+	for _, id := range picked {
+		asked[id]++
+	}
+	// Word 1 can be asked three times and word 2 twice more, words 3 and 4 reached the cap
+	if want := map[int]int{1: 3, 2: 2}; !reflect.DeepEqual(asked, want) {
+		t.Errorf("asked %v, want %v", asked, want)
+	}
+	if len(picked) != 5 {
+		t.Errorf("picked %d words, want the 5 the cap allows", len(picked))
+	}
+}
+
+func TestSelectQuizWordsSeed(t *testing.T) {
+	candidates := quizCandidates([3]int{8, 8, 8})
+	first := selectQuizWords(candidates, 12, QuizMix{2, 2, 1}, 3, rand.New(rand.NewSource(42)))
+
+	// The order of the candidates does not matter, only the seed does
+	reversed := make([]quizCandidate, len(candidates))
+	for i, c := range candidates {
+		reversed[len(candidates)-1-i] = c
+	}
+	if again := selectQuizWords(reversed, 12, QuizMix{2, 2, 1}, 3, rand.New(rand.NewSource(42))); !reflect.DeepEqual(again, first) {
+		t.Errorf("same seed picked %v, then %v", first, again)
+	}
+	differs := false
+	for seed := int64(1); seed <= 5 && !differs; seed++ {
+		other := selectQuizWords(candidates, 12, QuizMix{2, 2, 1}, 3, rand.New(rand.NewSource(seed)))
+		differs = !reflect.DeepEqual(other, first)
+		if !reflect.DeepEqual(buckets(other), buckets(first)) {
+			t.Errorf("seed %d interleaved the buckets as %v, want %v", seed, buckets(other), buckets(first))
+		}
+	}
+	if !differs {
+		t.Error("five other seeds all picked the same words in the same order")
+	}
+}
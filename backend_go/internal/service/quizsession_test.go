@@ -0,0 +1,53 @@
+package service_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"backend_go/internal/service"
+	"backend_go/internal/testutil"
+)
+
+func TestGetQuiz(t *testing.T) {
+	svc := testutil.NewService(t)
+	// 水 is new and was asked twice in the session already, 火 is weak and 山 strong
+	f := testutil.NewFixture(t, svc.DB).Group("N5").
+		Word("火", "hi", "fire").InGroup("N5").
+		Word("山", "yama", "mountain").InGroup("N5").
+		Word("川", "kawa", "river").
+		Word("水", "mizu", "water").InGroup("N5").Session().Review(false).Review(false)
+	schedule(t, svc.DB, f.WordID("火"), time.Now().Add(day))
+	schedule(t, svc.DB, f.WordID("山"), time.Now().Add(day))
+	if _, err := svc.DB.Exec("UPDATE word_srs SET repetitions = ? WHERE word_id = ?", service.MasteryCorrectReviews, f.WordID("山")); err != nil {
+		t.Fatal(err)
+	}
+
+	quiz, err := svc.GetQuiz(f.SessionID(), 20, service.QuizMix{1, 1, 1}, 3, 42, service.QuizDirectionMixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	asked := make(map[string]int)
+	for i, w := range quiz {
+		asked[w.Japanese]++
+		want := map[string]string{"水": service.QuizBucketNew, "火": service.QuizBucketWeak, "山": service.QuizBucketStrong}[w.Japanese]
+		if w.Bucket != want {
+			t.Errorf("%s in bucket %q, want %q", w.Japanese, w.Bucket, want)
+		}
+		if w.Direction != service.ReviewDirections[i%2] {
+			t.Errorf("word %d asked %q, want the directions to alternate", i, w.Direction)
+		}
+	}
+	// The cap of 3 counts the two reviews of 水 in the session, and 川 is not in the group
+	if want := map[string]int{"水": 1, "火": 3, "山": 3}; !reflect.DeepEqual(asked, want) {
+		t.Errorf("asked %v, want %v", asked, want)
+	}
+
+	again, err := svc.GetQuiz(f.SessionID(), 20, service.QuizMix{1, 1, 1}, 3, 42, service.QuizDirectionMixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, quiz) {
+		t.Error("the same seed and history gave another quiz")
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"backend_go/internal/models"
+)
+
+// Relation types between words.
+const (
+	RelationSynonym = "synonym"
+	RelationAntonym = "antonym"
+	RelationSeeAlso = "see_also"
+)
+
+// RelationTypes lists the valid relation types.
+var RelationTypes = []string{RelationSynonym, RelationAntonym, RelationSeeAlso}
+
+var (
+	// ErrSelfRelation is returned when a word is related to itself.
+	ErrSelfRelation = errors.New("a word cannot be related to itself")
+	// ErrDuplicateRelation is returned when two words are already related.
+	ErrDuplicateRelation = errors.New("these words are already related")
+)
+
+// canonicalPair orders the IDs of a relation the way it is stored, smaller ID first.
+func canonicalPair(a, b int) (int, int) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
+
+// CreateWordRelation relates two words. Relations are symmetric, so relating b to a is the same as
+// relating a to b. It returns ErrSelfRelation if the IDs are equal, ErrDuplicateRelation if the
+// words are already related, and sql.ErrNoRows if either word does not exist.
+func (s *Service) CreateWordRelation(wordID, relatedWordID int, relationType string) (*models.WordRelation, error) {
+	if wordID == relatedWordID {
+		return nil, ErrSelfRelation
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var found int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM words WHERE id IN (?, ?) AND deleted_at IS NULL", wordID, relatedWordID).Scan(&found); err != nil {
+		return nil, err
+	}
+	if found != 2 {
+		return nil, sql.ErrNoRows
+	}
+	first, second := canonicalPair(wordID, relatedWordID)
+	var exists int
+	err = tx.QueryRow("SELECT 1 FROM word_relations WHERE word_id = ? AND related_word_id = ?", first, second).Scan(&exists)
+	if err == nil {
+		return nil, ErrDuplicateRelation
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+	if _, err := tx.Exec("INSERT INTO word_relations (word_id, related_word_id, relation_type) VALUES (?, ?, ?)",
+		first, second, relationType); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &models.WordRelation{WordID: wordID, RelatedWordID: relatedWordID, Type: relationType}, nil
+}
+
+// DeleteWordRelation removes the relation between two words, or returns sql.ErrNoRows if they are not related.
+func (s *Service) DeleteWordRelation(wordID, relatedWordID int) error {
+	first, second := canonicalPair(wordID, relatedWordID)
+	result, err := s.DB.Exec("DELETE FROM word_relations WHERE word_id = ? AND related_word_id = ?", first, second)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetRelatedWords retrieves the words related to a word, grouped by relation type and ordered by
+// ID, or sql.ErrNoRows if the word does not exist.
+func (s *Service) GetRelatedWords(wordID int) (*models.RelatedWords, error) {
+	if _, err := s.GetWordByID(wordID); err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(`SELECT `+wordColumns+`, r.relation_type FROM word_relations r
+	                         JOIN words w ON w.id = CASE WHEN r.word_id = ? THEN r.related_word_id ELSE r.word_id END
+	                         WHERE (r.word_id = ? OR r.related_word_id = ?) AND w.deleted_at IS NULL
+	                         ORDER BY w.id`, wordID, wordID, wordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	related := &models.RelatedWords{
+		Synonyms: make([]models.Word, 0),
+		Antonyms: make([]models.Word, 0),
+		SeeAlso:  make([]models.Word, 0),
+	}
+	for rows.Next() {
+		var word models.Word
+		var relationType string
+		if err := scanWord(rows, &word, &relationType); err != nil {
+			return nil, err
+		}
+		switch relationType {
+		case RelationSynonym:
+			related.Synonyms = append(related.Synonyms, word)
+		case RelationAntonym:
+			related.Antonyms = append(related.Antonyms, word)
+		default:
+			related.SeeAlso = append(related.SeeAlso, word)
+		}
+	}
+	return related, rows.Err()
+}
+
+// deleteWordRelations removes every relation of the given words.
+func deleteWordRelations(db execQuerier, ids []int) error {
+	placeholders, args := inPlaceholders(ids)
+	_, err := db.Exec("DELETE FROM word_relations WHERE word_id IN ("+placeholders+") OR related_word_id IN ("+placeholders+")",
+		append(args, args...)...)
+	return err
+}
+
+// moveWordRelations re-points the relations of the source word at the target word, dropping the
+// ones the target already has and the relation between the two words themselves.
+func moveWordRelations(db execQuerier, targetID, sourceID int) error {
+	if _, err := db.Exec(`INSERT OR IGNORE INTO word_relations (word_id, related_word_id, relation_type, created_at)
+	                      SELECT MIN(?, other), MAX(?, other), relation_type, created_at
+	                      FROM (SELECT CASE WHEN word_id = ? THEN related_word_id ELSE word_id END AS other, relation_type, created_at
+	                            FROM word_relations WHERE word_id = ? OR related_word_id = ?)
+	                      WHERE other <> ?`,
+		targetID, targetID, sourceID, sourceID, sourceID, targetID); err != nil {
+		return err
+	}
+	return deleteWordRelations(db, []int{sourceID})
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+// Weekdays lists the day names of ReminderSettings.Days, indexed by time.Weekday.
+var Weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ReminderCheckInterval is how often RunReminders checks whether the reminder is due.
+const ReminderCheckInterval = time.Minute
+
+// reminderTimeFormat is the layout of ReminderSettings.TimeOfDay.
+const reminderTimeFormat = "15:04"
+
+// GetReminderSettings returns the study reminder settings, with the timezone they are read in.
+func (s *Service) GetReminderSettings() (*models.ReminderSettings, error) {
+	settings := models.ReminderSettings{Timezone: s.Location().String()}
+	var days string
+	var lastFired sql.NullString
+	err := s.DB.QueryRow(`SELECT enabled, time_of_day, days, webhook_url, daily_goal, last_fired_date
+	                      FROM reminder_settings WHERE id = 1`).Scan(&settings.Enabled, &settings.TimeOfDay, &days,
+		&settings.WebhookURL, &settings.DailyGoal, &lastFired)
+	if err != nil {
+		return nil, err
+	}
+	settings.Days = make([]string, 0, len(Weekdays))
+	for _, day := range strings.Split(days, ",") {
+		if day != "" {
+			settings.Days = append(settings.Days, day)
+		}
+	}
+	if lastFired.Valid {
+		settings.LastFiredDate = &lastFired.String
+	}
+	return &settings, nil
+}
+
+// UpdateReminderSettings saves the study reminder settings, which must be valid, and returns them.
+// The date of the last reminder sent is kept.
+func (s *Service) UpdateReminderSettings(settings models.ReminderSettings) (*models.ReminderSettings, error) {
+	if _, err := s.DB.Exec(`UPDATE reminder_settings
+	                        SET enabled = ?, time_of_day = ?, days = ?, webhook_url = ?, daily_goal = ?, updated_at = CURRENT_TIMESTAMP
+	                        WHERE id = 1`, settings.Enabled, settings.TimeOfDay, strings.Join(settings.Days, ","),
+		settings.WebhookURL, settings.DailyGoal); err != nil {
+		return nil, err
+	}
+	return s.GetReminderSettings()
+}
+
+// GetGoalProgress counts the reviews made on the calendar day of date in the configured timezone
+// against a daily goal of goal reviews.
+func (s *Service) GetGoalProgress(date time.Time, goal int) (*models.GoalProgress, error) {
+	loc := s.Location()
+	date = date.In(loc)
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	progress := &models.GoalProgress{Date: start.Format("2006-01-02"), Timezone: loc.String(), Goal: goal}
+
+	var err error
+	if progress.Reviews, _, err = s.reviewCounts(start, start.AddDate(0, 0, 1)); err != nil {
+		return nil, err
+	}
+	if progress.Reviews < goal {
+		progress.Remaining = goal - progress.Reviews
+	}
+	progress.Met = progress.Remaining == 0
+	return progress, nil
+}
+
+// RunReminders checks every interval whether the study reminder is due, sending it with client,
+// until ctx is done. Failures are logged and the check is tried again at the next tick.
+func (s *Service) RunReminders(ctx context.Context, client *http.Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.checkReminder(ctx, client, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("Sending study reminder: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkReminder sends the study reminder if it is due at now and the daily goal is not met yet.
+// The day is claimed in the database before the webhook is called, so a reminder is sent at most
+// once a day even across restarts. A failed delivery is not retried that day.
+func (s *Service) checkReminder(ctx context.Context, client *http.Client, now time.Time) error {
+	settings, err := s.GetReminderSettings()
+	if err != nil {
+		return err
+	}
+	now = now.In(s.Location())
+	today := now.Format("2006-01-02")
+	if !reminderDue(settings, now, today) {
+		return nil
+	}
+	progress, err := s.GetGoalProgress(now, settings.DailyGoal)
+	if err != nil || progress.Met {
+		return err
+	}
+
+	result, err := s.DB.Exec(`UPDATE reminder_settings SET last_fired_date = ?
+	                          WHERE id = 1 AND (last_fired_date IS NULL OR last_fired_date < ?)`, today, today)
+	if err != nil {
+		return err
+	}
+	if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+		return err
+	}
+	return postWebhook(ctx, client, settings.WebhookURL, map[string]interface{}{
+		"type":          "study_reminder",
+		"sent_at":       now.Format(time.RFC3339),
+		"goal_progress": progress,
+	})
+}
+
+// reminderDue reports whether the reminder should be sent at now, whose date is today: it is
+// enabled, today is one of its days, its time has passed and it was not sent today yet.
+func reminderDue(settings *models.ReminderSettings, now time.Time, today string) bool {
+	if !settings.Enabled || settings.WebhookURL == "" {
+		return false
+	}
+	if settings.LastFiredDate != nil && *settings.LastFiredDate >= today {
+		return false
+	}
+	if now.Format(reminderTimeFormat) < settings.TimeOfDay {
+		return false
+	}
+	for _, day := range settings.Days {
+		if day == Weekdays[now.Weekday()] {
+			return true
+		}
+	}
+	return false
+}
+
+// postWebhook posts payload as JSON to url, failing unless the answer is a 2xx status.
+func postWebhook(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s answered %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+var (
+	// ErrInvalidRemoteURL is returned for URLs that are not absolute http or https URLs.
+	ErrInvalidRemoteURL = errors.New("url must be an absolute http or https URL")
+	// ErrNotCSV is returned when a remote document is not served as CSV.
+	ErrNotCSV = errors.New("remote document is not CSV")
+	// ErrRemoteTooLarge is returned when a remote document exceeds the size limit.
+	ErrRemoteTooLarge = errors.New("remote document is too large")
+	// ErrPrivateAddress is returned when a remote host resolves to a loopback, private, link-local
+	// or other non-public address, which the clients of NewRemoteClient refuse to connect to.
+	ErrPrivateAddress = errors.New("remote host is not a public address")
+)
+
+// nonPublicPrefixes are the ranges of global unicast addresses that are not public besides the
+// private ones: this network, shared carrier-grade NAT space and benchmarking networks.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// NewRemoteClient returns a client for FetchRemoteCSV whose requests time out after timeout and
+// which only connects to public addresses. Addresses are checked after DNS resolution, on every
+// connection including those of redirects, so a URL cannot make the server fetch from itself or its
+// network, such as a cloud metadata endpoint. Proxies from the environment are not used, since they
+// would connect on the client's behalf.
+func NewRemoteClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialPublicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// dialPublicOnly is a net.Dialer Control function failing with ErrPrivateAddress before connecting
+// to an address that is not public.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	addr := addrPort.Addr().Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return ErrPrivateAddress
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// csvContentTypes are the content types accepted for remote CSV documents. Published Google
+// Sheets are served as text/csv, and plain files are often served as text/plain.
+var csvContentTypes = map[string]bool{
+	"text/csv":                 true,
+	"application/csv":          true,
+	"text/plain":               true,
+	"application/octet-stream": true,
+}
+
+// RemoteFetchError reports that a remote document could not be fetched, either because the
+// request failed or because the server answered with an error status.
+type RemoteFetchError struct {
+	URL    string
+	Status int
+	Err    error
+}
+
+func (e *RemoteFetchError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("fetching %s: %v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("fetching %s: server answered %d", e.URL, e.Status)
+}
+
+func (e *RemoteFetchError) Unwrap() error {
+	return e.Err
+}
+
+// FetchRemoteCSV downloads a CSV word list from rawURL with client and parses it with
+// ParseWordsCSV. Documents larger than maxBytes are rejected with ErrRemoteTooLarge. Rows are
+// returned as read, so callers must validate them.
+func FetchRemoteCSV(ctx context.Context, client *http.Client, rawURL string, maxBytes int64) ([]models.ImportWord, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidRemoteURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidRemoteURL
+	}
+	req.Header.Set("Accept", "text/csv, text/plain;q=0.5")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &RemoteFetchError{URL: u.Redacted(), Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &RemoteFetchError{URL: u.Redacted(), Status: resp.StatusCode}
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || !csvContentTypes[mediaType] {
+		return nil, ErrNotCSV
+	}
+	if resp.ContentLength > maxBytes {
+		return nil, ErrRemoteTooLarge
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, &RemoteFetchError{URL: u.Redacted(), Err: err}
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, ErrRemoteTooLarge
+	}
+
+	words, err := ParseWordsCSV(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotCSV, err)
+	}
+	return words, nil
+}
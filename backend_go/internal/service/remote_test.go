@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDialPublicOnly(t *testing.T) {
+	for address, public := range map[string]bool{
+		"93.184.216.34:443":           true,
+		"[2606:2800:220:1::1]:443":    true,
+		"127.0.0.1:80":                false,
+		"[::1]:80":                    false,
+		"10.1.2.3:80":                 false,
+		"172.16.0.1:80":               false,
+		"192.168.1.1:80":              false,
+		"169.254.169.254:80":          false,
+		"[fe80::1]:80":                false,
+		"[fd00::1]:80":                false,
+		"[::ffff:127.0.0.1]:80":       false,
+		"[::ffff:169.254.169.254]:80": false,
+		"0.0.0.0:80":                  false,
+		"100.64.0.1:80":               false,
+		"224.0.0.1:80":                false,
+	} {
+		err := dialPublicOnly("tcp", address, nil)
+		if public && err != nil {
+			t.Errorf("%s refused: %v", address, err)
+		}
+		if !public && !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("%s: %v, want ErrPrivateAddress", address, err)
+		}
+	}
+}
+
+func TestNewRemoteClient(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		fmt.Fprint(w, "japanese,romaji,english\n水,mizu,water\n")
+	}))
+	defer remote.Close()
+
+	// The server fetches from its own network with a plain client only
+	if words, err := FetchRemoteCSV(context.Background(), remote.Client(), remote.URL, 1<<10); err != nil || len(words) != 1 {
+		t.Fatalf("plain client: %d words, %v", len(words), err)
+	}
+	_, err := FetchRemoteCSV(context.Background(), NewRemoteClient(time.Second), remote.URL, 1<<10)
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("remote client: %v, want ErrPrivateAddress", err)
+	}
+}
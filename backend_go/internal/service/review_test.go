@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"sync"
+	"testing"
+
+	"backend_go/internal/testutil"
+)
+
+func TestReviewWordAttempts(t *testing.T) {
+	svc := testutil.NewService(t)
+	f := testutil.NewFixture(t, svc.DB).Group("N5").Word("水", "mizu", "water").Session()
+
+	for _, tt := range []struct {
+		attempt int
+		correct bool
+		created bool
+	}{
+		{1, true, true},
+		{1, false, false},
+		{2, true, true},
+		{2, true, false},
+	} {
+		outcome, err := svc.ReviewWord(f.SessionID(), f.WordID("水"), tt.correct, false, tt.attempt, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outcome.Created != tt.created {
+			t.Errorf("attempt %d created = %v, want %v", tt.attempt, outcome.Created, tt.created)
+		}
+	}
+	var reviews, correct int
+	if err := svc.DB.QueryRow("SELECT COUNT(*), SUM(correct) FROM word_review_items").Scan(&reviews, &correct); err != nil {
+		t.Fatal(err)
+	}
+	if reviews != 2 || correct != 1 {
+		t.Errorf("%d reviews with %d correct, want 2 with the first attempt updated to wrong", reviews, correct)
+	}
+}
+
+func TestReviewWordConcurrent(t *testing.T) {
+	svc := testutil.NewService(t)
+	f := testutil.NewFixture(t, svc.DB).Group("N5").Word("水", "mizu", "water").Session()
+
+	// A double tap submits the same attempt twice at once: one review is created, the other
+	// submissions update it
+	const taps = 8
+	var wg sync.WaitGroup
+	created := make(chan bool, taps)
+	for i := 0; i < taps; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outcome, err := svc.ReviewWord(f.SessionID(), f.WordID("水"), true, false, 1, "", "")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			created <- outcome.Created
+		}()
+	}
+	wg.Wait()
+	close(created)
+	n := 0
+	for c := range created {
+		if c {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("%d submissions created the review, want 1", n)
+	}
+	var reviews int
+	if err := svc.DB.QueryRow("SELECT COUNT(*) FROM word_review_items").Scan(&reviews); err != nil {
+		t.Fatal(err)
+	}
+	if reviews != 1 {
+		t.Errorf("%d reviews recorded, want 1", reviews)
+	}
+}
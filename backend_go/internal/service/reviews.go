@@ -0,0 +1,220 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+// ErrNoReviews is returned when undoing the last review of a study session that has none.
+var ErrNoReviews = errors.New("study session has no reviews")
+
+// sqliteTimeFormat matches the format SQLite's CURRENT_TIMESTAMP writes into created_at columns.
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+// ListReviews retrieves one page of word reviews matching filter, newest first, along with the
+// total number of matching reviews. GroupID matches reviews recorded in sessions for that group.
+func (s *Service) ListReviews(filter models.ReviewFilter, page, perPage int) ([]models.ReviewRecord, int, error) {
+	conds, args := reviewConditions(filter)
+	conds = append(conds, s.userCond("wr"))
+	where := "WHERE " + strings.Join(conds, " AND ")
+
+	from := reviewsFrom + where
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*) "+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + reviewColumns + ` ` + from + `
+	          ORDER BY wr.created_at DESC, wr.id DESC
+	          LIMIT ? OFFSET ?`
+	rows, err := s.DB.Query(query, append(args, perPage, (page-1)*perPage)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews := make([]models.ReviewRecord, 0)
+	for rows.Next() {
+		var r models.ReviewRecord
+		if err := scanReview(rows, &r); err != nil {
+			return nil, 0, err
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, total, rows.Err()
+}
+
+// reviewsKeyset is the order of the reviews listing, newest first, for cursor pagination.
+var reviewsKeyset = keyset{listing: "reviews", keys: []keysetKey{
+	{expr: "wr.created_at", desc: true},
+	{expr: "wr.id", desc: true, integer: true},
+}}
+
+// ListReviewsByCursor retrieves up to limit reviews matching filter, newest first, starting after
+// the page whose next cursor is cursor, or at the newest review when cursor is "". As with
+// GetWordsByCursor, reviews recorded between requests never shift the pages. It returns the cursor
+// of the next page, nil on the last page, or ErrInvalidCursor.
+func (s *Service) ListReviewsByCursor(filter models.ReviewFilter, cursor string, limit int) ([]models.ReviewRecord, *string, error) {
+	now := time.Now()
+	start, err := reviewsKeyset.decode(cursor, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	conds, args := reviewConditions(filter)
+	conds = append(conds, s.userCond("wr"))
+	if after, afterArgs := reviewsKeyset.after(start); after != "" {
+		conds = append(conds, after)
+		args = append(args, afterArgs...)
+	}
+	where := "WHERE " + strings.Join(conds, " AND ")
+
+	query := `SELECT ` + reviewColumns + reviewsKeyset.columns() + ` ` + reviewsFrom + where + reviewsKeyset.orderBy() + ` LIMIT ?`
+	rows, err := s.DB.Query(query, append(args, limit+1)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	// Fetch one extra row to learn whether another page follows
+	reviews := make([]models.ReviewRecord, 0)
+	var last []string
+	for rows.Next() {
+		var r models.ReviewRecord
+		values, dest := reviewsKeyset.scanValues()
+		if err := scanReview(rows, &r, dest...); err != nil {
+			return nil, nil, err
+		}
+		if len(reviews) == limit {
+			return reviews, reviewsKeyset.encode(last, now), nil
+		}
+		reviews = append(reviews, r)
+		last = values
+	}
+	return reviews, nil, rows.Err()
+}
+
+// reviewsFrom is the FROM clause of the reviews listing, joining each review (wr) to its word (w)
+// and its study session (ss), if any.
+const reviewsFrom = `FROM word_review_items wr
+	         JOIN words w ON w.id = wr.word_id
+	         LEFT JOIN study_sessions ss ON ss.id = wr.study_session_id
+	         `
+
+// reviewColumns lists the columns of the reviews listing read by scanReview, in order.
+const reviewColumns = `wr.id, wr.word_id, w.japanese, w.english, wr.study_session_id, COALESCE(ss.group_id, 0),
+	                 wr.correct, wr.skipped, wr.attempt, wr.answer, wr.created_at`
+
+// scanReview scans a row of reviewColumns, followed by the extra columns, into r.
+func scanReview(row rowScanner, r *models.ReviewRecord, extra ...interface{}) error {
+	return row.Scan(append([]interface{}{&r.ID, &r.WordID, &r.Japanese, &r.English, &r.StudySessionID, &r.GroupID,
+		&r.Correct, &r.Skipped, &r.Attempt, &r.Answer, &r.CreatedAt}, extra...)...)
+}
+
+// reviewConditions returns the WHERE conditions of the reviews listing selecting filter, and their
+// arguments.
+func reviewConditions(filter models.ReviewFilter) ([]string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if !filter.From.IsZero() {
+		conds = append(conds, "wr.created_at >= ?")
+		args = append(args, filter.From.UTC().Format(sqliteTimeFormat))
+	}
+	if !filter.To.IsZero() {
+		conds = append(conds, "wr.created_at <= ?")
+		args = append(args, filter.To.UTC().Format(sqliteTimeFormat))
+	}
+	if filter.WordID > 0 {
+		conds = append(conds, "wr.word_id = ?")
+		args = append(args, filter.WordID)
+	}
+	if filter.GroupID > 0 {
+		conds = append(conds, "ss.group_id = ?")
+		args = append(args, filter.GroupID)
+	}
+	if filter.Correct != nil {
+		conds = append(conds, "wr.correct = ? AND NOT wr.skipped")
+		args = append(args, *filter.Correct)
+	}
+	if filter.Skipped != nil {
+		conds = append(conds, "wr.skipped = ?")
+		args = append(args, *filter.Skipped)
+	}
+	return conds, args
+}
+
+// GetWordHistory retrieves every review of a word in chronological order with the running accuracy
+// after each one, which skipped reviews leave unchanged. It returns an empty list for words never reviewed and sql.ErrNoRows if the word
+// does not exist.
+func (s *Service) GetWordHistory(wordID int) ([]models.WordHistoryPoint, error) {
+	if _, err := s.GetWordByID(wordID); err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(`SELECT id, study_session_id, correct, skipped, created_at FROM word_review_items
+	                         WHERE word_id = ? AND `+s.userCond("")+`
+	                         ORDER BY created_at, id`, wordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]models.WordHistoryPoint, 0)
+	correct, answered := 0, 0
+	for rows.Next() {
+		var p models.WordHistoryPoint
+		if err := rows.Scan(&p.ReviewID, &p.StudySessionID, &p.Correct, &p.Skipped, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		if !p.Skipped {
+			answered++
+		}
+		if p.Correct {
+			correct++
+		}
+		if answered > 0 {
+			p.RunningAccuracy = float64(correct) / float64(answered)
+		}
+		history = append(history, p)
+	}
+	return history, rows.Err()
+}
+
+// UndoLastReview deletes the most recent review of a study session, by created_at and then ID, and
+// reschedules its word from the reviews left. It returns the review undone, sql.ErrNoRows if the
+// session does not exist and ErrNoReviews if it has no reviews.
+func (s *Service) UndoLastReview(sessionID int) (*models.ReviewRecord, error) {
+	if _, err := s.GetStudySessionByID(sessionID); err != nil {
+		return nil, err
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var review models.ReviewRecord
+	var userID int
+	err = scanReview(tx.QueryRow(`SELECT `+reviewColumns+`, COALESCE(wr.user_id, 0) `+reviewsFrom+`
+	                              WHERE wr.study_session_id = ?
+	                              ORDER BY wr.created_at DESC, wr.id DESC
+	                              LIMIT 1`, sessionID), &review, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoReviews
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM word_review_items WHERE id = ?", review.ID); err != nil {
+		return nil, err
+	}
+	if _, err := updateSchedule(tx, review.WordID, userID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"backend_go/internal/models"
+)
+
+// longVowels expands macron (Hepburn) and circumflex (Kunrei) long vowels into double vowels.
+var longVowels = map[rune]string{
+	'ā': "aa", 'ī': "ii", 'ū': "uu", 'ē': "ee", 'ō': "ou",
+	'â': "aa", 'î': "ii", 'û': "uu", 'ê': "ee", 'ô': "ou",
+}
+
+// hepburnSyllables maps Kunrei and wāpuro spellings to Hepburn. Hepburn spellings that contain
+// a Kunrei one (the "hu" in "shu" and "chu") map to themselves so the longest match keeps them whole.
+var hepburnSyllables = map[string]string{
+	"sya": "sha", "syu": "shu", "syo": "sho",
+	"tya": "cha", "tyu": "chu", "tyo": "cho",
+	"cya": "cha", "cyu": "chu", "cyo": "cho",
+	"zya": "ja", "zyu": "ju", "zyo": "jo",
+	"jya": "ja", "jyu": "ju", "jyo": "jo",
+	"dya": "ja", "dyu": "ju", "dyo": "jo",
+	"sha": "sha", "shu": "shu", "sho": "sho", "shi": "shi",
+	"cha": "cha", "chu": "chu", "cho": "cho", "chi": "chi",
+	"tsu": "tsu",
+
+	"si": "shi", "ti": "chi", "tu": "tsu", "hu": "fu",
+	"zi": "ji", "di": "ji", "du": "zu",
+}
+
+// NormalizeRomaji folds the different ways of writing the same romaji into one form so that
+// "kyō", "kyou", "kyoo" and "KYOU" compare equal: letters are lower-cased, long vowel marks are
+// expanded, "oo" is written "ou", Kunrei and wāpuro syllables are converted to Hepburn, "m" before
+// "b" and "p" is written "n", and apostrophes, hyphens and whitespace are dropped.
+func NormalizeRomaji(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if long, ok := longVowels[r]; ok {
+			b.WriteString(long)
+			continue
+		}
+		if unicode.IsSpace(r) || r == '\'' || r == '’' || r == '-' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	s = strings.ReplaceAll(b.String(), "oo", "ou")
+
+	b.Reset()
+	for i := 0; i < len(s); {
+		matched := false
+		for n := 3; n >= 2; n-- {
+			if i+n > len(s) {
+				continue
+			}
+			if hepburn, ok := hepburnSyllables[s[i:i+n]]; ok {
+				b.WriteString(hepburn)
+				i += n
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if s[i] == 'm' && i+1 < len(s) && (s[i+1] == 'b' || s[i+1] == 'p') {
+			b.WriteByte('n')
+		} else {
+			b.WriteByte(s[i])
+		}
+		i++
+	}
+	return b.String()
+}
+
+// backfillRomajiNormalized fills words.romaji_normalized for rows written before the column
+// existed. The normalization lives in Go, so the migration that adds the column cannot do it.
+func backfillRomajiNormalized(db *sql.DB) error {
+	rows, err := db.Query("SELECT id, romaji FROM words WHERE romaji_normalized IS NULL")
+	if err != nil {
+		return err
+	}
+	normalized := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var romaji string
+		if err := rows.Scan(&id, &romaji); err != nil {
+			rows.Close()
+			return err
+		}
+		normalized[id] = NormalizeRomaji(romaji)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, romaji := range normalized {
+		if _, err := db.Exec("UPDATE words SET romaji_normalized = ? WHERE id = ?", romaji, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchWordsByRomaji retrieves the words whose romaji starts with the given query, compared in
+// normalized form.
+func (s *Service) SearchWordsByRomaji(query string) ([]models.Word, error) {
+	prefix := NormalizeRomaji(query)
+	rows, err := s.DB.Query(`SELECT `+wordColumns+` FROM words w
+	                         WHERE w.deleted_at IS NULL AND w.romaji_normalized >= ? AND w.romaji_normalized < ?
+	                         ORDER BY w.romaji_normalized, w.id`, prefix, prefix+"\uffff")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanWords(rows)
+}
+
+// CheckRomajiAnswer reports whether the answer is the romaji of the word, compared in normalized form.
+func (s *Service) CheckRomajiAnswer(wordID int, answer string) (bool, *models.Word, error) {
+	word, err := s.GetWordByID(wordID)
+	if err != nil {
+		return false, nil, err
+	}
+	return NormalizeRomaji(answer) == NormalizeRomaji(word.Romaji), word, nil
+}
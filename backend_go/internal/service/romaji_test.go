@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"testing"
+
+	"backend_go/internal/service"
+	"backend_go/internal/testutil"
+)
+
+func TestNormalizeRomaji(t *testing.T) {
+	// Each row lists spellings of one word that must all normalize to want
+	tests := []struct {
+		want      string
+		spellings []string
+	}{
+		// Long vowels: macrons (Hepburn), circumflexes (Kunrei), doubled vowels and "oo"
+		{"kyou", []string{"kyō", "kyou", "kyoo", "KYOU", "Kyô"}},
+		{"toukyou", []string{"Tōkyō", "toukyou", "tookyoo", "Tôkyô"}},
+		{"ousaka", []string{"Ōsaka", "oosaka", "ôsaka", "OUSAKA"}},
+		{"okaasan", []string{"okāsan", "okâsan", "okaasan"}},
+		{"oniisan", []string{"onīsan", "onîsan", "oniisan"}},
+		{"kuukou", []string{"kūkō", "kûkô", "kuukou", "kuukoo"}},
+		{"oneesan", []string{"onēsan", "onêsan", "oneesan"}},
+
+		// Kunrei and wāpuro syllables
+		{"hashi", []string{"hashi", "hasi"}},
+		{"chotto", []string{"chotto", "tyotto"}},
+		{"tsukue", []string{"tsukue", "tukue"}},
+		{"fujisan", []string{"fujisan", "huzisan", "Fuji-san", "Fuji san"}},
+		{"jisho", []string{"jisho", "zisyo", "jisyo"}},
+		{"shukudai", []string{"shukudai", "syukudai"}},
+		{"ocha", []string{"ocha", "otya", "ocya"}},
+		{"matcha", []string{"matcha", "mattya"}},
+		{"chuugoku", []string{"chūgoku", "tyuugoku", "chuugoku"}},
+		{"konnichiwa", []string{"konnichiwa", "konnitiwa", "kon'nichiwa"}},
+		{"jouzu", []string{"jōzu", "zyouzu", "jyouzu", "dyouzu", "zyoozu", "jouzu"}},
+		{"hanaji", []string{"hanaji", "hanazi", "hanadi"}},
+		{"tsuzuku", []string{"tsuzuku", "tuduku"}},
+		{"ja", []string{"ja", "zya", "jya", "dya"}},
+		{"ju", []string{"ju", "zyu", "jyu", "dyu"}},
+		{"sha", []string{"sha", "sya"}},
+		{"cho", []string{"cho", "tyo", "cyo"}},
+
+		// Syllabic n before b and p, apostrophes and whitespace
+		{"shinbun", []string{"shinbun", "shimbun", "sinbun", "SHIMBUN"}},
+		{"tenpura", []string{"tenpura", "tempura"}},
+		{"ganbatte", []string{"ganbatte", "gambatte"}},
+		{"konya", []string{"kon'ya", "kon’ya", "konya"}},
+		{"taberu", []string{"taberu", " taberu ", "ta beru", "Ta-Beru"}},
+		{"", []string{"", " ", "'"}},
+	}
+	for _, tt := range tests {
+		for _, spelling := range tt.spellings {
+			if got := service.NormalizeRomaji(spelling); got != tt.want {
+				t.Errorf("NormalizeRomaji(%q) = %q, want %q", spelling, got, tt.want)
+			}
+		}
+	}
+
+	// Different words must stay different
+	for _, pair := range [][2]string{{"tokyo", "toukyou"}, {"obasan", "obaasan"}, {"kite", "kitte"}, {"shi", "chi"}, {"zu", "tsu"}} {
+		if service.NormalizeRomaji(pair[0]) == service.NormalizeRomaji(pair[1]) {
+			t.Errorf("%q and %q normalize to the same %q", pair[0], pair[1], service.NormalizeRomaji(pair[0]))
+		}
+	}
+}
+
+func TestRomajiMatching(t *testing.T) {
+	svc := testutil.NewService(t)
+	f := testutil.NewFixture(t, svc.DB).
+		Group("N5").
+		Word("今日", "kyou", "today").
+		Word("東京", "Tōkyō", "Tokyo").
+		Word("新聞", "shimbun", "newspaper")
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"KYŌ", []string{"今日"}},
+		{"kyo", []string{"今日"}},
+		{"tookyoo", []string{"東京"}},
+		{"sinbu", []string{"新聞"}},
+		{"x", nil},
+	}
+	for _, tt := range tests {
+		words, err := svc.SearchWordsByRomaji(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(words) != len(tt.want) {
+			t.Errorf("search %q found %d words, want %v", tt.query, len(words), tt.want)
+			continue
+		}
+		for i, japanese := range tt.want {
+			if words[i].ID != f.WordID(japanese) {
+				t.Errorf("search %q found %s, want %s", tt.query, words[i].Japanese, japanese)
+			}
+		}
+	}
+
+	for answer, want := range map[string]bool{"toukyou": true, "TOKYO": false, "Tôkyô": true, "to-kyo-": false} {
+		correct, _, err := svc.CheckRomajiAnswer(f.WordID("東京"), answer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if correct != want {
+			t.Errorf("answer %q correct = %v, want %v", answer, correct, want)
+		}
+	}
+}
+
+func TestRomajiBackfill(t *testing.T) {
+	svc := testutil.NewService(t)
+	f := testutil.NewFixture(t, svc.DB).Group("N5").Word("今日", "kyō", "today")
+	if _, err := svc.DB.Exec("UPDATE words SET romaji_normalized = NULL"); err != nil {
+		t.Fatal(err)
+	}
+	// Migrating again applies no script but backfills the words written without the column
+	if err := service.MigrateDir(svc.DB, "../../db/migrations"); err != nil {
+		t.Fatal(err)
+	}
+	words, err := svc.SearchWordsByRomaji("kyou")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 1 || words[0].ID != f.WordID("今日") {
+		t.Errorf("search after backfill found %v, want 今日", words)
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	"backend_go/internal/models"
+	"backend_go/internal/srs"
+)
+
+// execQuerier is implemented by both *sql.DB and *sql.Tx.
+type execQuerier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// updateSchedule recomputes the spaced repetition schedule of a word for a learner, 0 for reviews
+// made without one, by replaying their review history in order and stores it in word_srs.
+// Replaying, rather than stepping the stored state, keeps the schedule right when reviews are
+// corrected, merged or removed. Skipped reviews are not replayed. Words left without answered
+// reviews lose their schedule and nil is returned.
+func updateSchedule(db execQuerier, wordID, userID int) (*models.WordSchedule, error) {
+	rows, err := db.Query(`SELECT correct, created_at FROM word_review_items
+	                       WHERE word_id = ? AND COALESCE(user_id, 0) = ? AND NOT skipped
+	                       ORDER BY created_at, id`, wordID, userID)
+	if err != nil {
+		return nil, err
+	}
+	state := srs.New()
+	reviews := 0
+	for rows.Next() {
+		var correct bool
+		var createdAt time.Time
+		if err := rows.Scan(&correct, &createdAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		state = srs.Next(state, correct, createdAt)
+		reviews++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if reviews == 0 {
+		_, err := db.Exec("DELETE FROM word_srs WHERE word_id = ? AND user_id = ?", wordID, userID)
+		return nil, err
+	}
+
+	schedule := &models.WordSchedule{
+		WordID:         wordID,
+		Repetitions:    state.Repetitions,
+		IntervalDays:   state.IntervalDays,
+		EaseFactor:     state.Ease,
+		LastReviewedAt: state.LastReviewedAt.UTC(),
+		NextReviewAt:   state.NextReviewAt().UTC(),
+	}
+	_, err = db.Exec(`INSERT OR REPLACE INTO word_srs (word_id, user_id, repetitions, interval_days, ease_factor, last_reviewed_at, next_review_at)
+	                  VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		wordID, userID, schedule.Repetitions, schedule.IntervalDays, schedule.EaseFactor,
+		schedule.LastReviewedAt.Format(sqliteTimeFormat), schedule.NextReviewAt.Format(sqliteTimeFormat))
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// updateSchedules recomputes the schedules of a word for every learner who reviewed it (see
+// updateSchedule), dropping those of learners left without reviews.
+func updateSchedules(db execQuerier, wordID int) error {
+	rows, err := db.Query(`SELECT DISTINCT COALESCE(user_id, 0) FROM word_review_items WHERE word_id = ?
+	                       UNION SELECT user_id FROM word_srs WHERE word_id = ?`, wordID, wordID)
+	if err != nil {
+		return err
+	}
+	userIDs, err := scanIDs(rows)
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		if _, err := updateSchedule(db, wordID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanIDs reads and closes rows selecting one integer column, so the connection is free for the
+// statements that use the IDs within the same transaction.
+func scanIDs(rows *sql.Rows) ([]int, error) {
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// rebuildSchedules recomputes the schedules of every reviewed word, split by learner when 0040
+// adds user_id to word_srs.
+func rebuildSchedules(db execQuerier) error {
+	if _, err := db.Exec("DELETE FROM word_srs"); err != nil {
+		return err
+	}
+	rows, err := db.Query("SELECT DISTINCT word_id FROM word_review_items")
+	if err != nil {
+		return err
+	}
+	wordIDs, err := scanIDs(rows)
+	if err != nil {
+		return err
+	}
+	for _, wordID := range wordIDs {
+		if err := updateSchedules(db, wordID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetWordSchedule retrieves the learner's spaced repetition schedule of a word, or sql.ErrNoRows if
+// they have never reviewed it.
+func (s *Service) GetWordSchedule(wordID int) (*models.WordSchedule, error) {
+	var schedule models.WordSchedule
+	err := s.DB.QueryRow(`SELECT word_id, repetitions, interval_days, ease_factor, last_reviewed_at, next_review_at
+	                      FROM word_srs WHERE word_id = ? AND `+s.scheduleCond(""), wordID).
+		Scan(&schedule.WordID, &schedule.Repetitions, &schedule.IntervalDays, &schedule.EaseFactor,
+			&schedule.LastReviewedAt, &schedule.NextReviewAt)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import "strconv"
+
+// ForUser returns a copy of the service acting for the learner with the given user ID: the study
+// sessions, reviews, review schedules and achievements it creates belong to that learner, and
+// those it reads are limited to theirs. Words and groups stay shared. A zero userID returns the
+// service itself, which creates sessions without a learner and reads everyone's, except for the
+// review schedules and achievements, which it keeps apart from those of learners (see scheduleCond).
+func (s *Service) ForUser(userID int) *Service {
+	if userID == 0 {
+		return s
+	}
+	scoped := *s
+	scoped.userID = userID
+	return &scoped
+}
+
+// UserID returns the learner the service acts for, 0 if none (see ForUser).
+func (s *Service) UserID() int {
+	return s.userID
+}
+
+// userCond returns the condition limiting the study sessions or reviews of the table aliased alias,
+// or of the unqualified table when alias is empty, to the learner's, and "1" when the service acts
+// for no learner. Every read of study_sessions and word_review_items adds it. The user ID is an
+// integer and is written into the condition, so the queries and the fragments shared between them
+// keep their arguments.
+func (s *Service) userCond(alias string) string {
+	if s.userID == 0 {
+		return "1"
+	}
+	return userColumn(alias) + " = " + strconv.Itoa(s.userID)
+}
+
+// scheduleCond returns the condition limiting the review schedules or achievements of the table
+// aliased alias, or of the unqualified table when alias is empty, to the learner's. Unlike study
+// sessions and reviews, these are never read across learners: without a learner it keeps those
+// stored with user_id 0, which replay the reviews made without one (see updateSchedule).
+func (s *Service) scheduleCond(alias string) string {
+	return userColumn(alias) + " = " + strconv.Itoa(s.userID)
+}
+
+// userColumn returns the user_id column of the table aliased alias.
+func userColumn(alias string) string {
+	if alias == "" {
+		return "user_id"
+	}
+	return alias + ".user_id"
+}
+
+// checkOwner returns sql.ErrNoRows if the service acts for a learner and the study session is not
+// theirs, so that sessions of other learners look like missing ones.
+func (s *Service) checkOwner(sessionID int) error {
+	if s.userID == 0 {
+		return nil
+	}
+	_, err := s.GetStudySessionByID(sessionID)
+	return err
+}
+
+// owner returns the user_id to store on the study sessions and reviews the service creates, nil
+// without a learner.
+func (s *Service) owner() interface{} {
+	if s.userID == 0 {
+		return nil
+	}
+	return s.userID
+}
@@ -0,0 +1,183 @@
+package service_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+	"backend_go/internal/testutil"
+)
+
+// countRows counts the rows of table matching where.
+func countRows(t *testing.T, svc *service.Service, table, where string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := svc.DB.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE "+where, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestForUser(t *testing.T) {
+	svc := testutil.NewService(t)
+	var users [2]int
+	for i, name := range []string{"hana", "kenji"} {
+		id, err := svc.CreateUser(name, "password123", models.RoleViewer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		users[i] = id
+	}
+	f := testutil.NewFixture(t, svc.DB).Group("N5").Word("水", "mizu", "water").InGroup("N5").
+		User(users[0]).Session()
+	hanaSession := f.SessionID()
+	kenjiSession := f.User(users[1]).Session().SessionID()
+	hana, kenji := svc.ForUser(users[0]), svc.ForUser(users[1])
+
+	if _, err := hana.ReviewWord(hanaSession, f.WordID("水"), true, false, 1, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kenji.ReviewWord(kenjiSession, f.WordID("水"), false, false, 1, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	// The sessions of other learners look missing
+	if _, err := hana.ReviewWord(kenjiSession, f.WordID("水"), true, false, 1, "", ""); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("reviewing in another learner's session: %v, want sql.ErrNoRows", err)
+	}
+	if _, err := hana.GetStudySessionByID(kenjiSession); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("reading another learner's session: %v, want sql.ErrNoRows", err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		svc     *service.Service
+		want    []int
+		reviews int
+	}{
+		{"hana", hana, []int{hanaSession}, 1},
+		{"kenji", kenji, []int{kenjiSession}, 1},
+		{"no learner", svc, []int{hanaSession, kenjiSession}, 2},
+	} {
+		sessions, err := tt.svc.ListStudySessions(models.StudySessionFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[int]bool)
+		for _, session := range sessions {
+			got[session.ID] = true
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s lists sessions %v, want %v", tt.name, got, tt.want)
+		}
+		for _, id := range tt.want {
+			if !got[id] {
+				t.Errorf("%s does not list session %d", tt.name, id)
+			}
+		}
+		if n, err := tt.svc.CountStudySessions(models.StudySessionFilter{}); err != nil || n != len(tt.want) {
+			t.Errorf("%s counts %d sessions, %v, want %d", tt.name, n, err, len(tt.want))
+		}
+	}
+
+	// Each learner's review schedules are their own
+	for _, user := range users {
+		if n := countRows(t, svc, "word_srs", "user_id = ?", user); n != 1 {
+			t.Errorf("user %d has %d review schedules, want 1", user, n)
+		}
+	}
+
+	// Resetting a learner's history leaves the others' alone
+	if err := hana.ResetHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, svc, "word_review_items", "user_id = ?", users[0]); n != 0 {
+		t.Errorf("%d reviews of hana are left after the reset, want none", n)
+	}
+	if n := countRows(t, svc, "word_srs", "user_id = ?", users[0]); n != 0 {
+		t.Errorf("%d review schedules of hana are left after the reset, want none", n)
+	}
+	if n := countRows(t, svc, "word_review_items", "user_id = ?", users[1]); n != 1 {
+		t.Errorf("kenji has %d reviews after hana's reset, want 1", n)
+	}
+	if n := countRows(t, svc, "word_srs", "user_id = ?", users[1]); n != 1 {
+		t.Errorf("kenji has %d review schedules after hana's reset, want 1", n)
+	}
+}
+
+func TestLearnerAchievements(t *testing.T) {
+	svc := testutil.NewService(t)
+	var users [2]int
+	for i, name := range []string{"hana", "kenji"} {
+		id, err := svc.CreateUser(name, "password123", models.RoleViewer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		users[i] = id
+	}
+	hana, kenji := svc.ForUser(users[0]), svc.ForUser(users[1])
+
+	// kenji masters 9 words and reviews 100 times
+	f := testutil.NewFixture(t, svc.DB).Group("N5").User(users[1]).Session()
+	for _, japanese := range []string{"一", "二", "三", "四", "五", "六", "七", "八", "九"} {
+		f.Word(japanese, "kazu", "number").Review(true).Review(true).Review(true)
+	}
+	for i := 0; i < 100-9*service.MasteryCorrectReviews; i++ {
+		f.Review(false)
+	}
+	kenjiSession := f.SessionID()
+	hanaSession := f.User(users[0]).Word("十", "juu", "ten").Session().SessionID()
+
+	// hana's first review and mastered word count alone, whatever kenji did
+	for attempt := 1; attempt <= service.MasteryCorrectReviews; attempt++ {
+		if _, err := hana.ReviewWord(hanaSession, f.WordID("十"), true, false, attempt, "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := kenji.ReviewWord(kenjiSession, f.WordID("一"), true, false, service.MasteryCorrectReviews+1, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		svc  *service.Service
+		want map[string]bool
+	}{
+		{"hana", hana, map[string]bool{service.AchievementFirstReview: true}},
+		{"kenji", kenji, map[string]bool{service.AchievementFirstReview: true, service.AchievementReviews100: true}},
+	} {
+		list, err := tt.svc.ListAchievements()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range list {
+			if (a.EarnedAt != nil) != tt.want[a.Key] {
+				t.Errorf("%s earned %s = %v, want %v", tt.name, a.Key, a.EarnedAt != nil, tt.want[a.Key])
+			}
+		}
+	}
+
+	// Learner events are listed to their learner only, and no milestone counts both learners' words
+	for _, tt := range []struct {
+		name string
+		svc  *service.Service
+		want int
+	}{
+		{"hana", hana, 1},
+		{"kenji", kenji, 2},
+		{"no learner", svc, 3},
+	} {
+		events, total, err := tt.svc.ListEvents(nil, 1, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != tt.want || len(events) != tt.want {
+			t.Errorf("%s lists %d of %d events, want %d", tt.name, len(events), total, tt.want)
+		}
+		for _, e := range events {
+			if e.Type != service.EventAchievementEarned {
+				t.Errorf("%s lists a %s event: %s", tt.name, e.Type, e.Payload)
+			}
+		}
+	}
+}
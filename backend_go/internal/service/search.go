@@ -0,0 +1,104 @@
+package service
+
+import (
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend_go/internal/models"
+)
+
+// searchFields names the columns of the words_fts full-text index, in order.
+var searchFields = []string{"japanese", "romaji", "english", "meanings"}
+
+// snippetStart and snippetEnd delimit the matches in the snippets returned by SQLite. They are
+// control characters that never occur in words, so they survive HTML escaping and are then
+// replaced with <mark> tags.
+const (
+	snippetStart = "\x02"
+	snippetEnd   = "\x03"
+)
+
+// snippetTokens is the number of tokens around the matches kept in a snippet.
+const snippetTokens = 12
+
+// ftsQuery turns search text into a words_fts MATCH expression in which every term must prefix a
+// token. Quotes are dropped so the text cannot inject FTS syntax. It returns "" if no term is left.
+func ftsQuery(text string) string {
+	var terms []string
+	for _, term := range strings.Fields(strings.ReplaceAll(text, `"`, " ")) {
+		terms = append(terms, `"`+term+`*"`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// SearchWords finds the words not deleted whose japanese, romaji, english or meanings contain tokens
+// starting with every term of text, using the words_fts index. Results list the fields that matched
+// and a highlighted snippet. Words matching on an earlier field of searchFields come first, so a
+// match on the Japanese text ranks above one on a meaning, then words are ordered by ID. At most
+// limit results are returned.
+func (s *Service) SearchWords(text string, limit int) ([]models.WordSearchResult, error) {
+	results := make([]models.WordSearchResult, 0)
+	query := ftsQuery(text)
+	if query == "" {
+		return results, nil
+	}
+	rows, err := s.DB.Query(`SELECT `+wordColumns+`, offsets(words_fts), snippet(words_fts, ?, ?, '...', -1, ?)
+	                         FROM words_fts
+	                         JOIN words w ON w.id = words_fts.docid AND w.deleted_at IS NULL
+	                         WHERE words_fts MATCH ?
+	                         ORDER BY w.id`, snippetStart, snippetEnd, snippetTokens, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	best := make(map[int]int)
+	for rows.Next() {
+		var r models.WordSearchResult
+		var offsets, snippet string
+		if err := scanWord(rows, &r.Word, &offsets, &snippet); err != nil {
+			return nil, err
+		}
+		columns := matchedColumns(offsets)
+		r.MatchedFields = make([]string, 0, len(columns))
+		for _, col := range columns {
+			r.MatchedFields = append(r.MatchedFields, searchFields[col])
+		}
+		if len(columns) > 0 {
+			best[r.ID] = columns[0]
+		}
+		r.Snippet = strings.NewReplacer(snippetStart, "<mark>", snippetEnd, "</mark>").Replace(html.EscapeString(snippet))
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return best[results[i].ID] < best[results[j].ID]
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// matchedColumns reads the result of the FTS offsets() function, groups of four integers whose first
+// is the column of a match, and returns the columns that matched in ascending order.
+func matchedColumns(offsets string) []int {
+	fields := strings.Fields(offsets)
+	seen := make(map[int]bool)
+	var columns []int
+	for i := 0; i+3 < len(fields); i += 4 {
+		col, err := strconv.Atoi(fields[i])
+		if err != nil || col < 0 || col >= len(searchFields) || seen[col] {
+			continue
+		}
+		seen[col] = true
+		columns = append(columns, col)
+	}
+	sort.Ints(columns)
+	return columns
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend_go/db/seeds"
+	"backend_go/internal/models"
+)
+
+// SeedEntry is one word of a seed file. Japanese may also be given as "kanji", the key used
+// by db/seeds/vocabulary_seed.json.
+type SeedEntry struct {
+	Japanese  string   `json:"japanese"`
+	Kanji     string   `json:"kanji,omitempty"`
+	Reading   string   `json:"reading,omitempty"`
+	Romaji    string   `json:"romaji"`
+	English   string   `json:"english"`
+	Meanings  []string `json:"meanings,omitempty"`
+	Parts     string   `json:"parts,omitempty"`
+	JLPTLevel *int     `json:"jlpt_level,omitempty"`
+	Mnemonic  string   `json:"mnemonic,omitempty"`
+	Group     string   `json:"group,omitempty"`
+	Source    string   `json:"source,omitempty"`
+	SourceID  string   `json:"source_id,omitempty"`
+}
+
+// DefaultSeedDataset is the dataset SeedData loads into an empty database.
+const DefaultSeedDataset = "n5"
+
+// ErrUnknownDataset is returned for a seed dataset that is neither built in nor in the seed directory.
+var ErrUnknownDataset = errors.New("unknown seed dataset")
+
+// datasetNamePattern restricts dataset names so that they cannot reach outside the seed directory.
+var datasetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// datasetSource is the source recorded for the words added from the seed dataset called name.
+func datasetSource(name string) string {
+	return "seed:" + name
+}
+
+// fileSource is the source recorded for the words added from the seed file at path: its format
+// and base name, such as csv:words.csv.
+func fileSource(path string) string {
+	return strings.TrimPrefix(filepath.Ext(path), ".") + ":" + filepath.Base(path)
+}
+
+// SetSeedSources sets the JSON or CSV file SeedData loads instead of the DefaultSeedDataset, and
+// the directory of seed datasets loaded by name next to the built-in ones. Empty values mean none.
+func (s *Service) SetSeedSources(file, dir string) {
+	s.seedFile, s.seedDir = file, dir
+}
+
+// loadSeedDataset reads the seed dataset called name: the file <name>.json or <name>.csv in the
+// seed directory when it has one, otherwise the built-in db/seeds/datasets/<name>.json. It returns
+// ErrUnknownDataset when there is no such dataset.
+func (s *Service) loadSeedDataset(name string) ([]SeedEntry, error) {
+	if !datasetNamePattern.MatchString(name) {
+		return nil, ErrUnknownDataset
+	}
+	if dir := s.seedDir; dir != "" {
+		for _, ext := range []string{".json", ".csv"} {
+			entries, err := loadSeedFile(filepath.Join(dir, name+ext))
+			if !errors.Is(err, fs.ErrNotExist) {
+				return entries, err
+			}
+		}
+	}
+	f, err := seeds.Datasets.Open(path.Join("datasets", name+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrUnknownDataset
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readSeedEntries(f, name+".json")
+}
+
+// SeedDatasetNames lists the seed datasets that can be loaded, built-in ones and those in the seed directory, sorted by name.
+func (s *Service) SeedDatasetNames() ([]string, error) {
+	files, err := fs.Glob(seeds.Datasets, "datasets/*.json")
+	if err != nil {
+		return nil, err
+	}
+	if dir := s.seedDir; dir != "" {
+		for _, pattern := range []string{"*.json", "*.csv"} {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+	}
+
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if datasetNamePattern.MatchString(name) && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// LoadSeedDataset adds the words and groups of the named seed dataset to the database, as described
+// at seedEntries. It returns ErrUnknownDataset when there is no such dataset.
+func (s *Service) LoadSeedDataset(name string) (*models.SeedResult, error) {
+	entries, err := s.loadSeedDataset(name)
+	if err != nil {
+		return nil, err
+	}
+	result, err := seedEntries(s.DB, entries, datasetSource(name))
+	if err != nil {
+		return nil, err
+	}
+	result.Dataset = name
+	s.recordImportEvent(0, result.WordsCreated)
+	return result, nil
+}
+
+// ExportSeedEntries returns every word in the seed file format, with one entry per group the word
+// belongs to (or a single entry without a group), so that the export can be loaded again as a dataset.
+func (s *Service) ExportSeedEntries() ([]SeedEntry, error) {
+	rows, err := s.DB.Query(`SELECT ` + wordColumns + `, g.name FROM words w
+	                         LEFT JOIN word_groups wg ON wg.word_id = w.id
+	                         LEFT JOIN groups g ON g.id = wg.group_id
+	                         WHERE w.deleted_at IS NULL
+	                         ORDER BY w.id, g.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]SeedEntry, 0)
+	for rows.Next() {
+		var word models.Word
+		var group sql.NullString
+		if err := scanWord(rows, &word, &group); err != nil {
+			return nil, err
+		}
+		entry := SeedEntry{Japanese: word.Japanese, Romaji: word.Romaji, English: word.English, Parts: string(word.Parts),
+			JLPTLevel: word.JLPTLevel, Group: group.String}
+		if len(word.Meanings) > 1 {
+			entry.Meanings = word.Meanings
+		}
+		if word.Reading != nil {
+			entry.Reading = *word.Reading
+		}
+		if word.Mnemonic != nil {
+			entry.Mnemonic = *word.Mnemonic
+		}
+		if word.Source != nil {
+			entry.Source = *word.Source
+		}
+		if word.SourceID != nil {
+			entry.SourceID = *word.SourceID
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// loadSeedFile reads seed entries from a .json file holding an array of SeedEntry objects or from
+// a .csv file whose header row names the columns (japanese or kanji, romaji, english, meanings, group,
+// parts, jlpt_level, mnemonic, source, source_id). The meanings column lists the meanings separated by ";" or "/".
+func loadSeedFile(path string) ([]SeedEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readSeedEntries(f, path)
+}
+
+// readSeedEntries parses the seed file called name, whose extension selects the format.
+func readSeedEntries(r io.Reader, name string) ([]SeedEntry, error) {
+	var entries []SeedEntry
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		if err := json.NewDecoder(r).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("seed file %s: %w", name, err)
+		}
+	case ".csv":
+		if entries, err = readSeedCSV(r); err != nil {
+			return nil, fmt.Errorf("seed file %s: %w", name, err)
+		}
+	default:
+		return nil, fmt.Errorf("seed file %s: unsupported format, expected .json or .csv", name)
+	}
+
+	for i := range entries {
+		if entries[i].Japanese == "" {
+			entries[i].Japanese = entries[i].Kanji
+		}
+		if entries[i].Romaji == "" {
+			entries[i].Romaji = GenerateRomaji(entries[i].Japanese, entries[i].Reading)
+		}
+		if entries[i].Japanese == "" || entries[i].Romaji == "" || entries[i].English == "" {
+			return nil, fmt.Errorf("seed file %s: entry %d requires japanese, romaji (or a kana reading) and english", name, i+1)
+		}
+	}
+	return entries, nil
+}
+
+// readSeedCSV parses seed entries from CSV with a header row.
+func readSeedCSV(r io.Reader) ([]SeedEntry, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var entries []SeedEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := SeedEntry{
+			Japanese: field(record, "japanese"),
+			Kanji:    field(record, "kanji"),
+			Reading:  field(record, "reading"),
+			Romaji:   field(record, "romaji"),
+			English:  field(record, "english"),
+			Meanings: splitMeanings(field(record, "meanings")),
+			Parts:    field(record, "parts"),
+			Mnemonic: field(record, "mnemonic"),
+			Group:    field(record, "group"),
+			Source:   field(record, "source"),
+			SourceID: field(record, "source_id"),
+		}
+		if v := field(record, "jlpt_level"); v != "" {
+			level, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid jlpt_level %q", len(entries)+2, v)
+			}
+			entry.JLPTLevel = &level
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ParseWordsCSV parses a CSV word list like a CSV seed file, with a header row naming the japanese
+// or kanji, reading, romaji, english, meanings, parts, jlpt_level, mnemonic, source and source_id
+// columns. Rows are returned as read, so callers must validate them.
+func ParseWordsCSV(r io.Reader) ([]models.ImportWord, error) {
+	entries, err := readSeedCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]models.ImportWord, len(entries))
+	for i, e := range entries {
+		if e.Japanese == "" {
+			e.Japanese = e.Kanji
+		}
+		words[i] = models.ImportWord{Japanese: e.Japanese, Reading: e.Reading, Romaji: e.Romaji, English: e.English, Meanings: e.Meanings,
+			Parts: e.Parts, JLPTLevel: e.JLPTLevel, Mnemonic: e.Mnemonic, Source: e.Source, SourceID: e.SourceID}
+	}
+	return words, nil
+}
+
+// seedEntries adds the words of a seed file in one transaction, see seedEntriesTx.
+func seedEntries(db *sql.DB, entries []SeedEntry, source string) (*models.SeedResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := seedEntriesTx(tx, entries, source)
+	if err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+// seedEntriesTx adds the words of a seed file in tx. Groups are matched by name and created when
+// missing, and words that already exist are reused (see importWords), so loading a dataset again
+// adds nothing. Entries without a source are recorded as coming from source.
+func seedEntriesTx(tx *sql.Tx, entries []SeedEntry, source string) (*models.SeedResult, error) {
+	result := &models.SeedResult{}
+	groupIDs := make(map[string]int64)
+	for _, e := range entries {
+		var groupID int64
+		if e.Group != "" {
+			id, ok := groupIDs[e.Group]
+			if !ok {
+				err := tx.QueryRow("SELECT id FROM groups WHERE name = ? COLLATE NOCASE", e.Group).Scan(&id)
+				if errors.Is(err, sql.ErrNoRows) {
+					res, err := tx.Exec("INSERT INTO groups (name, created_at, updated_at) VALUES (?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)", e.Group)
+					if err != nil {
+						return nil, err
+					}
+					if id, err = res.LastInsertId(); err != nil {
+						return nil, err
+					}
+					result.GroupsCreated++
+				} else if err != nil {
+					return nil, err
+				}
+				groupIDs[e.Group] = id
+			}
+			groupID = id
+		}
+
+		word := models.ImportWord{Japanese: e.Japanese, Reading: e.Reading, Romaji: e.Romaji, English: e.English, Meanings: e.Meanings,
+			Parts: e.Parts, JLPTLevel: e.JLPTLevel, Mnemonic: e.Mnemonic, Source: e.Source, SourceID: e.SourceID}
+		created, reused, linked, err := importWords(tx, groupID, []models.ImportWord{word}, source)
+		if err != nil {
+			return nil, err
+		}
+		result.WordsCreated += created
+		result.WordsReused += reused
+		result.WordsLinked += linked
+	}
+	return result, nil
+}
@@ -2,47 +2,117 @@ package service
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
-	"math"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 
+	"backend_go/internal/config"
+	"backend_go/internal/media"
 	"backend_go/internal/models"
+	"backend_go/internal/tts"
 )
 
 // Service encapsulates the business logic and database connection.
 type Service struct {
 	DB *sql.DB
+
+	// auth is nil unless JWT login has been enabled with EnableAuth
+	auth *AuthConfig
+	// media is nil unless file storage has been enabled with EnableMedia
+	media *media.Store
+	// tts is nil unless audio generation has been enabled with EnableTTS
+	tts tts.Synthesizer
+	// location is the timezone of day-based stats, nil for UTC, set with SetLocation
+	location *time.Location
+	// live holds the subscribers to the reviews of each study session
+	live *liveHub
+	// userID is the learner the service acts for, 0 if none, set with ForUser
+	userID int
+	// queryStats holds the timings of the statements run on DB
+	queryStats *queryStats
+	// seedFile and seedDir are the seed file and directory of seed datasets, set with SetSeedSources
+	seedFile string
+	seedDir  string
+	// env is the environment the server runs in, FullReset does not seed in production
+	env config.Env
 }
 
-// NewService initializes the Service with a connection to the SQLite database specified by dbPath.
-func NewService(dbPath string) (*Service, error) {
-	db, err := sql.Open("sqlite3", dbPath+"?_parseTime=true")
-	if err != nil {
-		return nil, err
+// SetLocation sets the timezone whose calendar days the day-based stats and date filters use.
+func (s *Service) SetLocation(loc *time.Location) {
+	s.location = loc
+}
+
+// Location returns the timezone of day-based stats, UTC unless SetLocation was called.
+func (s *Service) Location() *time.Location {
+	if s.location == nil {
+		return time.UTC
 	}
+	return s.location
+}
 
-	// Test the database connection
-	if err = db.Ping(); err != nil {
+// NewService initializes the Service with a connection to the SQLite database of cfg, applies
+// pending migrations, failing when one does not apply, and enables the features cfg configures: media storage, audio generation,
+// the stats timezone, slow query logging and the seed sources. Seeding is left to the caller (see
+// SeedData).
+func NewService(cfg *config.Config) (*Service, error) {
+	s, err := Open(cfg.DataSource())
+	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Database connection established")
+	// A half-migrated schema must not be served
+	if err := Migrate(s.DB); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("migrating database: %w", err)
+	}
 
-	// Run migrations
-	if err := Migrate(db); err != nil {
-		log.Println("Warning: migration failed:", err)
+	// Uploaded images and audio are stored under MediaDir
+	store, err := media.NewStore(cfg.MediaDir)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("creating media directory: %w", err)
+	}
+	s.EnableMedia(store)
+
+	// Word audio can be generated by an external text-to-speech command or HTTP endpoint
+	switch {
+	case cfg.TTSCommand != "":
+		s.EnableTTS(tts.Command{Command: cfg.TTSCommand})
+	case cfg.TTSURL != "":
+		s.EnableTTS(tts.HTTP{URL: cfg.TTSURL})
 	}
 
-	// Optionally seed data for testing purposes
-	if err := SeedData(db); err != nil {
-		log.Println("Warning: seeding data failed:", err)
+	s.SetLocation(cfg.Location)
+	s.SetSlowQueryThreshold(cfg.SlowQueryThreshold)
+	s.SetSeedSources(cfg.SeedFile, cfg.SeedDir)
+	s.env = cfg.Env
+	return s, nil
+}
+
+// Open connects to the SQLite database specified by dsn without migrating it. dsn is a file path
+// or a go-sqlite3 connection string, whose options such as _busy_timeout apply to every
+// connection. Every statement run on the connection is timed, see GetQueryTimings, and statements
+// outside transactions that fail because the database is busy are retried, see retryBusy.
+func Open(dsn string) (*Service, error) {
+	stats := &queryStats{queries: make(map[string]*models.QueryTiming)}
+	db := sql.OpenDB(&timedConnector{dsn: dsn, driver: &sqlite3.SQLiteDriver{}, stats: stats})
+
+	// Test the database connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	return &Service{DB: db}, nil
+	log.Println("Database connection established")
+	return &Service{DB: db, live: &liveHub{}, queryStats: stats}, nil
 }
 
 // Close closes the database connection.
@@ -50,9 +120,10 @@ func (s *Service) Close() error {
 	return s.DB.Close()
 }
 
-// GetWords fetches all words from the database.
-func (s *Service) GetWords() ([]models.Word, error) {
-	rows, err := s.DB.Query("SELECT id, japanese, romaji, english, parts FROM words")
+// GetWords fetches all words matching filter from the database in the given order.
+func (s *Service) GetWords(sort WordSort, filter WordFilter) ([]models.Word, error) {
+	join, where, args := filter.clauses(s.userCond(""))
+	rows, err := s.DB.Query("SELECT "+wordColumns+" FROM words w"+join+" WHERE w.deleted_at IS NULL"+where+sort.orderBy(), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +132,7 @@ func (s *Service) GetWords() ([]models.Word, error) {
 	words := make([]models.Word, 0)
 	for rows.Next() {
 		var word models.Word
-		if err := rows.Scan(&word.ID, &word.Japanese, &word.Romaji, &word.English, &word.Parts); err != nil {
+		if err := scanWord(rows, &word); err != nil {
 			return nil, err
 		}
 		words = append(words, word)
@@ -69,9 +140,41 @@ func (s *Service) GetWords() ([]models.Word, error) {
 	return words, nil
 }
 
-// CreateStudySession inserts a new study session into the database and returns its ID.
+// CountWords returns the number of words matching filter.
+func (s *Service) CountWords(filter WordFilter) (int, error) {
+	var count int
+	join, where, args := filter.clauses(s.userCond(""))
+	err := s.DB.QueryRow("SELECT COUNT(*) FROM words w"+join+" WHERE w.deleted_at IS NULL"+where, args...).Scan(&count)
+	return count, err
+}
+
+// CreateStudySession inserts a new study session into the database and returns its ID. A zero
+// studyActivityID uses the first study activity of the group's default activity type, failing with
+// ErrNoDefaultActivity when it has none or no activity has the type, and sql.ErrNoRows when the
+// group does not exist.
 func (s *Service) CreateStudySession(groupID int, studyActivityID int) (int64, error) {
-	result, err := s.DB.Exec("INSERT INTO study_sessions (group_id, study_activity_id) VALUES (?, ?)", groupID, studyActivityID)
+	return s.createStudySession(s.DB, groupID, studyActivityID)
+}
+
+// createStudySession inserts a study session with db, as described at CreateStudySession.
+func (s *Service) createStudySession(db execQuerier, groupID int, studyActivityID int) (int64, error) {
+	if studyActivityID == 0 {
+		var defaultType sql.NullString
+		if err := db.QueryRow("SELECT default_activity_type FROM groups WHERE id = ?", groupID).Scan(&defaultType); err != nil {
+			return 0, err
+		}
+		if !defaultType.Valid {
+			return 0, ErrNoDefaultActivity
+		}
+		err := db.QueryRow("SELECT id FROM study_activities WHERE type = ? ORDER BY id LIMIT 1", defaultType.String).Scan(&studyActivityID)
+		if err == sql.ErrNoRows {
+			return 0, ErrNoDefaultActivity
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	result, err := db.Exec("INSERT INTO study_sessions (group_id, study_activity_id, user_id) VALUES (?, ?, ?)", groupID, studyActivityID, s.owner())
 	if err != nil {
 		return 0, err
 	}
@@ -80,7 +183,7 @@ func (s *Service) CreateStudySession(groupID int, studyActivityID int) (int64, e
 
 // GetStudySessionByID retrieves a study session by its ID.
 func (s *Service) GetStudySessionByID(sessionID int) (*models.StudySession, error) {
-	query := `SELECT id, group_id, created_at, study_activity_id FROM study_sessions WHERE id = ?`
+	query := `SELECT id, group_id, created_at, study_activity_id, ended_at, notes FROM study_sessions WHERE id = ? AND ` + s.userCond("")
 	row := s.DB.QueryRow(query, sessionID)
 
 	var session models.StudySession
@@ -88,7 +191,7 @@ func (s *Service) GetStudySessionByID(sessionID int) (*models.StudySession, erro
 
 	log.Printf("Fetching study session with ID: %d", sessionID)
 
-	if err := row.Scan(&session.ID, &session.GroupID, &nullCreatedAt, &session.StudyActivityID); err != nil {
+	if err := row.Scan(&session.ID, &session.GroupID, &nullCreatedAt, &session.StudyActivityID, &session.EndedAt, &session.Notes); err != nil {
 		log.Printf("Error scanning row for session ID %d: %v", sessionID, err)
 		return nil, err
 	}
@@ -108,6 +211,7 @@ func (s *Service) GetDashboardLastStudySession() (map[string]interface{}, error)
 	query := `SELECT ss.id, ss.group_id, ss.created_at, ss.study_activity_id, g.name 
 	          FROM study_sessions ss
 	          JOIN groups g ON ss.group_id = g.id
+	          WHERE ` + s.userCond("ss") + `
 	          ORDER BY ss.created_at DESC 
 	          LIMIT 1`
 	row := s.DB.QueryRow(query)
@@ -144,16 +248,22 @@ func (s *Service) GetDashboardLastStudySession() (map[string]interface{}, error)
 	}, nil
 }
 
-// GetDashboardStudyProgress returns study progress statistics.
-func (s *Service) GetDashboardStudyProgress() (map[string]interface{}, error) {
+// GetDashboardStudyProgress returns study progress statistics, including the minutes studied in
+// total and in each of the calendar weeks ending with the week of now (see getStudyTime).
+func (s *Service) GetDashboardStudyProgress(now time.Time, weeks int) (map[string]interface{}, error) {
 	var totalStudied int
-	err := s.DB.QueryRow("SELECT COUNT(DISTINCT word_id) FROM word_review_items").Scan(&totalStudied)
+	err := s.DB.QueryRow("SELECT COUNT(DISTINCT word_id) FROM word_review_items WHERE " + s.userCond("")).Scan(&totalStudied)
 	if err != nil {
 		return nil, err
 	}
 
 	var totalAvailable int
-	err = s.DB.QueryRow("SELECT COUNT(*) FROM words").Scan(&totalAvailable)
+	err = s.DB.QueryRow("SELECT COUNT(*) FROM words WHERE deleted_at IS NULL").Scan(&totalAvailable)
+	if err != nil {
+		return nil, err
+	}
+
+	totalMinutes, weekly, err := s.getStudyTime(now, weeks)
 	if err != nil {
 		return nil, err
 	}
@@ -161,13 +271,18 @@ func (s *Service) GetDashboardStudyProgress() (map[string]interface{}, error) {
 	return map[string]interface{}{
 		"total_words_studied":   totalStudied,
 		"total_available_words": totalAvailable,
+		"total_study_minutes":   totalMinutes,
+		"weekly_study_minutes":  weekly,
 	}, nil
 }
 
-// GetDashboardQuickStats returns a quick overview of dashboard statistics.
-func (s *Service) GetDashboardQuickStats() (map[string]interface{}, error) {
+// GetDashboardQuickStats returns a quick overview of dashboard statistics. words_mastered counts the
+// words mastered as in GetJLPTBreakdown (see masteredWordCondition). recent_accuracy covers
+// the reviews of the last recentDays calendar days of the configured timezone, today included, and
+// lifetime_accuracy every review, both as percentages, 0 without reviews.
+func (s *Service) GetDashboardQuickStats(now time.Time, recentDays int) (map[string]interface{}, error) {
 	var totalWords int
-	if err := s.DB.QueryRow("SELECT COUNT(*) FROM words").Scan(&totalWords); err != nil {
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM words WHERE deleted_at IS NULL").Scan(&totalWords); err != nil {
 		return nil, err
 	}
 
@@ -176,109 +291,228 @@ func (s *Service) GetDashboardQuickStats() (map[string]interface{}, error) {
 		return nil, err
 	}
 
-	wordsMastered := int(math.Round(float64(totalWords) * 0.24))
+	// Only scheduled words have been reviewed, so only they can be mastered
+	var wordsMastered int
+	if err := s.DB.QueryRow(`SELECT COUNT(*) FROM word_srs ws
+	                         JOIN words w ON w.id = ws.word_id
+	                         WHERE w.deleted_at IS NULL AND ` + s.scheduleCond("ws") + ` AND ` + s.masteredWordCondition()).Scan(&wordsMastered); err != nil {
+		return nil, err
+	}
 
-	var avgCorrect sql.NullFloat64
-	if err := s.DB.QueryRow("SELECT AVG(CASE WHEN correct THEN 1.0 ELSE 0.0 END) FROM word_review_items").Scan(&avgCorrect); err != nil {
+	today := now.In(s.Location())
+	recentStart := time.Date(today.Year(), today.Month(), today.Day()-recentDays+1, 0, 0, 0, 0, today.Location())
+	recentReviews, recentCorrect, err := s.reviewCounts(recentStart, time.Time{})
+	if err != nil {
 		return nil, err
 	}
-	recentAccuracy := 0.0
-	if avgCorrect.Valid {
-		recentAccuracy = avgCorrect.Float64 * 100.0
+	lifetimeReviews, lifetimeCorrect, err := s.reviewCounts(time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
 	}
+	recentAccuracy := accuracyPercent(recentCorrect, recentReviews)
+	lifetimeAccuracy := accuracyPercent(lifetimeCorrect, lifetimeReviews)
 
 	return map[string]interface{}{
-		"total_words":     totalWords,
-		"total_groups":    totalGroups,
-		"words_mastered":  wordsMastered,
-		"recent_accuracy": recentAccuracy,
+		"total_words":       totalWords,
+		"total_groups":      totalGroups,
+		"words_mastered":    wordsMastered,
+		"recent_accuracy":   recentAccuracy,
+		"recent_days":       recentDays,
+		"lifetime_accuracy": lifetimeAccuracy,
 	}, nil
 }
 
-// SeedData inserts sample data into the database if tables are empty.
-func SeedData(db *sql.DB) error {
-	// Reset tables for testing purposes
-	stmts := []string{
-		"DELETE FROM word_review_items",
-		"DELETE FROM study_activities",
-		"DELETE FROM study_sessions",
-		"DELETE FROM word_groups",
-		"DELETE FROM words",
-		"DELETE FROM groups",
+// reviewCounts counts the answered reviews made from from up to, but excluding, to and the correct
+// ones among them. Skipped reviews are left out. A zero from or to leaves that end of the range open.
+func (s *Service) reviewCounts(from, to time.Time) (int, int, error) {
+	conds := []string{"NOT skipped", s.userCond("")}
+	var args []interface{}
+	if !from.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, from.UTC().Format(sqliteTimeFormat))
 	}
-	for _, stmt := range stmts {
-		if _, err := db.Exec(stmt); err != nil {
-			return err
-		}
+	if !to.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, to.UTC().Format(sqliteTimeFormat))
 	}
+	query := "SELECT COUNT(*), COALESCE(SUM(CASE WHEN correct THEN 1 ELSE 0 END), 0) FROM word_review_items WHERE " +
+		strings.Join(conds, " AND ")
+	var reviews, correct int
+	err := s.DB.QueryRow(query, args...).Scan(&reviews, &correct)
+	return reviews, correct, err
+}
 
-	// Reset auto-increment counters in sqlite_sequence
-	seqTables := []string{"groups", "words", "study_sessions", "word_review_items", "study_activities", "word_groups"}
-	for _, table := range seqTables {
-		db.Exec("DELETE FROM sqlite_sequence WHERE name=?", table)
+// accuracyPercent returns the percentage of correct reviews, 0 without reviews.
+func accuracyPercent(correct, reviews int) float64 {
+	if reviews == 0 {
+		return 0
 	}
+	return float64(correct) / float64(reviews) * 100.0
+}
 
-	// Insert seed data in proper order
-	// 1. Insert a group
-	if _, err := db.Exec("INSERT INTO groups (name) VALUES (?)", "Basic Greetings"); err != nil {
+// SeedData inserts demo data into an empty database: the words and groups of the JSON or CSV seed
+// file when one is set and exists, otherwise those of the DefaultSeedDataset. A database that
+// already has words or groups is left untouched.
+func (s *Service) SeedData() error {
+	var count int
+	if err := s.DB.QueryRow("SELECT (SELECT COUNT(*) FROM words) + (SELECT COUNT(*) FROM groups)").Scan(&count); err != nil {
 		return err
 	}
+	if count > 0 {
+		return nil
+	}
 
-	// 2. Insert a word
-	if _, err := db.Exec("INSERT INTO words (japanese, romaji, english, parts) VALUES (?, ?, ?, ?)", "こんにちは", "konnichiwa", "hello", ""); err != nil {
+	entries, source, err := s.defaultSeed()
+	if err != nil {
 		return err
 	}
+	_, err = seedEntries(s.DB, entries, source)
+	return err
+}
 
-	// 3. Insert a study session with a dummy study_activity_id (0) for now
-	if _, err := db.Exec("INSERT INTO study_sessions (group_id, study_activity_id, created_at) VALUES (?, ?, datetime('now'))", 1, 0); err != nil {
-		return err
+// defaultSeed loads the entries SeedData adds and their source: the seed file when there is one,
+// the DefaultSeedDataset otherwise.
+func (s *Service) defaultSeed() ([]SeedEntry, string, error) {
+	if path := s.seedFile; path != "" {
+		entries, err := loadSeedFile(path)
+		if err == nil {
+			log.Printf("Seeding %d words from %s", len(entries), path)
+			return entries, fileSource(path), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, "", err
+		}
+		log.Printf("Seed file %s not found, using the %s seed dataset", path, DefaultSeedDataset)
 	}
 
-	// 4. Insert a study activity for the study session with id 1 (assuming it's the first row)
-	if _, err := db.Exec("INSERT INTO study_activities (study_session_id, group_id) VALUES (?, ?)", 1, 1); err != nil {
-		return err
+	entries, err := s.loadSeedDataset(DefaultSeedDataset)
+	if err != nil {
+		return nil, "", err
 	}
+	log.Printf("Seeding %d words from the %s seed dataset", len(entries), DefaultSeedDataset)
+	return entries, datasetSource(DefaultSeedDataset), nil
+}
 
-	// 5. Update the inserted study session to set study_activity_id properly (to 1)
-	if _, err := db.Exec("UPDATE study_sessions SET study_activity_id = ? WHERE id = ?", 1, 1); err != nil {
+// Migrate applies the SQL migration scripts in db/migrations that have not been applied yet, as
+// MigrateDir does.
+func Migrate(db *sql.DB) error {
+	// Try primary path
+	dir := "backend_go/db/migrations"
+	if _, err := os.Stat(dir); err != nil {
+		// If not found, try alternate path
+		dir = "db/migrations"
+		if _, err := os.Stat(dir); err != nil {
+			return err
+		}
+	}
+	return MigrateDir(db, dir)
+}
+
+// MigrateDir applies the SQL migration scripts in dir that have not been applied yet. Scripts run
+// in file name order, each in a transaction that records it in schema_migrations (see
+// applyMigration).
+func MigrateDir(db *sql.DB, dir string) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
 		return err
 	}
 
-	// 6. Insert a word review item as an example
-	if _, err := db.Exec("INSERT INTO word_review_items (word_id, study_session_id, correct) VALUES (?, ?, ?)", 1, 1, true); err != nil {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
 		return err
 	}
+	sort.Strings(files)
 
-	return nil
+	for _, file := range files {
+		name := filepath.Base(file)
+		var applied int
+		if err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE name = ?", name).Scan(&applied); err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := applyMigration(db, name, string(data)); err != nil {
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+		log.Printf("Applied migration %s", name)
+	}
+	return backfillRomajiNormalized(db)
 }
 
-// Migrate executes the SQL migration scripts to initialize the database schema.
-func Migrate(db *sql.DB) error {
-	// Try primary path
-	filename := "backend_go/db/migrations/0001_init.sql"
-	data, err := os.ReadFile(filename)
+// applyMigration runs the statements of the migration file name, then its post migration step,
+// and records it in schema_migrations, all in one transaction so a failing file leaves nothing
+// half applied and is tried again in full on the next start. Migration files must not begin or
+// commit transactions of their own.
+func applyMigration(db *sql.DB, name, data string) error {
+	tx, err := db.Begin()
 	if err != nil {
-		// If not found, try alternate path
-		filename = "db/migrations/0001_init.sql"
-		data, err = os.ReadFile(filename)
-		if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range splitStatements(data) {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if post := postMigrations[name]; post != nil {
+		if err := post(tx); err != nil {
 			return err
 		}
 	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (name) VALUES (?)", name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	// Split the file content into individual statements
-	stmts := strings.Split(string(data), ";")
-	for _, stmt := range stmts {
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" {
+// postMigrations holds the steps written in Go that complete a migration file, by file name. Each
+// runs right after the statements of its file.
+var postMigrations = map[string]func(execQuerier) error{
+	"0033_parts_schema.sql":        normalizeWordParts,
+	"0034_word_scripts.sql":        backfillWordScripts,
+	"0039_japanese_normalized.sql": backfillJapaneseNormalized,
+	"0040_user_schedules.sql":      rebuildSchedules,
+}
+
+// splitStatements splits a migration file into its statements at semicolons, except those within the
+// BEGIN ... END body of a CREATE TRIGGER. Semicolons must not appear in comments or string literals.
+func splitStatements(data string) []string {
+	var stmts []string
+	var current string
+	for _, part := range strings.Split(data, ";") {
+		if current != "" {
+			current += ";"
+		}
+		current += part
+		stmt := strings.TrimSpace(current)
+		// Look past the comments that precede the statement
+		head := stmt
+		for strings.HasPrefix(head, "--") {
+			_, head, _ = strings.Cut(head, "\n")
+			head = strings.TrimSpace(head)
+		}
+		upper := strings.ToUpper(head)
+		if strings.HasPrefix(upper, "CREATE TRIGGER") && !strings.HasSuffix(upper, "END") {
 			continue
 		}
-		_, err = db.Exec(stmt)
-		if err != nil {
-			return err
+		if stmt != "" {
+			stmts = append(stmts, stmt)
 		}
+		current = ""
 	}
-	return nil
+	if stmt := strings.TrimSpace(current); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
 }
 
 //////////////////////////////////////
@@ -287,10 +521,10 @@ func Migrate(db *sql.DB) error {
 
 // GetStudyActivity retrieves a study activity by its ID.
 func (s *Service) GetStudyActivity(id int) (*models.StudyActivity, error) {
-	row := s.DB.QueryRow("SELECT id, study_session_id, group_id, created_at FROM study_activities WHERE id = ?", id)
+	row := s.DB.QueryRow("SELECT id, name, type, study_session_id, group_id, created_at FROM study_activities WHERE id = ?", id)
 	var activity models.StudyActivity
 	var nullCreatedAt sql.NullTime
-	err := row.Scan(&activity.ID, &activity.StudySessionID, &activity.GroupID, &nullCreatedAt)
+	err := row.Scan(&activity.ID, &activity.Name, &activity.Type, &activity.StudySessionID, &activity.GroupID, &nullCreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -302,7 +536,8 @@ func (s *Service) GetStudyActivity(id int) (*models.StudyActivity, error) {
 	return &activity, nil
 }
 
-// GetStudyActivitySessions retrieves the study session associated with a given study activity ID.
+// GetStudyActivitySessions retrieves the study session a given study activity was launched from.
+// Use ListStudyActivitySessions to get every session that used the activity.
 func (s *Service) GetStudyActivitySessions(activityID int) (*models.StudySession, error) {
 	var studySessionID int
 	err := s.DB.QueryRow("SELECT study_session_id FROM study_activities WHERE id = ?", activityID).Scan(&studySessionID)
@@ -312,9 +547,51 @@ func (s *Service) GetStudyActivitySessions(activityID int) (*models.StudySession
 	return s.GetStudySessionByID(studySessionID)
 }
 
-// CreateStudyActivity creates a new study activity with the given studySessionID and groupID.
-func (s *Service) CreateStudyActivity(studySessionID, groupID int) (int64, error) {
-	result, err := s.DB.Exec("INSERT INTO study_activities (study_session_id, group_id) VALUES (?, ?)", studySessionID, groupID)
+// ListStudyActivitySessions retrieves one page of the study sessions that used the given study activity,
+// newest first, along with the total number of such sessions.
+func (s *Service) ListStudyActivitySessions(activityID, page, perPage int) ([]models.StudySessionDetail, int, error) {
+	var total int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM study_sessions WHERE study_activity_id = ? AND "+s.userCond(""), activityID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ss.id, ss.group_id, COALESCE(g.name, ''), ss.study_activity_id, sa.name, ss.created_at,
+	                 (SELECT COUNT(*) FROM word_review_items wr WHERE wr.study_session_id = ss.id)
+	          FROM study_sessions ss
+	          JOIN study_activities sa ON ss.study_activity_id = sa.id
+	          LEFT JOIN groups g ON ss.group_id = g.id
+	          WHERE ss.study_activity_id = ? AND ` + s.userCond("ss") + `
+	          ORDER BY ss.created_at DESC, ss.id DESC
+	          LIMIT ? OFFSET ?`
+	rows, err := s.DB.Query(query, activityID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	sessions := make([]models.StudySessionDetail, 0)
+	for rows.Next() {
+		var session models.StudySessionDetail
+		if err := rows.Scan(&session.ID, &session.GroupID, &session.GroupName, &session.StudyActivityID,
+			&session.ActivityName, &session.CreatedAt, &session.ReviewItemsCount); err != nil {
+			return nil, 0, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, total, rows.Err()
+}
+
+// StudyActivityOther is the type of the study activities that are none of the other types.
+const StudyActivityOther = "other"
+
+// StudyActivityTypes lists the types a study activity can have.
+var StudyActivityTypes = []string{"flashcards", "typing", "quiz", "matching", "listening", StudyActivityOther}
+
+// CreateStudyActivity creates a new study activity with the given name, type (one of
+// StudyActivityTypes), studySessionID and groupID.
+func (s *Service) CreateStudyActivity(name, activityType string, studySessionID, groupID int) (int64, error) {
+	result, err := s.DB.Exec("INSERT INTO study_activities (name, type, study_session_id, group_id) VALUES (?, ?, ?, ?)",
+		name, activityType, studySessionID, groupID)
 	if err != nil {
 		return 0, err
 	}
@@ -323,18 +600,43 @@ func (s *Service) CreateStudyActivity(studySessionID, groupID int) (int64, error
 
 // GetWordByID retrieves a word by its ID.
 func (s *Service) GetWordByID(id int) (*models.Word, error) {
-	row := s.DB.QueryRow("SELECT id, japanese, romaji, english, parts FROM words WHERE id = ?", id)
+	row := s.DB.QueryRow("SELECT "+wordColumns+" FROM words w WHERE w.id = ? AND w.deleted_at IS NULL", id)
 	var word models.Word
-	err := row.Scan(&word.ID, &word.Japanese, &word.Romaji, &word.English, &word.Parts)
+	err := scanWord(row, &word)
 	if err != nil {
 		return nil, err
 	}
 	return &word, nil
 }
 
-// ListGroups retrieves all groups.
-func (s *Service) ListGroups() ([]models.Group, error) {
-	rows, err := s.DB.Query("SELECT id, name FROM groups")
+// GetWordsByIDs retrieves the words with the given IDs in a single query.
+// IDs that do not match a word are left out of the result.
+func (s *Service) GetWordsByIDs(ids []int) ([]models.Word, error) {
+	words := make([]models.Word, 0, len(ids))
+	if len(ids) == 0 {
+		return words, nil
+	}
+
+	placeholders, args := inPlaceholders(ids)
+	rows, err := s.DB.Query("SELECT "+wordColumns+" FROM words w WHERE w.id IN ("+placeholders+") AND w.deleted_at IS NULL ORDER BY w.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var word models.Word
+		if err := scanWord(rows, &word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// ListGroups retrieves all groups, leaving out the archived ones unless includeArchived.
+func (s *Service) ListGroups(includeArchived bool) ([]models.Group, error) {
+	rows, err := s.DB.Query("SELECT "+groupColumns+" FROM groups g WHERE ? OR NOT g.archived", includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -342,7 +644,7 @@ func (s *Service) ListGroups() ([]models.Group, error) {
 	var groups []models.Group
 	for rows.Next() {
 		var grp models.Group
-		if err := rows.Scan(&grp.ID, &grp.Name); err != nil {
+		if err := scanGroup(rows, &grp); err != nil {
 			return nil, err
 		}
 		groups = append(groups, grp)
@@ -350,22 +652,54 @@ func (s *Service) ListGroups() ([]models.Group, error) {
 	return groups, nil
 }
 
+// CountGroups returns the total number of groups, leaving out the archived ones unless includeArchived.
+func (s *Service) CountGroups(includeArchived bool) (int, error) {
+	var count int
+	err := s.DB.QueryRow("SELECT COUNT(*) FROM groups WHERE ? OR NOT archived", includeArchived).Scan(&count)
+	return count, err
+}
+
 // GetGroupByID retrieves a group by its ID.
 func (s *Service) GetGroupByID(id int) (*models.Group, error) {
-	row := s.DB.QueryRow("SELECT id, name FROM groups WHERE id = ?", id)
+	row := s.DB.QueryRow("SELECT "+groupColumns+" FROM groups g WHERE g.id = ?", id)
 	var grp models.Group
-	if err := row.Scan(&grp.ID, &grp.Name); err != nil {
+	if err := scanGroup(row, &grp); err != nil {
 		return nil, err
 	}
 	return &grp, nil
 }
 
+// GetWordGroups retrieves the groups a word belongs to via the join table word_groups, ordered by
+// name. It returns an empty list for ungrouped words and sql.ErrNoRows if the word does not exist.
+func (s *Service) GetWordGroups(wordID int) ([]models.Group, error) {
+	if _, err := s.GetWordByID(wordID); err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(`SELECT `+groupColumns+` FROM groups g
+	                         JOIN word_groups wg ON wg.group_id = g.id
+	                         WHERE wg.word_id = ?
+	                         ORDER BY g.name, g.id`, wordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	groups := make([]models.Group, 0)
+	for rows.Next() {
+		var grp models.Group
+		if err := scanGroup(rows, &grp); err != nil {
+			return nil, err
+		}
+		groups = append(groups, grp)
+	}
+	return groups, rows.Err()
+}
+
 // GetGroupWords retrieves all words associated with a given group ID via the join table word_groups.
 func (s *Service) GetGroupWords(groupID int) ([]models.Word, error) {
-	query := `SELECT w.id, w.japanese, w.romaji, w.english, w.parts 
-	          FROM words w 
-	          JOIN word_groups wg ON w.id = wg.word_id 
-	          WHERE wg.group_id = ?`
+	query := `SELECT ` + wordColumns + `
+	          FROM words w
+	          JOIN word_groups wg ON w.id = wg.word_id
+	          WHERE wg.group_id = ? AND w.deleted_at IS NULL`
 	rows, err := s.DB.Query(query, groupID)
 	if err != nil {
 		return nil, err
@@ -374,7 +708,7 @@ func (s *Service) GetGroupWords(groupID int) ([]models.Word, error) {
 	var words []models.Word
 	for rows.Next() {
 		var word models.Word
-		if err := rows.Scan(&word.ID, &word.Japanese, &word.Romaji, &word.English, &word.Parts); err != nil {
+		if err := scanWord(rows, &word); err != nil {
 			return nil, err
 		}
 		words = append(words, word)
@@ -382,9 +716,43 @@ func (s *Service) GetGroupWords(groupID int) ([]models.Word, error) {
 	return words, nil
 }
 
+// GetGroupWordsWithStats retrieves the words of a group like GetGroupWords, annotating each word
+// with its correct and wrong review counts and its accuracy, overall and by direction.
+func (s *Service) GetGroupWordsWithStats(groupID int) ([]models.WordWithStats, error) {
+	query := `SELECT ` + wordColumns + `,
+	                 COALESCE(SUM(CASE WHEN wr.correct THEN 1 ELSE 0 END), 0),
+	                 COALESCE(SUM(CASE WHEN wr.id IS NOT NULL AND NOT wr.correct AND NOT wr.skipped THEN 1 ELSE 0 END), 0)` +
+		directionColumns() + `
+	          FROM words w
+	          JOIN word_groups wg ON w.id = wg.word_id
+	          LEFT JOIN word_review_items wr ON wr.word_id = w.id AND ` + s.userCond("wr") + `
+	          WHERE wg.group_id = ? AND w.deleted_at IS NULL
+	          GROUP BY w.id`
+	rows, err := s.DB.Query(query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	words := make([]models.WordWithStats, 0)
+	for rows.Next() {
+		var word models.WordWithStats
+		directions := newDirectionCounts()
+		if err := scanWord(rows, &word.Word, append([]interface{}{&word.CorrectCount, &word.WrongCount}, directions.dest()...)...); err != nil {
+			return nil, err
+		}
+		if total := word.CorrectCount + word.WrongCount; total > 0 {
+			accuracy := float64(word.CorrectCount) / float64(total)
+			word.Accuracy = &accuracy
+		}
+		word.ByDirection = directions.stats()
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
 // GetGroupStudySessions retrieves all study sessions for a given group.
 func (s *Service) GetGroupStudySessions(groupID int) ([]models.StudySession, error) {
-	rows, err := s.DB.Query("SELECT id, group_id, created_at, study_activity_id FROM study_sessions WHERE group_id = ?", groupID)
+	rows, err := s.DB.Query("SELECT id, group_id, created_at, study_activity_id, ended_at, notes FROM study_sessions WHERE group_id = ? AND "+s.userCond(""), groupID)
 	if err != nil {
 		return nil, err
 	}
@@ -392,7 +760,7 @@ func (s *Service) GetGroupStudySessions(groupID int) ([]models.StudySession, err
 	var sessions []models.StudySession
 	for rows.Next() {
 		var session models.StudySession
-		if err := rows.Scan(&session.ID, &session.GroupID, &session.CreatedAt, &session.StudyActivityID); err != nil {
+		if err := rows.Scan(&session.ID, &session.GroupID, &session.CreatedAt, &session.StudyActivityID, &session.EndedAt, &session.Notes); err != nil {
 			return nil, err
 		}
 		sessions = append(sessions, session)
@@ -400,101 +768,341 @@ func (s *Service) GetGroupStudySessions(groupID int) ([]models.StudySession, err
 	return sessions, nil
 }
 
-// ListStudySessions retrieves all study sessions.
-func (s *Service) ListStudySessions() ([]models.StudySession, error) {
-	rows, err := s.DB.Query("SELECT id, group_id, created_at, study_activity_id FROM study_sessions")
+// studySessionListColumns are the columns of the study sessions listing, scanned by
+// scanStudySessionListItem, selected from study_sessions ss joined to groups g.
+const studySessionListColumns = "ss.id, ss.group_id, ss.created_at, ss.study_activity_id, ss.ended_at, ss.notes, g.name, ss.abandoned"
+
+// studySessionListFrom joins each study session to its group, whose name is NULL once the group
+// was deleted.
+const studySessionListFrom = " FROM study_sessions ss LEFT JOIN groups g ON g.id = ss.group_id"
+
+// scanStudySessionListItem scans the studySessionListColumns of a row into session, followed by
+// any extra destinations.
+func scanStudySessionListItem(rows *sql.Rows, session *models.StudySessionListItem, extra ...interface{}) error {
+	return rows.Scan(append([]interface{}{&session.ID, &session.GroupID, &session.CreatedAt, &session.StudyActivityID,
+		&session.EndedAt, &session.Notes, &session.GroupName, &session.Abandoned}, extra...)...)
+}
+
+// studySessionConditions returns the join and the conditions keeping the learner's study sessions
+// ss that match filter, and their arguments.
+func (s *Service) studySessionConditions(filter models.StudySessionFilter) (string, []string, []interface{}) {
+	var join string
+	conds := []string{s.userCond("ss")}
+	var args []interface{}
+	if filter.ActivityType != "" {
+		join = " JOIN study_activities sa ON sa.id = ss.study_activity_id"
+		conds = append(conds, "sa.type = ?")
+		args = append(args, filter.ActivityType)
+	}
+	if !filter.IncludeAbandoned {
+		conds = append(conds, "NOT ss.abandoned")
+	}
+	return join, conds, args
+}
+
+// ListStudySessions retrieves the study sessions matching filter with the names of their groups.
+func (s *Service) ListStudySessions(filter models.StudySessionFilter) ([]models.StudySessionListItem, error) {
+	join, conds, args := s.studySessionConditions(filter)
+	where := " WHERE " + strings.Join(conds, " AND ")
+	rows, err := s.DB.Query("SELECT "+studySessionListColumns+studySessionListFrom+join+where+" ORDER BY ss.id", args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	sessions := make([]models.StudySession, 0)
+	sessions := make([]models.StudySessionListItem, 0)
 	for rows.Next() {
-		var session models.StudySession
-		if err := rows.Scan(&session.ID, &session.GroupID, &session.CreatedAt, &session.StudyActivityID); err != nil {
+		var session models.StudySessionListItem
+		if err := scanStudySessionListItem(rows, &session); err != nil {
 			return nil, err
 		}
 		sessions = append(sessions, session)
 	}
-	return sessions, nil
+	return sessions, rows.Err()
 }
 
-// GetStudySessionWords retrieves words associated with a given study session via the word_review_items table.
-func (s *Service) GetStudySessionWords(sessionID int) ([]models.Word, error) {
-	query := `SELECT w.id, w.japanese, w.romaji, w.english, w.parts 
-	          FROM words w 
-	          JOIN word_review_items wr ON w.id = wr.word_id 
-	          WHERE wr.study_session_id = ?`
+// studySessionsKeyset is the order of the study sessions listing, by ID, for cursor pagination.
+var studySessionsKeyset = keyset{listing: "study_sessions", keys: []keysetKey{{expr: "ss.id", integer: true}}}
+
+// ListStudySessionsByCursor retrieves up to limit study sessions matching filter in ID order,
+// starting after the page whose next cursor is cursor, or at the first session when cursor is "".
+// It returns the cursor of the next page, nil on the last page, or ErrInvalidCursor.
+func (s *Service) ListStudySessionsByCursor(filter models.StudySessionFilter, cursor string, limit int) ([]models.StudySessionListItem, *string, error) {
+	now := time.Now()
+	start, err := studySessionsKeyset.decode(cursor, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	join, conds, args := s.studySessionConditions(filter)
+	if after, afterArgs := studySessionsKeyset.after(start); after != "" {
+		conds = append(conds, after)
+		args = append(args, afterArgs...)
+	}
+	where := " WHERE " + strings.Join(conds, " AND ")
+	rows, err := s.DB.Query("SELECT "+studySessionListColumns+studySessionsKeyset.columns()+
+		studySessionListFrom+join+where+studySessionsKeyset.orderBy()+" LIMIT ?", append(args, limit+1)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	// Fetch one extra row to learn whether another page follows
+	sessions := make([]models.StudySessionListItem, 0)
+	var last []string
+	for rows.Next() {
+		var session models.StudySessionListItem
+		values, dest := studySessionsKeyset.scanValues()
+		if err := scanStudySessionListItem(rows, &session, dest...); err != nil {
+			return nil, nil, err
+		}
+		if len(sessions) == limit {
+			return sessions, studySessionsKeyset.encode(last, now), nil
+		}
+		sessions = append(sessions, session)
+		last = values
+	}
+	return sessions, nil, rows.Err()
+}
+
+// CountStudySessions returns the number of study sessions matching filter.
+func (s *Service) CountStudySessions(filter models.StudySessionFilter) (int, error) {
+	join, conds, args := s.studySessionConditions(filter)
+	where := " WHERE " + strings.Join(conds, " AND ")
+	var count int
+	err := s.DB.QueryRow("SELECT COUNT(*) FROM study_sessions ss"+join+where, args...).Scan(&count)
+	return count, err
+}
+
+// GetStudySessionWords retrieves the words reviewed in a given study session via the word_review_items
+// table, each once and in the order they were first reviewed, with the number of times it was reviewed.
+func (s *Service) GetStudySessionWords(sessionID int) ([]models.SessionWord, error) {
+	query := `SELECT ` + wordColumns + `, COUNT(wr.id)
+	          FROM words w
+	          JOIN word_review_items wr ON w.id = wr.word_id
+	          WHERE wr.study_session_id = ? AND w.deleted_at IS NULL AND ` + s.userCond("wr") + `
+	          GROUP BY w.id
+	          ORDER BY MIN(wr.id)`
 	rows, err := s.DB.Query(query, sessionID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	words := make([]models.Word, 0) // ensure empty slice
+	words := make([]models.SessionWord, 0) // ensure empty slice
 	for rows.Next() {
-		var word models.Word
-		if err := rows.Scan(&word.ID, &word.Japanese, &word.Romaji, &word.English, &word.Parts); err != nil {
+		var word models.SessionWord
+		if err := scanWord(rows, &word.Word, &word.TimesReviewed); err != nil {
 			return nil, err
 		}
 		words = append(words, word)
 	}
-	return words, nil
+	return words, rows.Err()
 }
 
-// ResetHistory clears all records from word_review_items.
+// ResetHistory clears the learner's reviews along with the schedules, achievements and mastery
+// milestones derived from them, in one transaction. Without a learner every review and schedule is
+// cleared.
 func (s *Service) ResetHistory() error {
-	_, err := s.DB.Exec("DELETE FROM word_review_items")
-	return err
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		"DELETE FROM word_review_items WHERE " + s.userCond(""),
+		"DELETE FROM word_srs",
+		"DELETE FROM achievements WHERE " + s.scheduleCond(""),
+		"DELETE FROM mastery_milestones WHERE " + s.scheduleCond(""),
+	}
+	if s.userID != 0 {
+		queries[1] += " WHERE " + s.scheduleCond("")
+	}
+	for _, q := range queries {
+		if _, err := tx.Exec(q); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.recordEvent(EventHistoryReset, map[string]interface{}{})
+	return nil
 }
 
-// FullReset deletes all records from the main tables in proper order.
+// FullReset deletes all records from the main tables in proper order, in one transaction. The
+// event timeline is cleared too and restarts with the full_reset event. Share links are deleted so
+// their tokens cannot expose the groups that later reuse the IDs, and every refresh token is
+// revoked. The seed data is added again except in production.
 func (s *Service) FullReset() error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	queries := []string{
+		"DELETE FROM share_links",
+		"UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE revoked_at IS NULL",
 		"DELETE FROM word_review_items",
+		"DELETE FROM word_srs",
+		"DELETE FROM study_session_queue",
 		"DELETE FROM study_activities",
 		"DELETE FROM study_sessions",
 		"DELETE FROM word_groups",
+		"DELETE FROM word_tags",
+		"DELETE FROM tags",
+		"DELETE FROM word_meanings",
+		"DELETE FROM word_relations",
+		"DELETE FROM word_examples",
 		"DELETE FROM words",
 		"DELETE FROM groups",
+		"DELETE FROM events",
+		"DELETE FROM achievements",
+		"DELETE FROM mastery_milestones",
 	}
 	for _, q := range queries {
-		if _, err := s.DB.Exec(q); err != nil {
+		if _, err := tx.Exec(q); err != nil {
 			return err
 		}
 	}
 
-	// Reset auto-increment counters
-	if _, err := s.DB.Exec("DELETE FROM sqlite_sequence"); err != nil {
+	// Reset auto-increment counters, except those of the accounts and share links kept or
+	// revoked above, so a new share link never gets the ID of an old one
+	if _, err := tx.Exec("DELETE FROM sqlite_sequence WHERE name NOT IN ('users', 'refresh_tokens', 'share_links')"); err != nil {
 		return err
 	}
 
 	// Re-seed the database with default data
-	return SeedData(s.DB)
+	if s.env != config.Production {
+		entries, source, err := s.defaultSeed()
+		if err != nil {
+			return err
+		}
+		if _, err := seedEntriesTx(tx, entries, source); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.recordEvent(EventFullReset, map[string]interface{}{})
+	return nil
 }
 
-// ReviewWord records the review result for a given word in a study session.
-func (s *Service) ReviewWord(studySessionID int, wordID int, correct bool) error {
-	_, err := s.DB.Exec("INSERT INTO word_review_items (word_id, study_session_id, correct) VALUES (?, ?, ?)", wordID, studySessionID, correct)
-	return err
+// ReviewWord records the review result for a given word in a study session and reschedules the word.
+// Each word is reviewed at most once per attempt: submitting the same attempt again updates the
+// existing review instead of adding another one, also when both submissions arrive at once, since
+// the transaction starts by writing. Callers pass attempt 2 or higher for genuine re-asks.
+// A skipped review is recorded as not correct but does not count as wrong (see updateSchedule).
+// answer is the answer given and direction one of ReviewDirections, each empty when the client did
+// not send it.
+func (s *Service) ReviewWord(studySessionID int, wordID int, correct, skipped bool, attempt int, answer, direction string) (*models.ReviewOutcome, error) {
+	if skipped {
+		correct = false
+	}
+	if err := s.checkOwner(studySessionID); err != nil {
+		return nil, err
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Inserting first takes the write lock, so a concurrent submission of the same attempt waits
+	// for this one and then updates its review, where reading first would fail one of them
+	result, err := tx.Exec(`INSERT INTO word_review_items (word_id, study_session_id, correct, skipped, attempt, answer, direction, user_id)
+	                        VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?)
+	                        ON CONFLICT (study_session_id, word_id, attempt) DO NOTHING`,
+		wordID, studySessionID, correct, skipped, attempt, answer, direction, s.owner())
+	if err != nil {
+		return nil, err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	outcome := &models.ReviewOutcome{Created: inserted > 0}
+	if !outcome.Created {
+		if _, err := tx.Exec(`UPDATE word_review_items SET correct = ?, skipped = ?, answer = NULLIF(?, ''), direction = NULLIF(?, ''), created_at = CURRENT_TIMESTAMP
+		                      WHERE study_session_id = ? AND word_id = ? AND attempt = ?`,
+			correct, skipped, answer, direction, studySessionID, wordID, attempt); err != nil {
+			return nil, err
+		}
+	}
+
+	if outcome.Schedule, err = updateSchedule(tx, wordID, s.userID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if correct {
+		s.recordMasteryMilestone(wordID)
+	}
+	if outcome.Created {
+		s.checkReviewAchievements(time.Now())
+	}
+	s.publishReview(studySessionID, wordID, correct, skipped, attempt)
+	return outcome, nil
 }
 
-// CreateGroup inserts a new group into the database and returns its ID.
-func (s *Service) CreateGroup(name string) (int, error) {
-	result, err := s.DB.Exec("INSERT INTO groups (name) VALUES (?)", name)
+// CreateGroup inserts a new group into the database and returns its ID. createdBy is the username
+// of its author, or empty when unknown. It returns ErrDuplicateGroupName if another group has the
+// name.
+func (s *Service) CreateGroup(name, createdBy string) (int, error) {
+	result, err := s.DB.Exec(`INSERT INTO groups (name, created_at, updated_at, created_by)
+	                          VALUES (?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, NULLIF(?, ''))`, name, createdBy)
 	if err != nil {
-		return 0, err
+		return 0, groupNameError(err)
 	}
 	id, err := result.LastInsertId()
 	if err != nil {
 		return 0, err
 	}
+	s.recordEvent(EventGroupCreated, map[string]interface{}{"group_id": id, "name": name})
 	return int(id), nil
 }
 
-// UpdateGroup updates the name of an existing group identified by id.
-func (s *Service) UpdateGroup(id int, name string) error {
-	_, err := s.DB.Exec("UPDATE groups SET name = ? WHERE id = ?", name, id)
-	return err
+// UpdateGroup changes the name and default activity type of the group identified by id. It returns
+// sql.ErrNoRows if the group does not exist and ErrDuplicateGroupName if another group has the name.
+func (s *Service) UpdateGroup(id int, update models.GroupUpdate) error {
+	result, err := s.DB.Exec(`UPDATE groups SET name = COALESCE(NULLIF(?, ''), name),
+	                          default_activity_type = CASE WHEN ? IS NULL THEN default_activity_type ELSE NULLIF(?, '') END,
+	                          updated_at = CURRENT_TIMESTAMP
+	                          WHERE id = ?`, update.Name, update.DefaultActivityType, update.DefaultActivityType, id)
+	if err != nil {
+		return groupNameError(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetGroupArchived archives or unarchives the group identified by id. Archived groups keep their
+// words and study history but are left out of the group list, the recommendations and the stale
+// groups by default. Archiving leaves the group's open study sessions open: it returns how many
+// there are, or sql.ErrNoRows if the group does not exist.
+func (s *Service) SetGroupArchived(id int, archived bool) (int, error) {
+	result, err := s.DB.Exec("UPDATE groups SET archived = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", archived, id)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, sql.ErrNoRows
+	}
+	var open int
+	err = s.DB.QueryRow("SELECT COUNT(*) FROM study_sessions WHERE group_id = ? AND ended_at IS NULL AND "+s.userCond(""), id).Scan(&open)
+	return open, err
 }
 
 // DeleteGroup deletes the group with the given id from the database.
@@ -503,22 +1111,279 @@ func (s *Service) DeleteGroup(id int) error {
 	return err
 }
 
+// ImportGroup creates a group named name containing the given words in a single transaction.
+// Words whose japanese text already exists are reused instead of being inserted again,
+// and each word is linked to the group once even if it is listed repeatedly. createdBy is the
+// username of the group's author, or empty when unknown. Words without a source get source, as in
+// ImportWords. With dryRun the transaction is rolled back, as in ImportWords. It returns
+// ErrDuplicateGroupName if another group has the name.
+func (s *Service) ImportGroup(name, createdBy string, words []models.ImportWord, source string, dryRun bool) (*models.GroupImportResult, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO groups (name, created_at, updated_at, created_by)
+	                        VALUES (?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, NULLIF(?, ''))`, name, createdBy)
+	if err != nil {
+		return nil, groupNameError(err)
+	}
+	groupID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.GroupImportResult{GroupID: int(groupID), GroupCreated: true, DryRun: dryRun}
+	summary.WordsCreated, summary.WordsReused, summary.WordsLinked, err = importWords(tx, groupID, words, source)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return summary, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.recordEvent(EventGroupCreated, map[string]interface{}{"group_id": groupID, "name": name})
+	s.recordImportEvent(summary.GroupID, summary.WordsCreated)
+	return summary, nil
+}
+
+// ImportWordsIntoGroup adds words to the group groupID or, when it is 0, to the group named name,
+// created if there is none, in a single transaction. Words that already exist are linked instead
+// of created (see importWords). createdBy is the username of the author of a created group, or
+// empty when unknown. It returns sql.ErrNoRows if the group groupID does not exist. With dryRun
+// the transaction is rolled back, as in ImportWords.
+func (s *Service) ImportWordsIntoGroup(groupID int, name, createdBy string, words []models.ImportWord, source string, dryRun bool) (*models.GroupImportResult, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	summary := &models.GroupImportResult{DryRun: dryRun}
+	if groupID != 0 {
+		err = tx.QueryRow("SELECT id FROM groups WHERE id = ?", groupID).Scan(&summary.GroupID)
+	} else if err = tx.QueryRow("SELECT id FROM groups WHERE name = ? COLLATE NOCASE", name).Scan(&summary.GroupID); err == sql.ErrNoRows {
+		var result sql.Result
+		result, err = tx.Exec(`INSERT INTO groups (name, created_at, updated_at, created_by)
+		                       VALUES (?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, NULLIF(?, ''))`, name, createdBy)
+		if err == nil {
+			var id int64
+			id, err = result.LastInsertId()
+			summary.GroupID, summary.GroupCreated = int(id), true
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	summary.WordsCreated, summary.WordsReused, summary.WordsLinked, err = importWords(tx, int64(summary.GroupID), words, source)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return summary, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if summary.GroupCreated {
+		s.recordEvent(EventGroupCreated, map[string]interface{}{"group_id": summary.GroupID, "name": name})
+	}
+	s.recordImportEvent(summary.GroupID, summary.WordsCreated)
+	return summary, nil
+}
+
+// ImportWords adds the given words in a single transaction, reusing words whose japanese text
+// already exists, and links them to the group groupID unless it is 0. The group must exist
+// (sql.ErrNoRows otherwise). The words created without a source of their own are recorded as
+// coming from source. With dryRun the transaction is rolled back, so the result reports what the
+// import would do without changing anything.
+func (s *Service) ImportWords(groupID int, words []models.ImportWord, source string, dryRun bool) (*models.WordImportResult, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	summary := &models.WordImportResult{DryRun: dryRun}
+	if groupID != 0 {
+		if err := tx.QueryRow("SELECT id FROM groups WHERE id = ?", groupID).Scan(&groupID); err != nil {
+			return nil, err
+		}
+		summary.GroupID = &groupID
+	}
+	summary.WordsCreated, summary.WordsReused, summary.WordsLinked, err = importWords(tx, int64(groupID), words, source)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return summary, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.recordImportEvent(groupID, summary.WordsCreated)
+	return summary, nil
+}
+
+// importWords inserts the words that do not exist yet and links every word to the group groupID
+// unless it is 0, skipping links that already exist. A word with both a source and a source ID
+// exists if a word has the same ones, so importing a source again is idempotent, and otherwise if a
+// word has the same japanese text once normalized (see NormalizeJapanese), so width variants are
+// not added twice. Words created without a source get source. Existing words keep their own. It
+// returns how many words were created, reused and newly linked.
+func importWords(tx *sql.Tx, groupID int64, words []models.ImportWord, source string) (created, reused, linked int, err error) {
+	keys := make([]string, len(words))
+	for i, w := range words {
+		keys[i] = NormalizeJapanese(w.Japanese)
+	}
+	byJapanese, err := wordsByNormalizedJapanese(tx, keys)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	for _, w := range words {
+		if w.Source == "" {
+			w.Source = source
+		}
+		var wordID int64
+		err := sql.ErrNoRows
+		if w.SourceID != "" {
+			err = tx.QueryRow("SELECT id FROM words WHERE source = ? AND source_id = ? AND deleted_at IS NULL ORDER BY id LIMIT 1",
+				w.Source, w.SourceID).Scan(&wordID)
+		}
+		key := NormalizeJapanese(w.Japanese)
+		if err == sql.ErrNoRows {
+			if id, ok := byJapanese[key]; ok {
+				wordID, err = id, nil
+			}
+		}
+		switch {
+		case err == sql.ErrNoRows:
+			if wordID, err = insertWord(tx, w); err != nil {
+				return 0, 0, 0, err
+			}
+			byJapanese[key] = wordID
+			created++
+		case err != nil:
+			return 0, 0, 0, err
+		default:
+			reused++
+		}
+
+		if groupID == 0 {
+			continue
+		}
+		result, err := tx.Exec(`INSERT INTO word_groups (word_id, group_id)
+		                        SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM word_groups WHERE word_id = ? AND group_id = ?)`,
+			wordID, groupID, wordID, groupID)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		linked += int(n)
+	}
+	return created, reused, linked, nil
+}
+
+// maxLookupKeys is the number of keys wordsByNormalizedJapanese looks up per query, well below
+// the SQLite limit on query arguments.
+const maxLookupKeys = 500
+
+// wordsByNormalizedJapanese maps those of keys that are the normalized japanese text of words that
+// are not deleted to their IDs, the lowest when several share it.
+func wordsByNormalizedJapanese(tx *sql.Tx, keys []string) (map[string]int64, error) {
+	byJapanese := make(map[string]int64)
+	for start := 0; start < len(keys); start += maxLookupKeys {
+		chunk := keys[start:min(start+maxLookupKeys, len(keys))]
+		args := make([]interface{}, len(chunk))
+		for i, key := range chunk {
+			args[i] = key
+		}
+		rows, err := tx.Query(`SELECT id, japanese_normalized FROM words
+		                       WHERE deleted_at IS NULL AND japanese_normalized IN (?`+strings.Repeat(", ?", len(chunk)-1)+`)
+		                       ORDER BY id`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int64
+			var key string
+			if err := rows.Scan(&id, &key); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if _, ok := byJapanese[key]; !ok {
+				byJapanese[key] = id
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return byJapanese, nil
+}
+
 // New service functions for managing Words and Study Sessions
 
-func (s *Service) CreateWord(japanese, romaji, english, parts string) (int, error) {
-	result, err := s.DB.Exec("INSERT INTO words (japanese, romaji, english, parts) VALUES (?, ?, ?, ?)", japanese, romaji, english, parts)
+// SourceManual is the source of the words created one at a time rather than imported.
+const SourceManual = "manual"
+
+// CreateWord inserts a word and returns its ID. A word without a source is recorded as SourceManual.
+func (s *Service) CreateWord(w models.ImportWord) (int, error) {
+	if w.Source == "" {
+		w.Source = SourceManual
+	}
+	tx, err := s.DB.Begin()
 	if err != nil {
 		return 0, err
 	}
-	id, err := result.LastInsertId()
+	defer tx.Rollback()
+
+	id, err := insertWord(tx, w)
 	if err != nil {
 		return 0, err
 	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	s.recordEvent(EventWordCreated, map[string]interface{}{"word_id": id, "japanese": w.Japanese})
 	return int(id), nil
 }
 
-func (s *Service) UpdateWord(id int, english string) error {
-	result, err := s.DB.Exec("UPDATE words SET english = ? WHERE id = ?", english, id)
+// UpdateWord changes the meanings, JLPT level, mnemonic, reading and parts of a word. A nil Meanings keeps the other meanings
+// and only replaces the primary one with English.
+func (s *Service) UpdateWord(id int, update models.WordUpdate) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	meanings := update.Meanings
+	if meanings == nil {
+		current, err := s.getWordMeanings(tx, id)
+		if err != nil {
+			return err
+		}
+		if len(current) > 0 {
+			meanings = current[1:]
+		}
+	}
+	english, meanings := wordMeanings(update.English, meanings)
+	mnemonic := trimmedText(update.Mnemonic)
+	reading := trimmedText(update.Reading)
+	result, err := tx.Exec(`UPDATE words SET english = ?, jlpt_level = COALESCE(?, jlpt_level),
+	                        mnemonic = CASE WHEN ? IS NULL THEN mnemonic ELSE NULLIF(?, '') END,
+	                        reading = CASE WHEN ? IS NULL THEN reading ELSE NULLIF(?, '') END,
+	                        parts = CASE WHEN ? IS NULL THEN parts ELSE NULLIF(?, '') END,
+	                        updated_at = CURRENT_TIMESTAMP
+	                        WHERE id = ? AND deleted_at IS NULL`, english, update.JLPTLevel, mnemonic, mnemonic, reading, reading, update.Parts, update.Parts, id)
 	if err != nil {
 		return err
 	}
@@ -529,11 +1394,23 @@ func (s *Service) UpdateWord(id int, english string) error {
 	if count == 0 {
 		return sql.ErrNoRows
 	}
-	return nil
+	if err := setWordMeanings(tx, int64(id), meanings); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
+// DeleteWord soft-deletes a word: it disappears from every listing but stays in the
+// updated_since delta so that syncing clients learn about the deletion. Its relations to other
+// words and its media files are removed.
 func (s *Service) DeleteWord(id int) error {
-	result, err := s.DB.Exec("DELETE FROM words WHERE id = ?", id)
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE words SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL", id)
 	if err != nil {
 		return err
 	}
@@ -544,11 +1421,27 @@ func (s *Service) DeleteWord(id int) error {
 	if count == 0 {
 		return sql.ErrNoRows
 	}
+	if err := deleteWordRelations(tx, []int{id}); err != nil {
+		return err
+	}
+	files, err := clearWordMedia(tx, []int{id})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.releaseMedia(files...)
 	return nil
 }
 
-func (s *Service) UpdateStudySession(sessionID int, studyActivityID int) error {
-	result, err := s.DB.Exec("UPDATE study_sessions SET study_activity_id = ? WHERE id = ?", studyActivityID, sessionID)
+func (s *Service) UpdateStudySession(sessionID int, update models.StudySessionUpdate) error {
+	if err := s.checkOwner(sessionID); err != nil {
+		return err
+	}
+	result, err := s.DB.Exec(`UPDATE study_sessions SET study_activity_id = COALESCE(NULLIF(?, 0), study_activity_id),
+	                          notes = CASE WHEN ? IS NULL THEN notes ELSE NULLIF(?, '') END
+	                          WHERE id = ?`, update.StudyActivityID, trimmedText(update.Notes), trimmedText(update.Notes), sessionID)
 	if err != nil {
 		return err
 	}
@@ -563,6 +1456,12 @@ func (s *Service) UpdateStudySession(sessionID int, studyActivityID int) error {
 }
 
 func (s *Service) DeleteStudySession(sessionID int) error {
+	if err := s.checkOwner(sessionID); err != nil {
+		return err
+	}
+	if _, err := s.DB.Exec("DELETE FROM study_session_queue WHERE study_session_id = ?", sessionID); err != nil {
+		return err
+	}
 	result, err := s.DB.Exec("DELETE FROM study_sessions WHERE id = ?", sessionID)
 	if err != nil {
 		return err
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+var (
+	// ErrNoDefaultActivity is returned when a session is started without a study activity for a
+	// group that has no default activity type, or when no study activity has that type.
+	ErrNoDefaultActivity = errors.New("group has no default study activity")
+)
+
+// CloseStaleStudySessions ends the open study sessions created before the given time. A closed
+// session ends at its last review, or at its creation time if nothing was reviewed in it.
+// It returns the number of sessions closed.
+func (s *Service) CloseStaleStudySessions(before time.Time) (int, error) {
+	result, err := s.DB.Exec(`UPDATE study_sessions
+	                          SET ended_at = COALESCE((SELECT MAX(wr.created_at) FROM word_review_items wr WHERE wr.study_session_id = study_sessions.id), created_at)
+	                          WHERE ended_at IS NULL AND created_at < ?`, before.UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return 0, err
+	}
+	closed, err := result.RowsAffected()
+	return int(closed), err
+}
+
+// GetCurrentStudySession returns the most recent open study session created within window before
+// now, or sql.ErrNoRows if there is none. Open sessions older than the window are closed first,
+// so sessions abandoned mid-way do not stay open forever.
+func (s *Service) GetCurrentStudySession(now time.Time, window time.Duration) (*models.CurrentStudySession, error) {
+	since := now.Add(-window)
+	if _, err := s.CloseStaleStudySessions(since); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ss.id, ss.group_id, ss.created_at, ss.study_activity_id, ss.ended_at, ss.notes,
+	                 (SELECT COUNT(*) FROM word_groups wg
+	                  JOIN words w ON w.id = wg.word_id AND w.deleted_at IS NULL
+	                  WHERE wg.group_id = ss.group_id
+	                    AND NOT EXISTS (SELECT 1 FROM word_review_items wr WHERE wr.study_session_id = ss.id AND wr.word_id = w.id))
+	          FROM study_sessions ss
+	          WHERE ss.ended_at IS NULL AND ss.created_at >= ? AND ` + s.userCond("ss") + `
+	          ORDER BY ss.created_at DESC, ss.id DESC
+	          LIMIT 1`
+	var current models.CurrentStudySession
+	err := s.DB.QueryRow(query, since.UTC().Format(sqliteTimeFormat)).Scan(&current.ID, &current.GroupID, &current.CreatedAt,
+		&current.StudyActivityID, &current.EndedAt, &current.Notes, &current.RemainingWords)
+	if err != nil {
+		return nil, err
+	}
+	return &current, nil
+}
+
+// EndStudySession marks a study session as ended. Ending a session that already ended keeps its
+// original end time. Non-nil notes replace the session's notes, as in UpdateStudySession.
+func (s *Service) EndStudySession(sessionID int, notes *string) (*models.StudySession, error) {
+	before, err := s.GetStudySessionByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	notes = trimmedText(notes)
+	if _, err := s.DB.Exec(`UPDATE study_sessions SET ended_at = COALESCE(ended_at, CURRENT_TIMESTAMP),
+	                        notes = CASE WHEN ? IS NULL THEN notes ELSE NULLIF(?, '') END
+	                        WHERE id = ?`, notes, notes, sessionID); err != nil {
+		return nil, err
+	}
+	session, err := s.GetStudySessionByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if before.EndedAt == nil {
+		var reviews int
+		if err := s.DB.QueryRow("SELECT COUNT(*) FROM word_review_items WHERE study_session_id = ?", sessionID).Scan(&reviews); err != nil {
+			log.Printf("Counting reviews of session %d: %v", sessionID, err)
+		}
+		s.recordEvent(EventSessionCompleted, map[string]interface{}{"study_session_id": sessionID, "group_id": session.GroupID, "reviews": reviews})
+		s.checkPerfectSession(sessionID, reviews)
+	}
+	return session, nil
+}
+
+// GetRetryQueue retrieves the words of a study session whose latest answer was wrong (not skipped), in the order
+// they should be asked again: the word missed longest ago first. A word leaves the queue once it
+// is answered correctly. It returns sql.ErrNoRows if the session does not exist.
+func (s *Service) GetRetryQueue(sessionID int) ([]models.RetryWord, error) {
+	if _, err := s.GetStudySessionByID(sessionID); err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(`SELECT `+wordColumns+`, latest.attempt, latest.created_at
+	                         FROM word_review_items latest
+	                         JOIN words w ON w.id = latest.word_id AND w.deleted_at IS NULL
+	                         WHERE latest.study_session_id = ? AND latest.correct = 0 AND NOT latest.skipped
+	                           AND latest.attempt = (SELECT MAX(wr.attempt) FROM word_review_items wr
+	                                                 WHERE wr.study_session_id = latest.study_session_id AND wr.word_id = latest.word_id)
+	                         ORDER BY latest.created_at, latest.id`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queue := make([]models.RetryWord, 0)
+	for rows.Next() {
+		var item models.RetryWord
+		if err := scanWord(rows, &item.Word, &item.Attempts, &item.LastAnsweredAt); err != nil {
+			return nil, err
+		}
+		item.NextAttempt = item.Attempts + 1
+		queue = append(queue, item)
+	}
+	return queue, rows.Err()
+}
+
+// DuplicateStudySession starts a new study session for the group and study activity of an existing
+// one. With queue, the words reviewed in the original session are queued in the new one in the
+// order they were first reviewed; their results are not copied. It returns the new session's ID and
+// the number of words queued, or sql.ErrNoRows if the original session does not exist.
+func (s *Service) DuplicateStudySession(sessionID int, queue bool) (int64, int, error) {
+	original, err := s.GetStudySessionByID(sessionID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO study_sessions (group_id, study_activity_id, user_id) VALUES (?, ?, ?)", original.GroupID, original.StudyActivityID, s.owner())
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, 0, err
+	}
+	var queued int64
+	if queue {
+		result, err := tx.Exec(`INSERT INTO study_session_queue (study_session_id, word_id, position)
+		                        SELECT ?, wr.word_id, ROW_NUMBER() OVER (ORDER BY MIN(wr.id))
+		                        FROM word_review_items wr
+		                        JOIN words w ON w.id = wr.word_id AND w.deleted_at IS NULL
+		                        WHERE wr.study_session_id = ?
+		                        GROUP BY wr.word_id`, id, sessionID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if queued, err = result.RowsAffected(); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return id, int(queued), nil
+}
+
+// GetStudySessionQueue retrieves the queued words of a study session that have not been reviewed in
+// it yet, in queue order. It returns sql.ErrNoRows if the session does not exist.
+func (s *Service) GetStudySessionQueue(sessionID int) ([]models.Word, error) {
+	if _, err := s.GetStudySessionByID(sessionID); err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(`SELECT `+wordColumns+`
+	                         FROM study_session_queue q
+	                         JOIN words w ON w.id = q.word_id AND w.deleted_at IS NULL
+	                         WHERE q.study_session_id = ?
+	                           AND NOT EXISTS (SELECT 1 FROM word_review_items wr WHERE wr.study_session_id = q.study_session_id AND wr.word_id = q.word_id)
+	                         ORDER BY q.position`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	words := make([]models.Word, 0)
+	for rows.Next() {
+		var word models.Word
+		if err := scanWord(rows, &word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// GetNextWord picks the word to study next in a study session at time now: among the words not
+// reviewed in it yet, the next queued word if the session has a queue, otherwise the word of its
+// group due longest ago, then a new word, then the word due soonest. It returns nil when the session
+// ended or has no word left, and sql.ErrNoRows if the session does not exist.
+func (s *Service) GetNextWord(sessionID int, now time.Time) (*models.NextWord, error) {
+	session, err := s.GetStudySessionByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.EndedAt != nil {
+		return nil, nil
+	}
+	var queued bool
+	if err := s.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM study_session_queue WHERE study_session_id = ?)", sessionID).Scan(&queued); err != nil {
+		return nil, err
+	}
+	candidates, arg := "SELECT DISTINCT word_id, NULL AS position FROM word_groups WHERE group_id = ?", interface{}(session.GroupID)
+	if queued {
+		candidates, arg = "SELECT word_id, position FROM study_session_queue WHERE study_session_id = ?", sessionID
+	}
+
+	query := `SELECT ` + wordColumns + `,
+	                 CASE WHEN c.position IS NOT NULL THEN 'queued'
+	                      WHEN srs.word_id IS NULL THEN 'new'
+	                      WHEN srs.next_review_at <= ? THEN 'due'
+	                      ELSE 'scheduled' END AS reason,
+	                 COUNT(*) OVER ()
+	          FROM (` + candidates + `) c
+	          JOIN words w ON w.id = c.word_id AND w.deleted_at IS NULL
+	          LEFT JOIN word_srs srs ON srs.word_id = w.id AND ` + s.scheduleCond("srs") + `
+	          WHERE NOT EXISTS (SELECT 1 FROM word_review_items wr WHERE wr.study_session_id = ? AND wr.word_id = w.id)
+	          ORDER BY c.position, CASE reason WHEN 'due' THEN 0 WHEN 'new' THEN 1 ELSE 2 END, srs.next_review_at, w.id
+	          LIMIT 1`
+	var next models.NextWord
+	err = scanWord(s.DB.QueryRow(query, now.UTC().Format(sqliteTimeFormat), arg, sessionID), &next.Word, &next.Reason, &next.Remaining)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// GetSessionReport assembles the report of a study session: its group and activity names, its
+// length (see sessionStatsSQL), each word reviewed in it in the order first reviewed, including words
+// deleted since, and summary stats. It returns sql.ErrNoRows if the session does not exist.
+func (s *Service) GetSessionReport(sessionID int) (*models.SessionReport, error) {
+	session, err := s.GetStudySessionByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	report := &models.SessionReport{Session: *session, Words: make([]models.SessionReportWord, 0)}
+
+	var duration sql.NullFloat64
+	if err := s.DB.QueryRow(`SELECT COALESCE(g.name, ''), a.name, ss.duration_seconds
+	                         FROM (`+s.sessionStatsSQL()+`) ss
+	                         LEFT JOIN groups g ON g.id = ?
+	                         LEFT JOIN study_activities a ON a.id = ss.study_activity_id
+	                         WHERE ss.id = ?`, session.GroupID, sessionID).Scan(&report.GroupName, &report.ActivityName, &duration); err != nil {
+		return nil, err
+	}
+	if duration.Valid {
+		seconds := math.Round(duration.Float64)
+		report.DurationSeconds = &seconds
+	}
+
+	rows, err := s.DB.Query(`SELECT `+wordColumns+`, COUNT(*),
+	                                SUM(CASE WHEN wr.correct THEN 1 ELSE 0 END),
+	                                SUM(CASE WHEN wr.skipped THEN 1 ELSE 0 END),
+	                                (SELECT latest.correct FROM word_review_items latest
+	                                 WHERE latest.study_session_id = ? AND latest.word_id = w.id
+	                                 ORDER BY latest.attempt DESC, latest.id DESC LIMIT 1)
+	                         FROM words w
+	                         JOIN word_review_items wr ON wr.word_id = w.id
+	                         WHERE wr.study_session_id = ?
+	                         GROUP BY w.id
+	                         ORDER BY MIN(wr.id)`, sessionID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	summary := &report.Summary
+	for rows.Next() {
+		var word models.SessionReportWord
+		if err := scanWord(rows, &word.Word, &word.Attempts, &word.CorrectCount, &word.SkippedCount, &word.Correct); err != nil {
+			return nil, err
+		}
+		word.WrongCount = word.Attempts - word.CorrectCount - word.SkippedCount
+		summary.WordsReviewed++
+		if word.Correct {
+			summary.WordsCorrect++
+		}
+		summary.TotalReviews += word.Attempts
+		summary.CorrectCount += word.CorrectCount
+		summary.WrongCount += word.WrongCount
+		summary.SkippedCount += word.SkippedCount
+		report.Words = append(report.Words, word)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if answered := summary.CorrectCount + summary.WrongCount; answered > 0 {
+		accuracy := float64(summary.CorrectCount) / float64(answered)
+		summary.Accuracy = &accuracy
+	}
+
+	directions := newDirectionCounts()
+	if err := s.DB.QueryRow(`SELECT COUNT(CASE WHEN wr.direction IS NULL THEN 1 END)`+directionColumns()+`
+	                         FROM word_review_items wr
+	                         WHERE wr.study_session_id = ?`, sessionID).Scan(append([]interface{}{&summary.UnknownDirectionCount}, directions.dest()...)...); err != nil {
+		return nil, err
+	}
+	summary.ByDirection = directions.stats()
+	return report, nil
+}
@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"backend_go/internal/testutil"
+)
+
+func TestGetCurrentStudySession(t *testing.T) {
+	svc := testutil.NewService(t)
+	now := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	window := 12 * time.Hour
+	f := testutil.NewFixture(t, svc.DB).Group("N5").
+		Word("水", "mizu", "water").InGroup("N5").
+		Word("火", "hi", "fire").InGroup("N5").
+		Word("山", "yama", "mountain").InGroup("N5")
+
+	if _, err := svc.GetCurrentStudySession(now, window); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("without sessions: error %v, want sql.ErrNoRows", err)
+	}
+
+	// One second past the window, with a review made later, and further past without reviews
+	f.SessionAt(now.Add(-window-time.Second)).ReviewAt(true, now.Add(-11*time.Hour))
+	stale := f.SessionID()
+	f.SessionAt(now.Add(-20 * time.Hour))
+	empty := f.SessionID()
+	// Exactly at the window, with one of the three words reviewed
+	f.SessionAt(now.Add(-window)).ReviewAt(false, now.Add(-window))
+	boundary := f.SessionID()
+	// Newer but already ended
+	f.SessionAt(now.Add(-time.Hour)).End(now.Add(-30 * time.Minute))
+
+	current, err := svc.GetCurrentStudySession(now, window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.ID != boundary || current.EndedAt != nil || current.RemainingWords != 2 {
+		t.Errorf("current = %+v, want session %d, open with 2 words remaining", current, boundary)
+	}
+
+	// Sessions past the window were closed at their last review, or at their start without one
+	for id, endedAt := range map[int]time.Time{stale: now.Add(-11 * time.Hour), empty: now.Add(-20 * time.Hour)} {
+		session, err := svc.GetStudySessionByID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.EndedAt == nil || !session.EndedAt.Equal(endedAt) {
+			t.Errorf("session %d ended at %v, want %v", id, session.EndedAt, endedAt)
+		}
+	}
+
+	// A newer open session takes over, and the boundary session closes a second later
+	f.SessionAt(now.Add(-time.Minute))
+	if current, err = svc.GetCurrentStudySession(now, window); err != nil {
+		t.Fatal(err)
+	}
+	if current.ID != f.SessionID() || current.RemainingWords != 3 {
+		t.Errorf("current = %+v, want the newest session %d with 3 words remaining", current, f.SessionID())
+	}
+	if _, err := svc.GetCurrentStudySession(now.Add(time.Second), window); err != nil {
+		t.Fatal(err)
+	}
+	session, err := svc.GetStudySessionByID(boundary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.EndedAt == nil || !session.EndedAt.Equal(now.Add(-window)) {
+		t.Errorf("boundary session ended at %v a second later, want %v", session.EndedAt, now.Add(-window))
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+// CreateShareLink creates a new share link for a group. A nil expiresAt creates a link that
+// never expires. It returns sql.ErrNoRows if the group does not exist.
+func (s *Service) CreateShareLink(groupID int, expiresAt *time.Time) (*models.ShareLink, error) {
+	if _, err := s.GetGroupByID(groupID); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	link := &models.ShareLink{GroupID: groupID, Token: base64.RawURLEncoding.EncodeToString(b), CreatedAt: time.Now().UTC()}
+	var expires interface{}
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		link.ExpiresAt = &utc
+		expires = utc
+	}
+
+	result, err := s.DB.Exec("INSERT INTO share_links (group_id, token, expires_at, created_at) VALUES (?, ?, ?, ?)",
+		groupID, link.Token, expires, link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	link.ID = int(id)
+	return link, nil
+}
+
+// RevokeShareLink revokes a group's share link. It returns sql.ErrNoRows if the group has no
+// active link with that token.
+func (s *Service) RevokeShareLink(groupID int, token string) error {
+	result, err := s.DB.Exec("UPDATE share_links SET revoked_at = ? WHERE group_id = ? AND token = ? AND revoked_at IS NULL",
+		time.Now().UTC(), groupID, token)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetSharedGroup resolves a share link token to the group it shares, with its words and stats.
+// It returns sql.ErrNoRows for unknown, revoked or expired tokens.
+func (s *Service) GetSharedGroup(token string) (*models.SharedGroup, error) {
+	var groupID int
+	var expiresAt, revokedAt sql.NullTime
+	err := s.DB.QueryRow("SELECT group_id, expires_at, revoked_at FROM share_links WHERE token = ?", token).
+		Scan(&groupID, &expiresAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid || (expiresAt.Valid && time.Now().After(expiresAt.Time)) {
+		return nil, sql.ErrNoRows
+	}
+
+	group, err := s.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	// Share links are public, so the author's username is not disclosed
+	group.CreatedBy = nil
+	words, err := s.GetGroupWords(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if words == nil {
+		words = make([]models.Word, 0)
+	}
+	stats, err := s.GetGroupStats(groupID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SharedGroup{Group: *group, Words: words, Stats: *stats}, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// wordSortColumns whitelists the fields words can be sorted by and the columns they sort on.
+var wordSortColumns = map[string]string{
+	"id":         "w.id",
+	"japanese":   "w.japanese",
+	"romaji":     "w.romaji",
+	"english":    "w.english",
+	"updated_at": "w.updated_at",
+}
+
+// SortField is one key of a multi-column sort.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// WordSort is an ordered list of sort keys for word listings. The zero value sorts by ID.
+type WordSort []SortField
+
+// SortError reports an invalid sort_by or order query parameter.
+type SortError struct {
+	Param   string
+	Message string
+}
+
+func (e *SortError) Error() string {
+	return e.Param + " " + e.Message
+}
+
+// ParseWordSort parses comma-separated sort_by and order parameters, e.g. "romaji,english" and
+// "asc,desc". A single order applies to every field, an empty one means ascending.
+func ParseWordSort(sortBy, order string) (WordSort, error) {
+	if strings.TrimSpace(sortBy) == "" {
+		if strings.TrimSpace(order) != "" {
+			return nil, &SortError{Param: "order", Message: "requires sort_by"}
+		}
+		return nil, nil
+	}
+	fields := strings.Split(sortBy, ",")
+	var orders []string
+	if strings.TrimSpace(order) != "" {
+		orders = strings.Split(order, ",")
+	}
+	if len(orders) > 1 && len(orders) != len(fields) {
+		return nil, &SortError{Param: "order", Message: fmt.Sprintf("must have one value or one per sort_by field (%d)", len(fields))}
+	}
+
+	sort := make(WordSort, 0, len(fields))
+	seen := make(map[string]bool)
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		if _, ok := wordSortColumns[field]; !ok {
+			return nil, &SortError{Param: "sort_by", Message: fmt.Sprintf("has unknown field %q", field)}
+		}
+		if seen[field] {
+			return nil, &SortError{Param: "sort_by", Message: fmt.Sprintf("repeats field %q", field)}
+		}
+		seen[field] = true
+
+		dir := ""
+		switch {
+		case len(orders) == 1:
+			dir = orders[0]
+		case len(orders) > 1:
+			dir = orders[i]
+		}
+		switch strings.ToLower(strings.TrimSpace(dir)) {
+		case "", "asc":
+			sort = append(sort, SortField{Field: field})
+		case "desc":
+			sort = append(sort, SortField{Field: field, Desc: true})
+		default:
+			return nil, &SortError{Param: "order", Message: fmt.Sprintf("has invalid direction %q, expected asc or desc", dir)}
+		}
+	}
+	return sort, nil
+}
+
+// orderBy builds the ORDER BY clause of the sort. The word ID is always the final key so that
+// words with equal sort values come back in the same order on every call and every page.
+func (ws WordSort) orderBy() string {
+	keys := make([]string, 0, len(ws)+1)
+	hasID := false
+	for _, f := range ws {
+		key := wordSortColumns[f.Field]
+		if f.Desc {
+			key += " DESC"
+		}
+		keys = append(keys, key)
+		hasID = hasID || f.Field == "id"
+	}
+	if !hasID {
+		keys = append(keys, "w.id")
+	}
+	return " ORDER BY " + strings.Join(keys, ", ")
+}
+
+// keyset returns the order of the sort as a keyset for cursor pagination, ending with the word ID
+// like orderBy. A word without updated_at sorts as if it were empty.
+func (ws WordSort) keyset() keyset {
+	ks := keyset{listing: "words"}
+	hasID := false
+	for _, f := range ws {
+		expr := wordSortColumns[f.Field]
+		if f.Field == "updated_at" {
+			expr = "COALESCE(" + expr + ", '')"
+		}
+		ks.keys = append(ks.keys, keysetKey{expr: expr, desc: f.Desc, integer: f.Field == "id"})
+		hasID = hasID || f.Field == "id"
+	}
+	if !hasID {
+		ks.keys = append(ks.keys, keysetKey{expr: "w.id", integer: true})
+	}
+	return ks
+}
@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"backend_go/internal/models"
+	"backend_go/internal/service"
+	"backend_go/internal/testutil"
+)
+
+func TestParseWordSort(t *testing.T) {
+	tests := []struct {
+		sortBy, order string
+		want          service.WordSort
+		errParam      string
+	}{
+		{"", "", nil, ""},
+		{"romaji", "", service.WordSort{{Field: "romaji"}}, ""},
+		{" romaji , english ", "DESC", service.WordSort{{Field: "romaji", Desc: true}, {Field: "english", Desc: true}}, ""},
+		{"romaji,english", "asc,desc", service.WordSort{{Field: "romaji"}, {Field: "english", Desc: true}}, ""},
+		{"updated_at,id", "desc, asc", service.WordSort{{Field: "updated_at", Desc: true}, {Field: "id"}}, ""},
+		{"", "asc", nil, "order"},
+		{"kanji", "", nil, "sort_by"},
+		{"romaji,", "", nil, "sort_by"},
+		{"romaji,romaji", "", nil, "sort_by"},
+		{"romaji,english,id", "asc,desc", nil, "order"},
+		{"romaji", "up", nil, "order"},
+	}
+	for _, tt := range tests {
+		got, err := service.ParseWordSort(tt.sortBy, tt.order)
+		if tt.errParam != "" {
+			var sortErr *service.SortError
+			if !errors.As(err, &sortErr) || sortErr.Param != tt.errParam {
+				t.Errorf("ParseWordSort(%q, %q) error = %v, want a %s error", tt.sortBy, tt.order, err, tt.errParam)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseWordSort(%q, %q) = %v, %v, want %v", tt.sortBy, tt.order, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetWordsSort(t *testing.T) {
+	svc := testutil.NewService(t)
+	// Several words share their romaji, and some their english too, so only the ID tells them apart
+	f := testutil.NewFixture(t, svc.DB).Group("N5").
+		Word("橋", "hashi", "bridge").
+		Word("箸", "hashi", "chopsticks").
+		Word("端", "hashi", "edge").
+		Word("雨", "ame", "rain").
+		Word("飴", "ame", "candy").
+		Word("紙", "kami", "paper").
+		Word("髪", "kami", "hair").
+		Word("神", "kami", "god").
+		Word("上", "kami", "paper")
+
+	ids := func(words []models.Word) []int {
+		var ids []int
+		for _, w := range words {
+			ids = append(ids, w.ID)
+		}
+		return ids
+	}
+	sort := service.WordSort{{Field: "romaji", Desc: true}, {Field: "english"}}
+	words, err := svc.GetWords(sort, service.WordFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{
+		f.WordID("神"), f.WordID("髪"), f.WordID("紙"), f.WordID("上"),
+		f.WordID("橋"), f.WordID("箸"), f.WordID("端"),
+		f.WordID("飴"), f.WordID("雨"),
+	}
+	if got := ids(words); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sorted IDs = %v, want %v", got, want)
+	}
+
+	for i := 0; i < 5; i++ {
+		again, err := svc.GetWords(sort, service.WordFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ids(again), want) {
+			t.Fatalf("call %d returned %v, want the same order %v", i+2, ids(again), want)
+		}
+	}
+
+	// Offset pages split ties the same way, so together they list every word once
+	var paged []int
+	for page := 1; page <= 5; page++ {
+		words, err := svc.GetWordsPage(page, 2, sort, service.WordFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		paged = append(paged, ids(words)...)
+	}
+	if !reflect.DeepEqual(paged, want) {
+		t.Errorf("pages of 2 listed %v, want %v", paged, want)
+	}
+
+	// A descending ID sort is not followed by another ID key
+	words, err = svc.GetWords(service.WordSort{{Field: "romaji"}, {Field: "id", Desc: true}}, service.WordFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(words)[:2]; !reflect.DeepEqual(got, []int{f.WordID("飴"), f.WordID("雨")}) {
+		t.Errorf("romaji then descending ID starts with %v, want 飴 and 雨", got)
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"backend_go/internal/models"
+)
+
+// MaxSessionDuration caps the length counted for a single study session, so that a session left
+// open in a forgotten tab does not report hours of study.
+const MaxSessionDuration = 4 * time.Hour
+
+// sessionStatsSQL selects one row per study session of the learner: its id, activity, start time,
+// number of answered (not skipped) reviews, number of correct reviews, and length in seconds. A
+// session lasts from its start until it ended. Legacy sessions without an end time last from their
+// first to their last review, and their length is NULL when they have no reviews. Lengths are
+// clamped to MaxSessionDuration.
+func (s *Service) sessionStatsSQL() string {
+	return fmt.Sprintf(`SELECT s.id, s.study_activity_id, COALESCE(s.created_at, r.first_review_at) AS started_at,
+       COALESCE(r.reviews, 0) AS reviews, COALESCE(r.correct, 0) AS correct,
+       MIN(%d, MAX(0, CASE WHEN s.ended_at IS NOT NULL
+                           THEN julianday(s.ended_at) - julianday(COALESCE(s.created_at, r.first_review_at))
+                           ELSE julianday(r.last_review_at) - julianday(r.first_review_at) END * 86400)) AS duration_seconds
+FROM study_sessions s
+LEFT JOIN (SELECT study_session_id, SUM(NOT skipped) AS reviews, SUM(correct) AS correct,
+                  MIN(created_at) AS first_review_at, MAX(created_at) AS last_review_at
+           FROM word_review_items GROUP BY study_session_id) r ON r.study_session_id = s.id
+WHERE %s`, int(MaxSessionDuration.Seconds()), s.userCond("s"))
+}
+
+// getStudyTime adds up the study time of all sessions and of the sessions started in each of the
+// given number of calendar weeks ending with the week of now (see calendarWeeks), oldest week first.
+func (s *Service) getStudyTime(now time.Time, weeks int) (float64, []models.StudyTimeWeek, error) {
+	var total float64
+	if err := s.DB.QueryRow(`SELECT COALESCE(SUM(duration_seconds), 0) FROM (` + s.sessionStatsSQL() + `)`).Scan(&total); err != nil {
+		return 0, nil, err
+	}
+
+	bounds := s.calendarWeeks(now, weeks)
+	// Week boundaries depend on the timezone rules, so the sessions are bucketed here rather than in SQL
+	rows, err := s.DB.Query(`SELECT datetime(started_at), duration_seconds
+	                         FROM (`+s.sessionStatsSQL()+`)
+	                         WHERE duration_seconds IS NOT NULL AND started_at >= ? AND started_at < ?`,
+		bounds[0].UTC().Format(sqliteTimeFormat), bounds[weeks].UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	seconds := make([]float64, weeks)
+	for rows.Next() {
+		var startedAt string
+		var duration float64
+		if err := rows.Scan(&startedAt, &duration); err != nil {
+			return 0, nil, err
+		}
+		at, err := time.Parse(sqliteTimeFormat, startedAt)
+		if err != nil {
+			return 0, nil, err
+		}
+		if i := weekOf(bounds, at); i >= 0 {
+			seconds[i] += duration
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	series := make([]models.StudyTimeWeek, weeks)
+	for i := range series {
+		series[i] = models.StudyTimeWeek{WeekStart: bounds[i], WeekEnd: bounds[i+1], Minutes: roundMinutes(seconds[i])}
+	}
+	return roundMinutes(total), series, nil
+}
+
+// roundMinutes converts seconds to minutes rounded to one decimal.
+func roundMinutes(seconds float64) float64 {
+	return math.Round(seconds/6) / 10
+}
@@ -0,0 +1,56 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"backend_go/internal/models"
+	"backend_go/internal/testutil"
+)
+
+func TestStudyTime(t *testing.T) {
+	svc := testutil.NewService(t)
+	// A Wednesday: the two weeks asked for start on Monday May 6 and Monday May 13
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour, minute int) time.Time { return time.Date(2024, 5, day, hour, minute, 0, 0, time.UTC) }
+	f := testutil.NewFixture(t, svc.DB).Group("N5").Word("水", "mizu", "water")
+
+	// This week: 30 minutes until ended
+	f.SessionAt(at(13, 10, 0)).End(at(13, 10, 30))
+	// Left open: 15 minutes from the first to the last review
+	f.SessionAt(at(14, 9, 0)).ReviewAt(true, at(14, 9, 5)).ReviewAt(false, at(14, 9, 20))
+	// Ended ten hours later: clamped to four hours
+	f.SessionAt(at(14, 20, 0)).End(at(15, 6, 0))
+
+	// Last week: 45 minutes, then sessions that count for nothing: open without reviews, open with
+	// a single review, and ended before it started
+	f.SessionAt(at(8, 12, 0)).End(at(8, 12, 45))
+	f.SessionAt(at(7, 8, 0))
+	f.SessionAt(at(8, 8, 0)).ReviewAt(true, at(8, 8, 10))
+	f.SessionAt(at(9, 8, 0)).End(at(9, 7, 0))
+
+	// Before the weeks asked for: only in the total
+	f.SessionAt(time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)).End(time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC))
+
+	progress, err := svc.GetDashboardStudyProgress(now, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total := progress["total_study_minutes"]; total != 30+15+240+45+60.0 {
+		t.Errorf("total_study_minutes = %v, want 390", total)
+	}
+	weekly, ok := progress["weekly_study_minutes"].([]models.StudyTimeWeek)
+	if !ok || len(weekly) != 2 {
+		t.Fatalf("weekly_study_minutes = %#v, want 2 weeks", progress["weekly_study_minutes"])
+	}
+	want := []models.StudyTimeWeek{
+		{WeekStart: at(6, 0, 0), WeekEnd: at(13, 0, 0), Minutes: 45},
+		{WeekStart: at(13, 0, 0), WeekEnd: at(20, 0, 0), Minutes: 30 + 15 + 240},
+	}
+	for i, w := range want {
+		got := weekly[i]
+		if !got.WeekStart.Equal(w.WeekStart) || !got.WeekEnd.Equal(w.WeekEnd) || got.Minutes != w.Minutes {
+			t.Errorf("week %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
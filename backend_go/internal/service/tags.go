@@ -0,0 +1,112 @@
+package service
+
+import (
+	"database/sql"
+	"strings"
+
+	"backend_go/internal/models"
+)
+
+// NormalizeTag returns the canonical form of a tag name: trimmed and lower-cased.
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// GetWordTags retrieves the tags attached to a word, ordered by name.
+func (s *Service) GetWordTags(wordID int) ([]models.Tag, error) {
+	rows, err := s.DB.Query(`SELECT t.id, t.name
+	                         FROM tags t
+	                         JOIN word_tags wt ON t.id = wt.tag_id
+	                         WHERE wt.word_id = ?
+	                         ORDER BY t.name`, wordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags := make([]models.Tag, 0)
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// AddWordTag attaches the named tag to a word, creating the tag if it does not exist yet.
+// Adding a tag the word already has is a no-op. It returns sql.ErrNoRows if the word does not exist.
+func (s *Service) AddWordTag(wordID int, name string) error {
+	name = NormalizeTag(name)
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM words WHERE id = ? AND deleted_at IS NULL", wordID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT OR IGNORE INTO word_tags (word_id, tag_id) SELECT ?, id FROM tags WHERE name = ?", wordID, name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveWordTag detaches the named tag from a word.
+// It returns sql.ErrNoRows if the word did not have the tag.
+func (s *Service) RemoveWordTag(wordID int, name string) error {
+	result, err := s.DB.Exec("DELETE FROM word_tags WHERE word_id = ? AND tag_id = (SELECT id FROM tags WHERE name = ?)", wordID, NormalizeTag(name))
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetWordsByTag retrieves all words carrying the named tag.
+func (s *Service) GetWordsByTag(name string) ([]models.Word, error) {
+	rows, err := s.DB.Query(`SELECT `+wordColumns+`
+	                         FROM words w
+	                         JOIN word_tags wt ON w.id = wt.word_id
+	                         JOIN tags t ON t.id = wt.tag_id
+	                         WHERE t.name = ? AND w.deleted_at IS NULL`, NormalizeTag(name))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	words := make([]models.Word, 0)
+	for rows.Next() {
+		var word models.Word
+		if err := scanWord(rows, &word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// CountWordsByTag returns the number of words carrying the named tag.
+func (s *Service) CountWordsByTag(name string) (int, error) {
+	var count int
+	err := s.DB.QueryRow(`SELECT COUNT(*)
+	                      FROM word_tags wt
+	                      JOIN tags t ON t.id = wt.tag_id
+	                      JOIN words w ON w.id = wt.word_id
+	                      WHERE t.name = ? AND w.deleted_at IS NULL`, NormalizeTag(name)).Scan(&count)
+	return count, err
+}
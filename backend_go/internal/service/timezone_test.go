@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"backend_go/internal/models"
+	"backend_go/internal/testutil"
+)
+
+// kiritimati is UTC+14, so its calendar days start at 10:00 UTC of the previous UTC day and most
+// of a local day falls on another UTC date.
+func kiritimati(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func TestTimezoneDays(t *testing.T) {
+	svc := testutil.NewService(t)
+	loc := kiritimati(t)
+	svc.SetLocation(loc)
+	local := func(day, hour, minute, second int) time.Time {
+		return time.Date(2024, 6, day, hour, minute, second, 0, loc)
+	}
+	// Tuesday June 11 at 20:00, 06:00 UTC
+	now := local(11, 20, 0, 0)
+	f := testutil.NewFixture(t, svc.DB).Group("N5").
+		Word("水", "mizu", "water").SessionAt(local(9, 12, 0, 0)).
+		ReviewAt(true, local(9, 12, 0, 0)).
+		// Half a minute before and right at local midnight, both on June 10 in UTC
+		ReviewAt(true, local(10, 23, 59, 30)).
+		ReviewAt(false, local(11, 0, 0, 0)).
+		ReviewAt(true, local(11, 0, 30, 0)).
+		ReviewAt(true, local(11, 19, 0, 0)).
+		SkipAt(local(11, 19, 30, 0)).
+		Word("火", "hi", "fire").
+		Word("山", "yama", "mountain").
+		Word("川", "kawa", "river")
+	schedule(t, svc.DB, f.WordID("火"), local(11, 19, 59, 0))
+	schedule(t, svc.DB, f.WordID("山"), local(11, 23, 30, 0))
+	schedule(t, svc.DB, f.WordID("川"), local(12, 0, 10, 0))
+
+	progress, err := svc.GetGoalProgress(now, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Date != "2024-06-11" || progress.Timezone != "Pacific/Kiritimati" || progress.Reviews != 3 || progress.Remaining != 2 || progress.Met {
+		t.Errorf("goal progress = %+v, want 3 reviews of 5 on 2024-06-11", progress)
+	}
+
+	digest, err := svc.GetDigest(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest.Date != "2024-06-11" || digest.Reviews != 3 || digest.CorrectCount != 2 || digest.StreakDays != 3 {
+		t.Errorf("digest = %+v, want 2 of 3 right on 2024-06-11 in a 3 day streak", digest)
+	}
+	// Tomorrow's due words: the one due before now, the one due tonight and the one due after midnight
+	if digest.DueTomorrow != 3 {
+		t.Errorf("digest due tomorrow = %d, want 3", digest.DueTomorrow)
+	}
+	if digest, err = svc.GetDigest(local(10, 12, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if digest.Date != "2024-06-10" || digest.Reviews != 1 || digest.StreakDays != 2 {
+		t.Errorf("digest of June 10 = %+v, want the one review before midnight in a 2 day streak", digest)
+	}
+
+	stats, err := svc.GetDashboardQuickStats(now, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accuracy := stats["recent_accuracy"].(float64); math.Abs(accuracy-200.0/3) > 1e-9 {
+		t.Errorf("recent accuracy of today = %v, want 66.7", accuracy)
+	}
+
+	upcoming, err := svc.GetUpcomingReviews(now, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.UpcomingReviews{Due: 1, Days: []models.UpcomingDay{{Date: "2024-06-11", Words: 1}, {Date: "2024-06-12", Words: 1}}}
+	if upcoming.Due != want.Due || len(upcoming.Days) != 2 || upcoming.Days[0] != want.Days[0] || upcoming.Days[1] != want.Days[1] {
+		t.Errorf("upcoming = %+v, want %+v", upcoming, want)
+	}
+}
+
+func TestTimezoneWeeks(t *testing.T) {
+	svc := testutil.NewService(t)
+	loc := kiritimati(t)
+	svc.SetLocation(loc)
+	local := func(day, hour, minute int) time.Time { return time.Date(2024, 6, day, hour, minute, 0, 0, loc) }
+	// Tuesday June 11: the weeks asked for start on Monday June 3 and Monday June 10, local time
+	now := local(11, 20, 0)
+
+	// Both words are mastered on Sunday June 9 in UTC: 水 five minutes before local midnight, in
+	// the first week, and 火 five minutes after, in the second
+	testutil.NewFixture(t, svc.DB).Group("N5").
+		SessionAt(local(9, 23, 0)).End(local(9, 23, 50)).
+		Word("水", "mizu", "water").
+		ReviewAt(true, local(9, 23, 0)).ReviewAt(true, local(9, 23, 30)).ReviewAt(true, local(9, 23, 55)).
+		SessionAt(local(10, 0, 5)).End(local(10, 0, 35)).
+		Word("火", "hi", "fire").
+		ReviewAt(true, local(9, 23, 0)).ReviewAt(true, local(9, 23, 30)).ReviewAt(true, local(10, 0, 5))
+
+	velocity, err := svc.GetDashboardVelocity(now, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(velocity) != 2 || !velocity[1].WeekStart.Equal(local(10, 0, 0)) || !velocity[0].WeekStart.Equal(local(3, 0, 0)) {
+		t.Fatalf("velocity weeks = %+v, want them to start on local Mondays June 3 and 10", velocity)
+	}
+	if velocity[0].Mastered != 1 || velocity[1].Mastered != 1 {
+		t.Errorf("mastered per week = %d and %d, want 1 and 1", velocity[0].Mastered, velocity[1].Mastered)
+	}
+
+	progress, err := svc.GetDashboardStudyProgress(now, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weekly := progress["weekly_study_minutes"].([]models.StudyTimeWeek)
+	if weekly[0].Minutes != 50 || weekly[1].Minutes != 30 {
+		t.Errorf("study minutes per week = %v and %v, want 50 and 30", weekly[0].Minutes, weekly[1].Minutes)
+	}
+}
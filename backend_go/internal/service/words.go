@@ -0,0 +1,429 @@
+package service
+
+import (
+	"database/sql"
+	"hash/fnv"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend_go/internal/media"
+	"backend_go/internal/models"
+)
+
+// wordColumns lists the columns of the words table (aliased w) read by scanWord, in order,
+// including the word's meanings as a JSON array.
+const wordColumns = "w.id, w.japanese, w.reading, w.romaji, w.english, w.parts, w.updated_at, w.deleted_at, w.image_path, w.audio_path, w.jlpt_level, w.mnemonic, " +
+	"w.source, w.source_id, " +
+	"(SELECT json_group_array(meaning) FROM (SELECT wm.meaning FROM word_meanings wm WHERE wm.word_id = w.id ORDER BY wm.position))"
+
+// trimmedText trims the optional text of a write, keeping nil as nil.
+func trimmedText(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	return &trimmed
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWord scans the wordColumns of a row into word, followed by any extra destinations.
+func scanWord(row rowScanner, word *models.Word, extra ...interface{}) error {
+	var updatedAt, deletedAt sql.NullTime
+	var imagePath, audioPath sql.NullString
+	dest := append([]interface{}{&word.ID, &word.Japanese, &word.Reading, &word.Romaji, &word.English, &word.Parts, &updatedAt, &deletedAt,
+		&imagePath, &audioPath, &word.JLPTLevel, &word.Mnemonic, &word.Source, &word.SourceID, &word.Meanings}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	word.UpdatedAt = updatedAt.Time
+	if deletedAt.Valid {
+		word.DeletedAt = &deletedAt.Time
+	}
+	if imagePath.Valid {
+		url := media.URL(imagePath.String)
+		word.ImageURL = &url
+	}
+	if audioPath.Valid {
+		url := media.URL(audioPath.String)
+		word.AudioURL = &url
+	}
+	return nil
+}
+
+// GetWordsUpdatedSince retrieves the words created, updated or soft-deleted after since, oldest
+// change first. Soft-deleted words are included, with DeletedAt set, so that syncing clients can
+// remove them locally.
+func (s *Service) GetWordsUpdatedSince(since time.Time) ([]models.Word, error) {
+	rows, err := s.DB.Query("SELECT "+wordColumns+" FROM words w WHERE w.updated_at > ? ORDER BY w.updated_at, w.id",
+		since.UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	words := make([]models.Word, 0)
+	for rows.Next() {
+		var word models.Word
+		if err := scanWord(rows, &word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// GetWordWithStats retrieves a word together with its review counts and accuracy, overall and by
+// direction.
+func (s *Service) GetWordWithStats(id int) (*models.WordWithStats, error) {
+	query := `SELECT ` + wordColumns + `,
+	                 COALESCE(SUM(CASE WHEN wr.correct THEN 1 ELSE 0 END), 0),
+	                 COALESCE(SUM(CASE WHEN wr.id IS NOT NULL AND NOT wr.correct AND NOT wr.skipped THEN 1 ELSE 0 END), 0)` +
+		directionColumns() + `
+	          FROM words w
+	          LEFT JOIN word_review_items wr ON wr.word_id = w.id AND ` + s.userCond("wr") + `
+	          WHERE w.id = ? AND w.deleted_at IS NULL
+	          GROUP BY w.id`
+	var word models.WordWithStats
+	directions := newDirectionCounts()
+	if err := scanWord(s.DB.QueryRow(query, id), &word.Word, append([]interface{}{&word.CorrectCount, &word.WrongCount}, directions.dest()...)...); err != nil {
+		return nil, err
+	}
+	if total := word.CorrectCount + word.WrongCount; total > 0 {
+		accuracy := float64(word.CorrectCount) / float64(total)
+		word.Accuracy = &accuracy
+	}
+	word.ByDirection = directions.stats()
+	return &word, nil
+}
+
+// GetWordOfTheDay picks a word deterministically from the date of day in the configured timezone,
+// so every request on the same day gets the same word. It returns sql.ErrNoRows when there are no words.
+func (s *Service) GetWordOfTheDay(day time.Time) (*models.WordWithStats, error) {
+	var count int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM words WHERE deleted_at IS NULL").Scan(&count); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(day.In(s.Location()).Format("2006-01-02")))
+	offset := int(h.Sum32() % uint32(count))
+
+	var id int
+	if err := s.DB.QueryRow("SELECT id FROM words WHERE deleted_at IS NULL ORDER BY id LIMIT 1 OFFSET ?", offset).Scan(&id); err != nil {
+		return nil, err
+	}
+	return s.GetWordWithStats(id)
+}
+
+// GetWordsPage retrieves one page of words in the given order using offset pagination.
+func (s *Service) GetWordsPage(page, perPage int, sort WordSort, filter WordFilter) ([]models.Word, error) {
+	join, where, args := filter.clauses(s.userCond(""))
+	rows, err := s.DB.Query("SELECT "+wordColumns+" FROM words w"+join+" WHERE w.deleted_at IS NULL"+where+sort.orderBy()+" LIMIT ? OFFSET ?",
+		append(args, perPage, (page-1)*perPage)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanWords(rows)
+}
+
+// GetWordsAfter retrieves up to limit words with IDs greater than afterID, in ID order.
+// This keyset pagination stays efficient for large scans and never skips or repeats words
+// when rows are added or removed between requests. The returned cursor is the afterID to
+// pass for the next page, or nil when there are no more words.
+func (s *Service) GetWordsAfter(afterID, limit int, filter WordFilter) ([]models.Word, *int, error) {
+	// Fetch one extra row to learn whether another page follows
+	join, where, args := filter.clauses(s.userCond(""))
+	rows, err := s.DB.Query("SELECT "+wordColumns+" FROM words w"+join+" WHERE w.id > ? AND w.deleted_at IS NULL"+where+" ORDER BY w.id LIMIT ?",
+		append(append([]interface{}{afterID}, args...), limit+1)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	words, err := scanWords(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(words) <= limit {
+		return words, nil, nil
+	}
+	words = words[:limit]
+	next := words[limit-1].ID
+	return words, &next, nil
+}
+
+// GetWordsByCursor retrieves up to limit words in the given order, starting after the page whose
+// next cursor is cursor, or at the first word when cursor is "". The pages never skip or repeat
+// words when words are added or removed between requests, as offset pages do. It returns the cursor
+// of the next page, nil on the last page, and ErrInvalidCursor for a cursor it did not issue for
+// this order or that has expired.
+func (s *Service) GetWordsByCursor(cursor string, limit int, sort WordSort, filter WordFilter) ([]models.Word, *string, error) {
+	ks := sort.keyset()
+	now := time.Now()
+	start, err := ks.decode(cursor, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	join, where, args := filter.clauses(s.userCond(""))
+	if after, afterArgs := ks.after(start); after != "" {
+		where += " AND " + after
+		args = append(args, afterArgs...)
+	}
+	query := "SELECT " + wordColumns + ks.columns() + " FROM words w" + join +
+		" WHERE w.deleted_at IS NULL" + where + ks.orderBy() + " LIMIT ?"
+	rows, err := s.DB.Query(query, append(args, limit+1)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	// Fetch one extra row to learn whether another page follows
+	words := make([]models.Word, 0)
+	var last []string
+	for rows.Next() {
+		var word models.Word
+		values, dest := ks.scanValues()
+		if err := scanWord(rows, &word, dest...); err != nil {
+			return nil, nil, err
+		}
+		if len(words) == limit {
+			return words, ks.encode(last, now), nil
+		}
+		words = append(words, word)
+		last = values
+	}
+	return words, nil, rows.Err()
+}
+
+// WordFilter narrows the word listings by review stats. The zero value matches every word.
+type WordFilter struct {
+	// MaxAccuracy keeps the words answered correctly at most this share of the time, 0 to 1.
+	// Words never reviewed have no accuracy and are left out.
+	MaxAccuracy *float64
+	// MinReviews keeps the words reviewed at least this many times.
+	MinReviews int
+	// Source keeps the words of this source, see ImportWord.
+	Source string
+	// PartOfSpeech, VerbClass, Transitivity and AdjectiveType keep the words whose parts have
+	// these values, and Usage those whose parts have this usage tag (see ParseParts).
+	PartOfSpeech  string
+	VerbClass     string
+	Transitivity  string
+	AdjectiveType string
+	Usage         string
+	// Script keeps the words whose japanese text is written in this script, one of Scripts.
+	Script string
+}
+
+// clauses returns the join and the WHERE conditions (each starting with a space) that apply the
+// filter to a query on words w, and their arguments. The review stats are those of the reviews
+// meeting reviewCond (see Service.userCond).
+func (f WordFilter) clauses(reviewCond string) (join, where string, args []interface{}) {
+	if f.Source != "" {
+		where += " AND w.source = ?"
+		args = append(args, f.Source)
+	}
+	for _, field := range [][2]string{{"$.part_of_speech", f.PartOfSpeech}, {"$.verb_class", f.VerbClass},
+		{"$.transitivity", f.Transitivity}, {"$.adjective_type", f.AdjectiveType}} {
+		if field[1] != "" {
+			where += " AND " + partsField(field[0]) + " = ?"
+			args = append(args, field[1])
+		}
+	}
+	if f.Script != "" {
+		where += " AND w.script = ?"
+		args = append(args, f.Script)
+	}
+	if f.Usage != "" {
+		where += " AND EXISTS (SELECT 1 FROM json_each(" + partsField("$.usage") + ") WHERE value = ?)"
+		args = append(args, f.Usage)
+	}
+	if f.MaxAccuracy == nil && f.MinReviews <= 0 {
+		return "", where, args
+	}
+	join = ` LEFT JOIN (SELECT word_id, COUNT(*) AS reviews, AVG(CASE WHEN skipped THEN NULL WHEN correct THEN 1.0 ELSE 0.0 END) AS accuracy
+	                   FROM word_review_items WHERE ` + reviewCond + ` GROUP BY word_id) rs ON rs.word_id = w.id`
+	if f.MinReviews > 0 {
+		where += " AND rs.reviews >= ?"
+		args = append(args, f.MinReviews)
+	}
+	if f.MaxAccuracy != nil {
+		where += " AND rs.accuracy <= ?"
+		args = append(args, *f.MaxAccuracy)
+	}
+	return join, where, args
+}
+
+// scanWords scans every row of a query selecting wordColumns.
+func scanWords(rows *sql.Rows) ([]models.Word, error) {
+	words := make([]models.Word, 0)
+	for rows.Next() {
+		var word models.Word
+		if err := scanWord(rows, &word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// bulkIDs sorts out the IDs given to a bulk operation: IDs that are not positive fail and repeated
+// ones are skipped. It returns the rest, in order, for the operation to apply or skip.
+func bulkIDs(ids []int) ([]int, *models.BulkResult) {
+	result := &models.BulkResult{Succeeded: []int{}, Skipped: []models.BulkOutcome{}, Failed: []models.BulkOutcome{}}
+	seen := make(map[int]bool, len(ids))
+	var candidates []int
+	for _, id := range ids {
+		switch {
+		case id <= 0:
+			result.Failed = append(result.Failed, models.BulkOutcome{ID: id, Reason: "not a valid word ID"})
+		case seen[id]:
+			result.Skipped = append(result.Skipped, models.BulkOutcome{ID: id, Reason: "listed more than once"})
+		default:
+			seen[id] = true
+			candidates = append(candidates, id)
+		}
+	}
+	return candidates, result
+}
+
+// BulkDeleteWords soft-deletes the given words in one transaction and removes their group links,
+// tags, reviews, schedules and relations so that nothing is left pointing at a deleted word. IDs of
+// unknown or already deleted words are skipped, and the media files of the deleted words are removed.
+func (s *Service) BulkDeleteWords(ids []int) (*models.BulkResult, error) {
+	candidates, result := bulkIDs(ids)
+	if len(candidates) == 0 {
+		return result, nil
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	placeholders, args := inPlaceholders(candidates)
+	rows, err := tx.Query("SELECT id, deleted_at IS NOT NULL FROM words WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	deleted := make(map[int]bool, len(candidates))
+	for rows.Next() {
+		var id int
+		var isDeleted bool
+		if err := rows.Scan(&id, &isDeleted); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deleted[id] = isDeleted
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, id := range candidates {
+		isDeleted, found := deleted[id]
+		switch {
+		case !found:
+			result.Skipped = append(result.Skipped, models.BulkOutcome{ID: id, Reason: "word not found"})
+		case isDeleted:
+			result.Skipped = append(result.Skipped, models.BulkOutcome{ID: id, Reason: "word already deleted"})
+		default:
+			result.Succeeded = append(result.Succeeded, id)
+		}
+	}
+	if len(result.Succeeded) == 0 {
+		return result, nil
+	}
+
+	placeholders, args = inPlaceholders(result.Succeeded)
+	if _, err := tx.Exec(`UPDATE words SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+	                      WHERE id IN (`+placeholders+`)`, args...); err != nil {
+		return nil, err
+	}
+	for _, table := range []string{"word_groups", "word_tags", "word_review_items", "word_srs"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE word_id IN ("+placeholders+")", args...); err != nil {
+			return nil, err
+		}
+	}
+	if err := deleteWordRelations(tx, result.Succeeded); err != nil {
+		return nil, err
+	}
+	files, err := clearWordMedia(tx, result.Succeeded)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.releaseMedia(files...)
+	return result, nil
+}
+
+// DeleteWordsBySource soft-deletes every word of source, as BulkDeleteWords does, to roll back an
+// import. It returns the number of words deleted.
+func (s *Service) DeleteWordsBySource(source string) (int, error) {
+	rows, err := s.DB.Query("SELECT id FROM words WHERE source = ? AND deleted_at IS NULL", source)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	result, err := s.BulkDeleteWords(ids)
+	if err != nil {
+		return 0, err
+	}
+	return len(result.Succeeded), nil
+}
+
+// ContainsKanji reports whether s contains a kanji (Han script) character, which means the
+// word cannot be read without a reading.
+func ContainsKanji(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetWordsMissingReading retrieves the words that have no reading although their japanese text
+// contains kanji, ordered by ID.
+func (s *Service) GetWordsMissingReading() ([]models.Word, error) {
+	rows, err := s.DB.Query("SELECT " + wordColumns + " FROM words w WHERE w.deleted_at IS NULL AND COALESCE(TRIM(w.reading), '') = '' ORDER BY w.id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	words, err := scanWords(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]models.Word, 0)
+	for _, w := range words {
+		if ContainsKanji(w.Japanese) {
+			missing = append(missing, w)
+		}
+	}
+	return missing, nil
+}
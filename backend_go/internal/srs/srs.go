@@ -0,0 +1,66 @@
+// Package srs implements the spaced repetition schedule used to decide when a word is next due.
+//
+// It is a simplified SM-2: every correct answer grows the review interval by the word's ease
+// factor, and every wrong answer resets the interval to one day and makes the word "harder".
+package srs
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// InitialEase is the ease factor of a word that has not been reviewed yet.
+	InitialEase = 2.5
+	// MinEase is the floor the ease factor never drops below.
+	MinEase = 1.3
+	// MaxEase is the ceiling the ease factor never rises above.
+	MaxEase = 3.0
+)
+
+// State is the scheduling state of a word after some number of reviews.
+type State struct {
+	// Repetitions counts consecutive correct reviews.
+	Repetitions int
+	// IntervalDays is the gap between the last review and the next one.
+	IntervalDays int
+	// Ease multiplies the interval after each correct review.
+	Ease float64
+	// LastReviewedAt is the time of the last review applied.
+	LastReviewedAt time.Time
+}
+
+// New returns the state of a word that has never been reviewed.
+func New() State {
+	return State{Ease: InitialEase}
+}
+
+// Next applies a review made at reviewedAt to s and returns the resulting state.
+func Next(s State, correct bool, reviewedAt time.Time) State {
+	if s.Ease == 0 {
+		s.Ease = InitialEase
+	}
+	if correct {
+		s.Repetitions++
+		switch s.Repetitions {
+		case 1:
+			s.IntervalDays = 1
+		case 2:
+			s.IntervalDays = 3
+		default:
+			s.IntervalDays = int(math.Round(float64(s.IntervalDays) * s.Ease))
+		}
+		s.Ease = math.Min(MaxEase, s.Ease+0.1)
+	} else {
+		s.Repetitions = 0
+		s.IntervalDays = 1
+		s.Ease = math.Max(MinEase, s.Ease-0.2)
+	}
+	s.LastReviewedAt = reviewedAt
+	return s
+}
+
+// NextReviewAt is when a word in state s is next due.
+func (s State) NextReviewAt() time.Time {
+	return s.LastReviewedAt.Add(time.Duration(s.IntervalDays) * 24 * time.Hour)
+}
@@ -0,0 +1,244 @@
+package testutil
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"backend_go/internal/service"
+)
+
+// timeFormat matches the format SQLite's CURRENT_TIMESTAMP writes into created_at columns.
+const timeFormat = "2006-01-02 15:04:05"
+
+// Fixture adds test data to a database. Each call adds rows and returns the fixture so calls can be
+// chained; the rows added last become the current group, word, study activity and session that
+// the following calls refer to. Groups and words are named by their name and japanese text, and
+// the ID of any row added can be looked up for assertions. Any SQL error fails the test at once.
+//
+// Rows are inserted directly: reviews added here do not reschedule their words or award
+// achievements, which only the service does when it records a review.
+type Fixture struct {
+	t  testing.TB
+	db *sql.DB
+
+	groups   map[string]int
+	words    map[string]int
+	user     int
+	group    int
+	word     int
+	activity int
+	session  int
+	sessions []int
+	reviews  []int
+	attempts map[[2]int]int
+}
+
+// NewFixture returns a fixture adding rows to db for t.
+func NewFixture(t testing.TB, db *sql.DB) *Fixture {
+	return &Fixture{
+		t:        t,
+		db:       db,
+		groups:   make(map[string]int),
+		words:    make(map[string]int),
+		attempts: make(map[[2]int]int),
+	}
+}
+
+// insert runs an INSERT of what and returns the ID of the row added, failing the test on error.
+func (f *Fixture) insert(what, query string, args ...interface{}) int {
+	f.t.Helper()
+	result, err := f.db.Exec(query, args...)
+	if err != nil {
+		f.t.Fatalf("fixture: adding %s: %v\n%s\nargs: %v", what, err, strings.TrimSpace(query), args)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		f.t.Fatalf("fixture: adding %s: %v", what, err)
+	}
+	return int(id)
+}
+
+// User makes the sessions and reviews added next belong to the user with the given ID, or to no
+// user when userID is 0.
+func (f *Fixture) User(userID int) *Fixture {
+	f.user = userID
+	return f
+}
+
+// Group adds a group and makes it the current group.
+func (f *Fixture) Group(name string) *Fixture {
+	f.t.Helper()
+	f.group = f.insert(fmt.Sprintf("group %q", name),
+		"INSERT INTO groups (name, created_at, updated_at) VALUES (?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)", name)
+	f.groups[name] = f.group
+	return f
+}
+
+// Word adds a word with english as its only meaning and makes it the current word. The normalized
+// columns are filled in as the service fills them.
+func (f *Fixture) Word(japanese, romaji, english string) *Fixture {
+	f.t.Helper()
+	what := fmt.Sprintf("word %q", japanese)
+	f.word = f.insert(what, `INSERT INTO words (japanese, japanese_normalized, script, romaji, romaji_normalized, english, updated_at)
+	                         VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, CURRENT_TIMESTAMP)`,
+		japanese, service.NormalizeJapanese(japanese), service.WordScript(japanese), romaji, service.NormalizeRomaji(romaji), english)
+	f.insert(what+" meaning", "INSERT INTO word_meanings (word_id, meaning, position) VALUES (?, ?, 0)", f.word, english)
+	f.words[japanese] = f.word
+	return f
+}
+
+// InGroup adds the current word to the group named name, which must have been added.
+func (f *Fixture) InGroup(name string) *Fixture {
+	f.t.Helper()
+	f.requireWord("InGroup")
+	f.insert(fmt.Sprintf("word %d to group %q", f.word, name),
+		"INSERT INTO word_groups (word_id, group_id) VALUES (?, ?)", f.word, f.GroupID(name))
+	return f
+}
+
+// Activity adds a study activity of the given type (one of service.StudyActivityTypes) and makes
+// it the current activity.
+func (f *Fixture) Activity(name, activityType string) *Fixture {
+	f.t.Helper()
+	f.activity = f.insert(fmt.Sprintf("study activity %q", name),
+		"INSERT INTO study_activities (name, type, study_session_id, group_id) VALUES (?, ?, 0, 0)", name, activityType)
+	return f
+}
+
+// Session starts a study session of the current group with the current activity, adding a
+// flashcards activity first if there is none, and makes it the current session.
+func (f *Fixture) Session() *Fixture {
+	f.t.Helper()
+	return f.SessionAt(time.Now())
+}
+
+// SessionAt starts a study session like Session, created at the given time.
+func (f *Fixture) SessionAt(at time.Time) *Fixture {
+	f.t.Helper()
+	if f.group == 0 {
+		f.t.Fatalf("fixture: Session called before Group")
+	}
+	if f.activity == 0 {
+		f.Activity("Flashcards", "flashcards")
+	}
+	f.session = f.insert(fmt.Sprintf("study session of group %d", f.group),
+		"INSERT INTO study_sessions (group_id, study_activity_id, user_id, created_at) VALUES (?, ?, ?, ?)",
+		f.group, f.activity, f.owner(), at.UTC().Format(timeFormat))
+	f.sessions = append(f.sessions, f.session)
+	return f
+}
+
+// End ends the current session at the given time.
+func (f *Fixture) End(at time.Time) *Fixture {
+	f.t.Helper()
+	f.requireSession("End")
+	if _, err := f.db.Exec("UPDATE study_sessions SET ended_at = ? WHERE id = ?", at.UTC().Format(timeFormat), f.session); err != nil {
+		f.t.Fatalf("fixture: ending study session %d: %v", f.session, err)
+	}
+	return f
+}
+
+// Review records a review of the current word in the current session, made now. Reviewing the
+// same word again in a session records the next attempt.
+func (f *Fixture) Review(correct bool) *Fixture {
+	f.t.Helper()
+	return f.ReviewAt(correct, time.Now())
+}
+
+// ReviewAt records a review like Review, made at the given time.
+func (f *Fixture) ReviewAt(correct bool, at time.Time) *Fixture {
+	f.t.Helper()
+	return f.review(correct, false, at)
+}
+
+// Skip records a skipped review of the current word in the current session, made now.
+func (f *Fixture) Skip() *Fixture {
+	f.t.Helper()
+	return f.SkipAt(time.Now())
+}
+
+// SkipAt records a skipped review like Skip, made at the given time.
+func (f *Fixture) SkipAt(at time.Time) *Fixture {
+	f.t.Helper()
+	return f.review(false, true, at)
+}
+
+func (f *Fixture) review(correct, skipped bool, at time.Time) *Fixture {
+	f.t.Helper()
+	f.requireWord("Review")
+	f.requireSession("Review")
+	key := [2]int{f.session, f.word}
+	f.attempts[key]++
+	id := f.insert(fmt.Sprintf("review of word %d in session %d", f.word, f.session),
+		`INSERT INTO word_review_items (word_id, study_session_id, correct, skipped, attempt, user_id, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		f.word, f.session, correct, skipped, f.attempts[key], f.owner(), at.UTC().Format(timeFormat))
+	f.reviews = append(f.reviews, id)
+	return f
+}
+
+// owner returns the user_id of the sessions and reviews added, nil without a user.
+func (f *Fixture) owner() interface{} {
+	if f.user == 0 {
+		return nil
+	}
+	return f.user
+}
+
+func (f *Fixture) requireWord(call string) {
+	f.t.Helper()
+	if f.word == 0 {
+		f.t.Fatalf("fixture: %s called before Word", call)
+	}
+}
+
+func (f *Fixture) requireSession(call string) {
+	f.t.Helper()
+	if f.session == 0 {
+		f.t.Fatalf("fixture: %s called before Session", call)
+	}
+}
+
+// GroupID returns the ID of the group named name, failing the test if it was not added.
+func (f *Fixture) GroupID(name string) int {
+	f.t.Helper()
+	id, ok := f.groups[name]
+	if !ok {
+		f.t.Fatalf("fixture: no group %q was added", name)
+	}
+	return id
+}
+
+// WordID returns the ID of the word with the given japanese text, failing the test if it was not
+// added.
+func (f *Fixture) WordID(japanese string) int {
+	f.t.Helper()
+	id, ok := f.words[japanese]
+	if !ok {
+		f.t.Fatalf("fixture: no word %q was added", japanese)
+	}
+	return id
+}
+
+// ActivityID returns the ID of the current study activity.
+func (f *Fixture) ActivityID() int {
+	return f.activity
+}
+
+// SessionID returns the ID of the current study session.
+func (f *Fixture) SessionID() int {
+	return f.session
+}
+
+// SessionIDs returns the IDs of the study sessions added, in order.
+func (f *Fixture) SessionIDs() []int {
+	return append([]int(nil), f.sessions...)
+}
+
+// ReviewIDs returns the IDs of the reviews added, in order.
+func (f *Fixture) ReviewIDs() []int {
+	return append([]int(nil), f.reviews...)
+}
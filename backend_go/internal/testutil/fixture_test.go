@@ -0,0 +1,76 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"backend_go/internal/service"
+)
+
+func TestFixture(t *testing.T) {
+	svc := NewService(t)
+	f := NewFixture(t, svc.DB).
+		Group("N5").
+		Word("食べる", "taberu", "to eat").InGroup("N5").
+		Word("飲む", "nomu", "to drink").InGroup("N5").
+		Session().Review(true).Review(false).Skip().
+		Word("すし", "sushi", "sushi")
+
+	words, err := svc.GetGroupWords(f.GroupID("N5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 2 || words[0].ID != f.WordID("食べる") || words[1].ID != f.WordID("飲む") {
+		t.Fatalf("group words = %+v, want 食べる and 飲む", words)
+	}
+	if len(words[0].Meanings) != 1 || words[0].Meanings[0] != "to eat" {
+		t.Errorf("meanings = %v, want [to eat]", words[0].Meanings)
+	}
+	kana, err := svc.GetWords(nil, service.WordFilter{Script: service.ScriptKana})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kana) != 1 || kana[0].ID != f.WordID("すし") {
+		t.Errorf("words in kana = %+v, want すし", kana)
+	}
+
+	session, err := svc.GetStudySessionByID(f.SessionID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.GroupID != f.GroupID("N5") || session.StudyActivityID != f.ActivityID() {
+		t.Errorf("session = %+v, want group %d and activity %d", session, f.GroupID("N5"), f.ActivityID())
+	}
+
+	history, err := svc.GetWordHistory(f.WordID("飲む"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("history has %d reviews, want 3", len(history))
+	}
+	for i, id := range f.ReviewIDs() {
+		if history[i].ReviewID != id {
+			t.Errorf("review %d has ID %d, want %d", i, history[i].ReviewID, id)
+		}
+	}
+	if !history[2].Skipped || history[2].RunningAccuracy != 0.5 {
+		t.Errorf("last review = %+v, want skipped with accuracy 0.5", history[2])
+	}
+}
+
+func TestFixtureTimes(t *testing.T) {
+	svc := NewService(t)
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	f := NewFixture(t, svc.DB).
+		Group("N5").Word("水", "mizu", "water").
+		SessionAt(start).ReviewAt(true, start.Add(time.Minute)).End(start.Add(10 * time.Minute))
+
+	session, err := svc.GetStudySessionByID(f.SessionID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.CreatedAt.Equal(start) || session.EndedAt == nil || !session.EndedAt.Equal(start.Add(10*time.Minute)) {
+		t.Errorf("session = %+v, want it from %v to %v", session, start, start.Add(10*time.Minute))
+	}
+}
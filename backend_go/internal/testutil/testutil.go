@@ -0,0 +1,40 @@
+// Package testutil sets up databases and test data for the backend's tests.
+//
+// NewService opens a migrated service on a database of its own, and Fixture adds rows to it with a
+// fluent builder that fails the test on the first SQL error:
+//
+//	f := testutil.NewFixture(t, svc.DB).
+//		Group("N5").
+//		Word("食べる", "taberu", "to eat").InGroup("N5").
+//		Session().Review(true).Review(false)
+//	wordID := f.WordID("食べる")
+package testutil
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"backend_go/internal/service"
+)
+
+// migrationsDir returns the db/migrations directory of the backend, wherever the tests run from.
+func migrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "db", "migrations")
+}
+
+// NewService opens a service on a new database in a temporary directory of t and applies every
+// migration. The database starts without seed data and is closed when the test ends.
+func NewService(t testing.TB) *service.Service {
+	t.Helper()
+	svc, err := service.Open(filepath.Join(t.TempDir(), "words.db"))
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	t.Cleanup(func() { svc.Close() })
+	if err := service.MigrateDir(svc.DB, migrationsDir()); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+	return svc
+}
@@ -0,0 +1,113 @@
+// Package tts synthesizes pronunciation audio through an external text-to-speech command or
+// HTTP endpoint.
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxAudioBytes caps the audio accepted from a synthesizer.
+const maxAudioBytes = 10 << 20
+
+// ErrTooLarge is returned when a synthesizer produces more than maxAudioBytes.
+var ErrTooLarge = errors.New("synthesized audio is too large")
+
+// Synthesizer turns japanese text into MP3 or Ogg audio.
+type Synthesizer interface {
+	Synthesize(ctx context.Context, text string) ([]byte, error)
+}
+
+// Command runs a shell command that reads the text on stdin and writes the audio to stdout.
+type Command struct {
+	Command string
+}
+
+// Synthesize implements Synthesizer. The command is killed when ctx is done.
+func (s Command) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.Command)
+	cmd.Stdin = strings.NewReader(text)
+	// Children of the shell may keep stdout open after it is killed, so stop waiting for them
+	cmd.WaitDelay = time.Second
+	stdout := &limitedBuffer{max: maxAudioBytes}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if stdout.exceeded {
+		return nil, ErrTooLarge
+	}
+	if err != nil {
+		msg := stderr.String()
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		return nil, fmt.Errorf("tts command: %w: %s", err, strings.TrimSpace(msg))
+	}
+	return stdout.buf.Bytes(), nil
+}
+
+// HTTP posts {"text": "..."} to an endpoint that answers with the audio.
+type HTTP struct {
+	URL    string
+	Client *http.Client
+}
+
+// Synthesize implements Synthesizer.
+func (s HTTP) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("tts endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tts endpoint answered %d", resp.StatusCode)
+	}
+	audio, err := io.ReadAll(io.LimitReader(resp.Body, maxAudioBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("tts endpoint: %w", err)
+	}
+	if len(audio) > maxAudioBytes {
+		return nil, ErrTooLarge
+	}
+	return audio, nil
+}
+
+// limitedBuffer is a writer that fails once more than max bytes have been written to it.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	max      int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) > b.max {
+		b.exceeded = true
+		return 0, ErrTooLarge
+	}
+	return b.buf.Write(p)
+}
@@ -0,0 +1,29 @@
+// Package version identifies the running build. Version and Commit are set when linking:
+//
+//	go build -ldflags "-X backend_go/internal/version.Version=v1.4.0 -X backend_go/internal/version.Commit=$(git rev-parse --short HEAD)" ./cmd/server
+package version
+
+import "runtime/debug"
+
+// Version is the release of the build, "dev" when it was not set at link time.
+var Version = "dev"
+
+// Commit is the git commit the build was made from. When it was not set at link time it is read
+// from the VCS information Go embeds in binaries built inside a git checkout, "unknown" without
+// either.
+var Commit = ""
+
+// BuildCommit returns Commit, falling back as described there.
+func BuildCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}